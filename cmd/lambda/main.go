@@ -1,41 +1,92 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/json-to-terraform/parser/internal/apigw"
+	"github.com/json-to-terraform/parser/internal/archive"
+	"github.com/json-to-terraform/parser/internal/cost"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/jobs"
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/logger"
 	"github.com/json-to-terraform/parser/internal/parser"
+	"github.com/json-to-terraform/parser/internal/policy"
 	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/server"
+	"github.com/json-to-terraform/parser/internal/terraform"
 )
 
-// LambdaEvent is the invocation payload (e.g. from API Gateway).
+// LambdaEvent is the legacy invocation payload (direct invoke, or API Gateway with a mapping
+// template). API Gateway proxy and Function URL events are recognized and served by api.
 type LambdaEvent struct {
-	Body   string            `json:"body"`             // diagram JSON (raw or base64 if isBase64)
-	IsBase64 bool            `json:"isBase64,omitempty"`
-	EmitTfvars *bool         `json:"emitTfvars,omitempty"`
+	Body          string   `json:"body"` // diagram JSON (raw or base64 if isBase64)
+	IsBase64      bool     `json:"isBase64,omitempty"`
+	EmitTfvars    *bool    `json:"emitTfvars,omitempty"`
+	Strict        bool     `json:"strict,omitempty"`        // reject unknown diagram fields
+	NameFromLabel bool     `json:"nameFromLabel,omitempty"` // derive resource names from node labels
+	ImportNodes   []string `json:"importNodes,omitempty"`   // only these nodes get import blocks (default: all with import_id)
+	NodeComments  bool     `json:"nodeComments,omitempty"`  // write a "# node: <id> (<label>)" comment above each generated block
+	Format        string   `json:"format,omitempty"`        // json (default: files base64 in the JSON response), zip or tar.gz (the archive as the base64 body)
 }
 
 // LambdaResponse is returned to the client (API Gateway).
 type LambdaResponse struct {
-	StatusCode int               `json:"statusCode"`
-	Success    bool              `json:"success"`
-	Errors     []result.Error    `json:"errors,omitempty"`
-	Warnings   []result.Warning  `json:"warnings,omitempty"`
-	Files      map[string]string `json:"files,omitempty"` // filename -> content (base64)
+	StatusCode int                 `json:"statusCode"`
+	Success    bool                `json:"success"`
+	Errors     []result.Error      `json:"errors,omitempty"`
+	Warnings   []result.Warning    `json:"warnings,omitempty"`
+	Files      map[string]string   `json:"files,omitempty"`     // filename -> content (base64)
+	SourceMap  terraform.SourceMap `json:"sourceMap,omitempty"` // generated lines -> diagram nodes and properties
+	Cost       *cost.Estimate      `json:"cost,omitempty"`      // monthly cost estimate of the generated resources
 }
 
 // APIGatewayResponse is the shape expected by API Gateway proxy integration (body = JSON string).
-type APIGatewayResponse struct {
-	StatusCode int               `json:"statusCode"`
-	Headers    map[string]string `json:"headers,omitempty"`
-	Body       string            `json:"body"`
+type APIGatewayResponse = apigw.Response
+
+// api serves API Gateway REST and HTTP API events and Function URL events, with the routes
+// and query parameters of cmd/server; set up by main.
+var api http.Handler
+
+// options are the parser options of every request: the defaults with the lint rules,
+// policies and plaintext-secret permission of the function's configuration; set up by main.
+var options parser.Options
+
+// jobService runs asynchronous jobs when JOBS_BUCKET is set; nil otherwise.
+var jobService *jobs.Service
+
+// handler serves HTTP events through api, runs the jobs of S3 notifications and serves
+// any other payload as a LambdaEvent.
+func handler(ctx context.Context, raw json.RawMessage) (APIGatewayResponse, error) {
+	if resp, ok := apigw.Handle(ctx, api, raw); ok {
+		return resp, nil
+	}
+	if records := s3Records(raw); jobService != nil && records != nil {
+		return APIGatewayResponse{StatusCode: 200}, runJobs(ctx, records)
+	}
+	var event LambdaEvent
+	if err := json.Unmarshal(raw, &event); err != nil {
+		return wrap(LambdaResponse{StatusCode: 400, Errors: []result.Error{{Type: "invalid_input", Severity: "error", Message: "invalid event: " + err.Error()}}}), nil
+	}
+	return legacyHandler(ctx, event)
 }
 
-func handler(ctx context.Context, event LambdaEvent) (APIGatewayResponse, error) {
+// legacyHandler serves the LambdaEvent payload of direct invocations and mapping templates.
+func legacyHandler(ctx context.Context, event LambdaEvent) (APIGatewayResponse, error) {
 	out := LambdaResponse{StatusCode: 200}
 
 	body := event.Body
@@ -50,20 +101,35 @@ func handler(ctx context.Context, event LambdaEvent) (APIGatewayResponse, error)
 		body = string(dec)
 	}
 
-	var d diagram.Diagram
-	if err := json.Unmarshal([]byte(body), &d); err != nil {
+	var archiveFormat archive.Format
+	switch event.Format {
+	case "", "json":
+	case archive.ZipFormat.Name, archive.TarGzFormat.Name:
+		archiveFormat, _ = archive.Lookup(event.Format)
+	default:
 		out.StatusCode = 400
 		out.Success = false
-		out.Errors = []result.Error{{Type: "invalid_json", Severity: "error", Message: "invalid diagram JSON: " + err.Error()}}
+		out.Errors = []result.Error{{Type: "invalid_input", Severity: "error", Message: "unknown format " + event.Format + ": use json, zip or tar.gz"}}
 		return wrap(out), nil
 	}
 
-	opts := parser.DefaultOptions()
+	d, err := diagram.Decode([]byte(body), event.Strict)
+	if err != nil {
+		out.StatusCode = 400
+		out.Success = false
+		out.Errors = parser.DecodeErrors(err)
+		return wrap(out), nil
+	}
+
+	opts := options
 	if event.EmitTfvars != nil {
 		opts.EmitTfvars = *event.EmitTfvars
 	}
+	opts.NameFromLabel = event.NameFromLabel
+	opts.ImportNodes = event.ImportNodes
+	opts.NodeComments = event.NodeComments
 	p := parser.New(opts)
-	res, err := p.Parse(&d)
+	res, err := p.Parse(d)
 	if err != nil {
 		out.StatusCode = 500
 		out.Success = false
@@ -74,11 +140,16 @@ func handler(ctx context.Context, event LambdaEvent) (APIGatewayResponse, error)
 	out.Success = res.Success
 	out.Errors = res.Errors
 	out.Warnings = res.Warnings
+	out.Cost = res.Cost
+	if res.Success && archiveFormat.Write != nil {
+		return wrapArchive(archiveFormat, res.TerraformFiles)
+	}
 	if res.Success && len(res.TerraformFiles) > 0 {
 		out.Files = make(map[string]string)
 		for name, content := range res.TerraformFiles {
 			out.Files[name] = base64.StdEncoding.EncodeToString(content)
 		}
+		out.SourceMap = res.SourceMap
 	}
 	if !res.Success {
 		out.StatusCode = 422
@@ -95,6 +166,111 @@ func wrap(out LambdaResponse) APIGatewayResponse {
 	}
 }
 
+// wrapArchive returns the files as an archive: the base64 body API Gateway decodes into
+// the binary response. Errors and warnings are not part of it.
+func wrapArchive(f archive.Format, files map[string][]byte) (APIGatewayResponse, error) {
+	var buf bytes.Buffer
+	if err := f.Write(&buf, files); err != nil {
+		return wrap(LambdaResponse{StatusCode: 500, Errors: []result.Error{{Type: "internal_error", Severity: "error", Message: err.Error()}}}), nil
+	}
+	return APIGatewayResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":        f.MediaType,
+			"Content-Disposition": `attachment; filename="terraform` + f.Ext + `"`,
+		},
+		Body:            base64.StdEncoding.EncodeToString(buf.Bytes()),
+		IsBase64Encoded: true,
+	}, nil
+}
+
+// s3Records returns the records of an S3 event notification, nil for any other payload.
+func s3Records(raw json.RawMessage) []events.S3EventRecord {
+	var e events.S3Event
+	if json.Unmarshal(raw, &e) != nil || len(e.Records) == 0 || e.Records[0].EventSource != "aws:s3" {
+		return nil
+	}
+	return e.Records
+}
+
+// runJobs is the worker: the bucket notifies the function of every new request.json. An
+// error makes Lambda retry the invocation; jobs that already ran are skipped then.
+func runJobs(ctx context.Context, records []events.S3EventRecord) error {
+	for _, r := range records {
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return err
+		}
+		if id, ok := jobs.JobID(key); ok {
+			if err := jobService.Run(ctx, id); err != nil {
+				return fmt.Errorf("job %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// newJobStore returns the S3 store of the jobs: JOBS_BUCKET, with keys under JOBS_PREFIX.
+// JOBS_ENDPOINT points it at an S3-compatible service such as MinIO instead of AWS.
+func newJobStore(ctx context.Context, bucket string) (jobs.Store, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := os.Getenv("JOBS_ENDPOINT"); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		}
+	})
+	return jobs.S3Store{Client: client, Bucket: bucket, Prefix: os.Getenv("JOBS_PREFIX")}, nil
+}
+
+// loadOptions returns the default parser options adjusted by the environment: LINT_RULES_FILE
+// and POLICY_FILE name a lint rules file and a policy file (as -rules and -policy of
+// cmd/server), and ALLOW_PLAINTEXT_SECRETS=true accepts secret properties in production
+// diagrams. They are configuration, so every caller is held to the same rules.
+func loadOptions() (parser.Options, error) {
+	opts := parser.DefaultOptions()
+	var err error
+	if v := os.Getenv("ALLOW_PLAINTEXT_SECRETS"); v != "" {
+		if opts.AllowPlaintextSecrets, err = strconv.ParseBool(v); err != nil {
+			return opts, fmt.Errorf("ALLOW_PLAINTEXT_SECRETS: %w", err)
+		}
+	}
+	if file := os.Getenv("LINT_RULES_FILE"); file != "" {
+		if opts.Lint, err = lint.LoadConfig(file); err != nil {
+			return opts, fmt.Errorf("rules: %w", err)
+		}
+	}
+	if file := os.Getenv("POLICY_FILE"); file != "" {
+		if opts.Policies, err = policy.Load(file); err != nil {
+			return opts, fmt.Errorf("policy: %w", err)
+		}
+	}
+	return opts, nil
+}
+
 func main() {
+	var err error
+	if options, err = loadOptions(); err != nil {
+		logger.Default.Error("configuration", "error", err)
+		os.Exit(1)
+	}
+	cfg := server.Config{Options: options, Logger: logger.Default}
+	if bucket := os.Getenv("JOBS_BUCKET"); bucket != "" {
+		store, err := newJobStore(context.Background(), bucket)
+		if err != nil {
+			logger.Default.Error("jobs store", "error", err)
+			os.Exit(1)
+		}
+		jobService = &jobs.Service{Store: store}
+		cfg.Jobs = jobService
+	}
+	api = server.New(cfg)
+	if jobService != nil {
+		jobService.API = api
+	}
 	lambda.Start(handler)
 }
@@ -1,89 +1,547 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"text/tabwriter"
 
-	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/archive"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/diff"
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/output"
 	"github.com/json-to-terraform/parser/internal/parser"
+	"github.com/json-to-terraform/parser/internal/policy"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/tfcli"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "schema":
+			runSchema(os.Args[2:])
+			return
+		case "cost":
+			runCost(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+
 	input := flag.String("input", "", "Path to diagram JSON file (or - for stdin)")
-	output := flag.String("o", "output", "Output directory for Terraform files")
+	outDir := flag.String("o", "output", "Output directory for Terraform files, or the archive file for -format zip/tar.gz (- for stdout)")
+	format := flag.String("format", "dir", "Output format: dir, stdout (all files as one text stream), zip or tar.gz")
 	noTfvars := flag.Bool("no-tfvars", false, "Do not generate terraform.tfvars")
 	parallel := flag.Int("parallel", 0, "Max parallel nodes per tier (0 = auto)")
 	jsonOut := flag.Bool("json", false, "Output errors as JSON")
+	timings := flag.Bool("timings", false, "Print per-tier handler timings to stderr")
+	timeout := flag.Duration("timeout", 0, "Abort generation after this long (0 = no limit)")
+	strict := flag.Bool("strict", false, "Reject unknown fields in the diagram JSON")
+	noValidateHCL := flag.Bool("no-validate-hcl", false, "Do not re-parse the generated files and check their references")
+	validateSchema := flag.Bool("validate-schema", false, "Check generated resources against the bundled AWS provider schema")
+	rulesFile := flag.String("rules", "", "Lint rules file adjusting rule severities (JSON)")
+	policyFile := flag.String("policy", "", "Policy file with CEL conditions the generated resources must satisfy (JSON)")
+	planOut := flag.String("plan-out", "", "Also write the normalized plan model (JSON) to this file")
+	sourceMapOut := flag.String("sourcemap-out", "", "Also write the source map from generated lines to diagram nodes (JSON) to this file")
+	nodeComments := flag.Bool("node-comments", false, "Write a \"# node: <id> (<label>)\" comment above every generated block")
+	dryRun := flag.Bool("dry-run", false, "Show which files would be created, updated or removed without writing")
+	force := flag.Bool("force", false, "Overwrite generated files that were edited by hand")
+	allowSecrets := flag.Bool("allow-plaintext-secrets", false, "Accept secret properties (e.g. an RDS password) in production diagrams")
+	importNodes := flag.String("import", "", "Comma-separated node ids whose import_id becomes an import block (default: every node with an import_id)")
+	nameFromLabel := flag.Bool("name-from-label", false, "Derive resource names from node labels instead of node ids")
+	previous := flag.String("previous", "", "Previous version of the diagram; renamed resources get moved blocks (default: the snapshot in the output manifest)")
+	tfValidate := flag.Bool("tf-validate", false, "Run terraform (or tofu) fmt -check, init -backend=false and validate on the generated files")
+	tfPlan := flag.Bool("tf-plan", false, "Like -tf-validate, then also run terraform plan (needs provider credentials)")
+	tfBin := flag.String("tf-bin", "", "terraform or tofu binary for -tf-validate/-tf-plan (default: terraform, else tofu, from PATH)")
 	flag.Parse()
 
 	if *input == "" {
-		fmt.Fprintln(os.Stderr, "usage: parser -input <file|-> [-o output] [-no-tfvars] [-parallel N] [-json]")
+		fmt.Fprintln(os.Stderr, "usage: parser -input <file|-> [-o output] [-format dir|stdout|zip|tar.gz] [-no-tfvars] [-parallel N] [-json] [-strict] [-no-validate-hcl] [-validate-schema] [-rules file] [-policy file] [-plan-out file] [-sourcemap-out file] [-node-comments] [-allow-plaintext-secrets] [-name-from-label] [-import ids] [-previous file] [-tf-validate] [-tf-plan] [-tf-bin path] [-dry-run] [-force] [-timings] [-timeout D]")
+		fmt.Fprintln(os.Stderr, "       parser schema [-o file]")
+		fmt.Fprintln(os.Stderr, "       parser cost -input <file|-> [-json] [-strict]")
+		fmt.Fprintln(os.Stderr, "       parser diff [-json] [-strict] [-fail-on-destructive] old.json new.json")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	var data []byte
-	var err error
-	if *input == "-" {
-		data, err = io.ReadAll(os.Stdin)
-	} else {
-		data, err = os.ReadFile(*input)
-	}
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "read input: %v\n", err)
-		os.Exit(1)
+	var stream archive.Format // zero for -format dir
+	if *format != "dir" {
+		var ok bool
+		if stream, ok = archive.Lookup(*format); !ok {
+			fmt.Fprintf(os.Stderr, "unknown -format %q: use dir, stdout, zip or tar.gz\n", *format)
+			os.Exit(1)
+		}
+		if *dryRun || *force {
+			fmt.Fprintln(os.Stderr, "-dry-run and -force only apply to -format dir")
+			os.Exit(1)
+		}
 	}
 
-	var d diagram.Diagram
-	if err := json.Unmarshal(data, &d); err != nil {
-		fmt.Fprintf(os.Stderr, "parse JSON: %v\n", err)
-		os.Exit(1)
-	}
+	d := readDiagram(*input, *strict, *jsonOut)
+	var err error
 
 	opts := parser.DefaultOptions()
 	opts.EmitTfvars = !*noTfvars
 	opts.MaxParallel = *parallel
+	opts.ValidateHCL = !*noValidateHCL
+	opts.ValidateSchema = *validateSchema
+	opts.AllowPlaintextSecrets = *allowSecrets
+	opts.NameFromLabel = *nameFromLabel
+	opts.NodeComments = *nodeComments
+	if *importNodes != "" {
+		opts.ImportNodes = strings.Split(*importNodes, ",")
+	}
+	if *rulesFile != "" {
+		if opts.Lint, err = lint.LoadConfig(*rulesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "rules: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *policyFile != "" {
+		if opts.Policies, err = policy.Load(*policyFile); err != nil {
+			fmt.Fprintf(os.Stderr, "policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *tfValidate || *tfPlan {
+		bin, err := tfcli.Find(*tfBin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "terraform: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Terraform = &tfcli.Runner{Binary: bin, Plan: *tfPlan}
+	}
+	if *previous != "" {
+		// Only the addresses matter; the old diagram need not pass today's rules and policies
+		prevOpts := parser.DefaultOptions()
+		prevOpts.AllowPlaintextSecrets = true
+		prevOpts.NameFromLabel = *nameFromLabel
+		opts.Previous = generateWith(prevOpts, readDiagram(*previous, *strict, *jsonOut), *previous, *jsonOut).Snapshot
+	} else if stream.Write == nil {
+		m, err := output.ReadManifest(*outDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "output: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Previous = m.Snapshot
+	}
 	p := parser.New(opts)
-	result, err := p.Parse(&d)
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	res, err := p.ParseContext(ctx, d)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "parse: %v\n", err)
 		os.Exit(1)
 	}
+	if *timings {
+		for _, t := range res.Tiers {
+			fmt.Fprintf(os.Stderr, "tier %d: %d nodes in %s\n", t.Tier, t.Nodes, t.Duration)
+		}
+	}
+
+	if !res.Success {
+		printFailure(res, *jsonOut)
+		os.Exit(1)
+	}
+	printWarnings(res.Warnings)
 
-	if !result.Success {
-		if *jsonOut {
-			enc := json.NewEncoder(os.Stdout)
-			enc.SetIndent("", "  ")
-			_ = enc.Encode(result)
+	status := os.Stdout // progress messages
+	if stream.Write != nil {
+		path := writeStream(stream, *outDir, res.TerraformFiles)
+		if path == "" {
+			status = os.Stderr
 		} else {
-			for _, e := range result.Errors {
-				fmt.Fprintf(os.Stderr, "ERROR [%s] %s\n", e.NodeID, e.Message)
-				if e.Suggestion != "" {
-					fmt.Fprintf(os.Stderr, "  suggestion: %s\n", e.Suggestion)
-				}
-			}
-			for _, w := range result.Warnings {
-				fmt.Fprintf(os.Stderr, "WARN [%s] %s\n", w.NodeID, w.Message)
-			}
+			fmt.Println("wrote", path)
 		}
+	} else if !writeDir(*outDir, res, *force, *dryRun) {
+		return
+	}
+	if *planOut != "" {
+		writeJSONFile(status, *planOut, res.Plan)
+	}
+	if *sourceMapOut != "" {
+		writeJSONFile(status, *sourceMapOut, res.SourceMap)
+	}
+}
+
+// writeJSONFile writes v as indented JSON to path, exiting on failure, and reports it on status.
+func writeJSONFile(status *os.File, path string, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
 		os.Exit(1)
 	}
+	fmt.Fprintln(status, "wrote", path)
+}
 
-	if err := os.MkdirAll(*output, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "mkdir: %v\n", err)
+// writeDir updates outDir to hold exactly the generated files (see output.Prepare) and
+// reports what changed. It returns false after a dry run, when nothing was written.
+func writeDir(outDir string, res *result.ParseResult, force, dryRun bool) bool {
+	cs, err := output.Prepare(outDir, res.TerraformFiles, force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "output: %v\n", err)
 		os.Exit(1)
 	}
-	for name, content := range result.TerraformFiles {
-		path := filepath.Join(*output, name)
-		if err := os.WriteFile(path, content, 0644); err != nil {
-			fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
+	cs.Record(res.Snapshot)
+	conflicts := cs.Conflicts()
+	if dryRun {
+		for _, c := range cs.Changes {
+			fmt.Printf("%-9s %s%s\n", c.Action, filepath.Join(outDir, c.Name), reason(c.Reason))
+		}
+		if len(conflicts) > 0 {
+			os.Exit(1)
+		}
+		return false
+	}
+	if len(conflicts) > 0 {
+		for _, c := range conflicts {
+			fmt.Fprintf(os.Stderr, "CONFLICT %s: %s\n", filepath.Join(outDir, c.Name), c.Reason)
+		}
+		fmt.Fprintln(os.Stderr, "nothing written; move manual changes into a custom region or an *_override.tf file, or rerun with -force to overwrite them")
+		os.Exit(1)
+	}
+	if err := cs.Apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "write: %v\n", err)
+		os.Exit(1)
+	}
+	for _, c := range cs.Changes {
+		switch c.Action {
+		case output.Create, output.Update:
+			fmt.Println("wrote", filepath.Join(outDir, c.Name))
+		case output.Delete:
+			fmt.Println("removed", filepath.Join(outDir, c.Name)+reason(c.Reason))
+		}
+	}
+	return true
+}
+
+// writeStream writes the files in a single-stream format: to stdout for -format stdout or
+// -o -, otherwise to the file path (given the format's extension if it lacks it). It
+// returns the file written, "" for stdout.
+func writeStream(f archive.Format, path string, files map[string][]byte) string {
+	if f.Ext == "" || path == "-" {
+		if err := f.Write(os.Stdout, files); err != nil {
+			fmt.Fprintf(os.Stderr, "write: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("wrote", path)
+		return ""
+	}
+	if !strings.HasSuffix(path, f.Ext) {
+		path += f.Ext
+	}
+	var buf bytes.Buffer
+	err := f.Write(&buf, files)
+	if err == nil {
+		err = os.WriteFile(path, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", path, err)
+		os.Exit(1)
+	}
+	return path
+}
+
+// runSchema prints the diagram JSON Schema generated from the registered handlers.
+func runSchema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	output := fs.String("o", "", "Write the schema to this file instead of stdout")
+	fs.Parse(args)
+
+	data, err := json.MarshalIndent(registry.Default.DiagramSchema(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "schema: %v\n", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "write %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+}
+
+// runCost parses the diagram and prints the monthly cost estimate of the generated resources.
+func runCost(args []string) {
+	fs := flag.NewFlagSet("cost", flag.ExitOnError)
+	input := fs.String("input", "", "Path to diagram JSON file (or - for stdin)")
+	jsonOut := fs.Bool("json", false, "Print the estimate (or errors) as JSON")
+	strict := fs.Bool("strict", false, "Reject unknown fields in the diagram JSON")
+	fs.Parse(args)
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "usage: parser cost -input <file|-> [-json] [-strict]")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	res := generate(readDiagram(*input, *strict, *jsonOut), *input, *jsonOut)
+	est := res.Cost
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(est)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "NODE\tRESOURCE\tITEM\t%10s\n", "MONTHLY")
+	for _, n := range est.Nodes {
+		for _, it := range n.Items {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%10.2f\n", n.NodeID, it.Address, it.Description, it.Monthly)
+		}
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t%10.2f %s\n", est.MonthlyTotal, est.Currency)
+	tw.Flush()
+	if len(est.Unpriced) > 0 {
+		fmt.Println("\nNot estimated:")
+		for _, u := range est.Unpriced {
+			fmt.Printf("  %s [%s]: %s\n", u.Address, u.NodeID, u.Reason)
+		}
+	}
+	fmt.Println("\nAssumptions:")
+	for _, a := range est.Assumptions {
+		fmt.Println("  " + a)
+	}
+}
+
+// runDiff compares two diagram versions and the resources generated from each.
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the report (or errors) as JSON")
+	strict := fs.Bool("strict", false, "Reject unknown fields in the diagram JSON")
+	failDestructive := fs.Bool("fail-on-destructive", false, "Exit with status 2 when a resource would be deleted or replaced")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: parser diff [-json] [-strict] [-fail-on-destructive] old.json new.json")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+
+	oldD := readDiagram(fs.Arg(0), *strict, *jsonOut)
+	newD := readDiagram(fs.Arg(1), *strict, *jsonOut)
+	report := diff.Diagrams(oldD, newD, registry.Default)
+	// The new version is generated as an update of the old one, so renamed nodes get moved
+	// blocks and are reported as moves
+	oldRes := generate(oldD, fs.Arg(0), *jsonOut)
+	opts := parser.DefaultOptions()
+	opts.AllowPlaintextSecrets = true
+	opts.Previous = oldRes.Snapshot
+	newRes := generateWith(opts, newD, fs.Arg(1), *jsonOut)
+	report.Resources = diff.Resources(oldRes.Plan, newRes.Plan, newRes.Snapshot.Moved)
+
+	destructive := false
+	for _, c := range report.Resources {
+		destructive = destructive || c.Destructive
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+	} else {
+		printDiff(report)
+	}
+	if destructive && *failDestructive {
+		os.Exit(2)
+	}
+}
+
+// diffSymbols prefix changes in the text report, as in terraform plan.
+var diffSymbols = map[string]string{
+	diff.Added: "+", diff.Removed: "-", diff.Modified: "~",
+	diff.Create: "+", diff.Delete: "-", diff.Update: "~", diff.Replace: "-/+", diff.Move: "->",
+}
+
+func printDiff(r *diff.Report) {
+	if r.Empty() {
+		fmt.Println("No changes.")
+		return
+	}
+	printChanges := func(changes []diff.Change) {
+		for _, c := range changes {
+			fmt.Printf("      %s: %s -> %s\n", c.Path, jsonText(c.Old), jsonText(c.New))
+		}
+	}
+	if len(r.Metadata) > 0 {
+		fmt.Println("Metadata:")
+		printChanges(r.Metadata)
+	}
+	if len(r.Nodes) > 0 {
+		fmt.Println("Nodes:")
+		for _, n := range r.Nodes {
+			fmt.Printf("  %s %s (%s)\n", diffSymbols[n.Action], n.NodeID, n.Type)
+			printChanges(n.Changes)
+		}
+	}
+	if len(r.Edges) > 0 {
+		fmt.Println("Edges:")
+		for _, e := range r.Edges {
+			fmt.Printf("  %s %s: %s -> %s (%s)\n", diffSymbols[e.Action], e.EdgeID, e.Source, e.Target, e.Type)
+			printChanges(e.Changes)
+		}
+	}
+	counts := make(map[string]int)
+	destructive := 0
+	if len(r.Resources) > 0 {
+		fmt.Println("Resources:")
+	}
+	for _, c := range r.Resources {
+		counts[c.Action]++
+		line := fmt.Sprintf("  %s %s [%s]", diffSymbols[c.Action], c.Address, c.NodeID)
+		if c.MovedFrom != "" {
+			line += " (moved from " + c.MovedFrom + ")"
+		}
+		if c.Reason != "" {
+			line += ": " + c.Reason
+		}
+		if c.Destructive {
+			destructive++
+			line += " (destructive)"
+		}
+		fmt.Println(line)
+		for _, a := range c.Attributes {
+			mark := ""
+			if a.ForcesReplacement {
+				mark = " # forces replacement"
+			}
+			fmt.Printf("      %s: %s -> %s%s\n", a.Name, jsonText(a.Old), jsonText(a.New), mark)
+		}
+	}
+	fmt.Printf("\nResources: %d to create, %d to update, %d to replace, %d to delete, %d to move (%d destructive).\n",
+		counts[diff.Create], counts[diff.Update], counts[diff.Replace], counts[diff.Delete], counts[diff.Move], destructive)
+}
+
+// jsonText renders a value compactly for the text report; absent values are "(none)".
+func jsonText(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// generate parses the diagram read from path for commands that only inspect the result,
+// exiting on failure. Nothing is written, so secrets in the diagram are allowed.
+func generate(d *diagram.Diagram, path string, jsonOut bool) *result.ParseResult {
+	opts := parser.DefaultOptions()
+	opts.AllowPlaintextSecrets = true
+	return generateWith(opts, d, path, jsonOut)
+}
+
+// generateWith is generate with the given options.
+func generateWith(opts parser.Options, d *diagram.Diagram, path string, jsonOut bool) *result.ParseResult {
+	res, err := parser.New(opts).Parse(d)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse: %v\n", err)
+		os.Exit(1)
+	}
+	if !res.Success {
+		if !jsonOut {
+			fmt.Fprintf(os.Stderr, "%s: cannot generate Terraform\n", path)
+		}
+		printFailure(res, jsonOut)
+		os.Exit(1)
+	}
+	return res
+}
+
+// readDiagram reads and decodes the diagram at path ("-" for stdin), exiting on failure.
+func readDiagram(path string, strict, jsonOut bool) *diagram.Diagram {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read input: %v\n", err)
+		os.Exit(1)
+	}
+	d, err := diagram.Decode(data, strict)
+	if err != nil {
+		printFailure(&result.ParseResult{Errors: parser.DecodeErrors(err)}, jsonOut)
+		os.Exit(1)
+	}
+	return d
+}
+
+// printFailure reports errors and warnings as JSON on stdout or as text on stderr.
+func printFailure(res *result.ParseResult, jsonOut bool) {
+	if jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(res)
+		return
+	}
+	for _, e := range res.Errors {
+		msg := e.Message
+		if e.Rule != "" {
+			msg = e.Rule + ": " + msg
+		}
+		fmt.Fprintf(os.Stderr, "ERROR [%s] %s%s\n", e.NodeID, msg, where(e.Path, e.Line, e.Column))
+		if e.Suggestion != "" {
+			fmt.Fprintf(os.Stderr, "  suggestion: %s\n", e.Suggestion)
+		}
+	}
+	printWarnings(res.Warnings)
+}
+
+// printWarnings writes warnings as text on stderr; lint findings are prefixed with their rule id.
+func printWarnings(warns []result.Warning) {
+	for _, w := range warns {
+		label := "WARN"
+		if w.Severity == "info" {
+			label = "INFO"
+		}
+		msg := w.Message
+		if w.Rule != "" {
+			msg = w.Rule + ": " + msg
+		}
+		fmt.Fprintf(os.Stderr, "%s [%s] %s%s\n", label, w.NodeID, msg, where(w.Path, w.Line, w.Column))
+		if w.Suggestion != "" {
+			fmt.Fprintf(os.Stderr, "  suggestion: %s\n", w.Suggestion)
+		}
+	}
+}
+
+// reason formats an optional explanation as " (explanation)".
+func reason(s string) string {
+	if s == "" {
+		return ""
+	}
+	return " (" + s + ")"
+}
+
+// where formats a diagram location as " (line 3, column 7, /nodes/0/type)".
+func where(path string, line, col int) string {
+	switch {
+	case line > 0:
+		return fmt.Sprintf(" (line %d, column %d, %s)", line, col, path)
+	case path != "":
+		return " (" + path + ")"
 	}
+	return ""
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/jobs"
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/logger"
+	"github.com/json-to-terraform/parser/internal/parser"
+	"github.com/json-to-terraform/parser/internal/policy"
+	"github.com/json-to-terraform/parser/internal/server"
+)
+
+func main() {
+	addr := flag.String("addr", ":8080", "Listen address")
+	maxBody := flag.Int64("max-body", server.DefaultMaxBodyBytes, "Max request body size in bytes")
+	timeout := flag.Duration("timeout", 30*time.Second, "Abort the generation of one diagram after this long (0 = no limit)")
+	readTimeout := flag.Duration("read-timeout", 10*time.Second, "Max time to read a request")
+	writeTimeout := flag.Duration("write-timeout", time.Minute, "Max time to write a response")
+	idleTimeout := flag.Duration("idle-timeout", 2*time.Minute, "Max time a keep-alive connection stays idle")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "Max time in-flight requests get to finish on SIGINT/SIGTERM")
+	rulesFile := flag.String("rules", "", "Lint rules file adjusting rule severities (JSON)")
+	policyFile := flag.String("policy", "", "Policy file with CEL conditions the generated resources must satisfy (JSON)")
+	allowSecrets := flag.Bool("allow-plaintext-secrets", false, "Accept secret properties (e.g. an RDS password) in production diagrams")
+	jobsDir := flag.String("jobs-dir", "", "Enable the asynchronous /v1/jobs endpoints, keeping jobs in this directory")
+	flag.Parse()
+
+	log := logger.New()
+	opts := parser.DefaultOptions()
+	opts.AllowPlaintextSecrets = *allowSecrets
+	var err error
+	if *rulesFile != "" {
+		if opts.Lint, err = lint.LoadConfig(*rulesFile); err != nil {
+			fmt.Fprintf(os.Stderr, "rules: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *policyFile != "" {
+		if opts.Policies, err = policy.Load(*policyFile); err != nil {
+			fmt.Fprintf(os.Stderr, "policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	cfg := server.Config{Options: opts, MaxBodyBytes: *maxBody, Timeout: *timeout, Logger: log}
+	var workers sync.WaitGroup
+	if *jobsDir != "" {
+		svc := &jobs.Service{Store: jobs.DirStore{Dir: *jobsDir}}
+		svc.Dispatch = func(id string) {
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				if err := svc.Run(context.Background(), id); err != nil {
+					log.Error("job", "id", id, "error", err)
+				}
+			}()
+		}
+		cfg.Jobs = svc
+	}
+	api := server.New(cfg)
+	if cfg.Jobs != nil {
+		cfg.Jobs.API = api
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           api,
+		ReadHeaderTimeout: *readTimeout,
+		ReadTimeout:       *readTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		log.Info("listening", "addr", *addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		log.Error("server stopped", "error", err)
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process
+	log.Info("shutting down", "timeout", *shutdownTimeout)
+	sctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		log.Error("shutdown", "error", err)
+		os.Exit(1)
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		log.Error("server stopped", "error", err)
+		os.Exit(1)
+	}
+	workers.Wait() // running jobs finish; their generation is bounded by -timeout
+	log.Info("stopped")
+}
@@ -0,0 +1,162 @@
+// Package apigw serves AWS Lambda HTTP events with a net/http handler: API Gateway REST API
+// proxy events (payload 1.0), HTTP API events (payload 2.0) and Lambda Function URL events,
+// which share the 2.0 shape. The event becomes an *http.Request, the handler's response
+// becomes the proxy response all three integrations accept.
+package apigw
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Response is the proxy integration response. Text bodies are returned as is; others
+// (archives, gzip-encoded bodies) are base64-encoded with IsBase64Encoded set.
+type Response struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded,omitempty"`
+}
+
+// Handle serves raw with h when it is an HTTP event; ok is false for any other payload,
+// which the caller handles itself. ctx (the invocation's, with its deadline) becomes the
+// request context.
+func Handle(ctx context.Context, h http.Handler, raw []byte) (resp Response, ok bool) {
+	r, ok, err := NewRequest(ctx, raw)
+	if !ok {
+		return Response{}, false
+	}
+	if err != nil {
+		body, _ := json.Marshal(map[string]any{"success": false, "errors": []map[string]string{
+			{"type": "invalid_input", "severity": "error", "message": err.Error()},
+		}})
+		return Response{StatusCode: http.StatusBadRequest, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}, true
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return NewResponse(rec.Result().StatusCode, rec.Header(), rec.Body.Bytes()), true
+}
+
+// probe has the fields that tell the event shapes apart.
+type probe struct {
+	HTTPMethod     string `json:"httpMethod"` // 1.0
+	RequestContext struct {
+		HTTP struct {
+			Method string `json:"method"` // 2.0
+		} `json:"http"`
+	} `json:"requestContext"`
+}
+
+// NewRequest converts an HTTP event to a request; ok is false when raw is not one. The path
+// is the route without an HTTP API stage prefix (/prod/v1/generate -> /v1/generate).
+func NewRequest(ctx context.Context, raw []byte) (r *http.Request, ok bool, err error) {
+	var p probe
+	if json.Unmarshal(raw, &p) != nil {
+		return nil, false, nil
+	}
+	switch {
+	case p.RequestContext.HTTP.Method != "":
+		var e events.APIGatewayV2HTTPRequest // also the shape of events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, true, err
+		}
+		path := e.RawPath
+		if stage := e.RequestContext.Stage; stage != "" && stage != "$default" {
+			path = strings.TrimPrefix(path, "/"+stage)
+		}
+		r, err = newRequest(ctx, e.RequestContext.HTTP.Method, path, e.RawQueryString, e.Body, e.IsBase64Encoded)
+		if err != nil {
+			return nil, true, err
+		}
+		for k, v := range e.Headers {
+			r.Header.Set(k, v)
+		}
+		if len(e.Cookies) > 0 {
+			r.Header.Set("Cookie", strings.Join(e.Cookies, "; "))
+		}
+		r.RemoteAddr = e.RequestContext.HTTP.SourceIP
+		return r, true, nil
+
+	case p.HTTPMethod != "":
+		var e events.APIGatewayProxyRequest
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, true, err
+		}
+		query := url.Values(e.MultiValueQueryStringParameters)
+		if query == nil {
+			query = url.Values{}
+			for k, v := range e.QueryStringParameters {
+				query.Set(k, v)
+			}
+		}
+		r, err = newRequest(ctx, e.HTTPMethod, e.Path, query.Encode(), e.Body, e.IsBase64Encoded)
+		if err != nil {
+			return nil, true, err
+		}
+		for k, v := range e.Headers {
+			r.Header.Set(k, v)
+		}
+		for k, vs := range e.MultiValueHeaders {
+			r.Header.Del(k)
+			for _, v := range vs {
+				r.Header.Add(k, v)
+			}
+		}
+		r.RemoteAddr = e.RequestContext.Identity.SourceIP
+		return r, true, nil
+	}
+	return nil, false, nil
+}
+
+func newRequest(ctx context.Context, method, path, query, body string, isBase64 bool) (*http.Request, error) {
+	data := []byte(body)
+	if isBase64 {
+		var err error
+		if data, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+	}
+	if path == "" {
+		path = "/"
+	}
+	target := path
+	if query != "" {
+		target += "?" + query
+	}
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	return r, nil
+}
+
+// NewResponse converts a handler's response to the proxy response. Multi-valued headers
+// are joined with commas.
+func NewResponse(status int, header http.Header, body []byte) Response {
+	resp := Response{StatusCode: status, Headers: make(map[string]string, len(header))}
+	for k, vs := range header {
+		resp.Headers[k] = strings.Join(vs, ", ")
+	}
+	if header.Get("Content-Encoding") == "" && isText(header.Get("Content-Type")) {
+		resp.Body = string(body)
+	} else {
+		resp.Body = base64.StdEncoding.EncodeToString(body)
+		resp.IsBase64Encoded = true
+	}
+	return resp
+}
+
+func isText(contentType string) bool {
+	media, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(media, "text/") || media == "application/json" || strings.HasSuffix(media, "+json")
+}
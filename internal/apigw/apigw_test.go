@@ -0,0 +1,125 @@
+package apigw
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func marshal(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// echo answers with the request it received.
+var echo = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"method": r.Method, "path": r.URL.Path, "query": r.URL.RawQuery,
+		"accept": r.Header.Get("Accept"), "body": string(body), "remote": r.RemoteAddr,
+	})
+})
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name  string
+		event any
+		want  map[string]string
+	}{
+		{
+			name: "http api",
+			event: events.APIGatewayV2HTTPRequest{
+				Version: "2.0", RawPath: "/prod/v1/generate", RawQueryString: "strict=true",
+				Headers: map[string]string{"accept": "application/zip"},
+				Body:    base64.StdEncoding.EncodeToString([]byte(`{"nodes":[]}`)), IsBase64Encoded: true,
+				RequestContext: events.APIGatewayV2HTTPRequestContext{
+					Stage: "prod",
+					HTTP:  events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: "POST", SourceIP: "10.0.0.1"},
+				},
+			},
+			want: map[string]string{"method": "POST", "path": "/v1/generate", "query": "strict=true", "accept": "application/zip", "body": `{"nodes":[]}`, "remote": "10.0.0.1"},
+		},
+		{
+			name: "function url",
+			event: events.LambdaFunctionURLRequest{
+				Version: "2.0", RawPath: "/v1/types",
+				RequestContext: events.LambdaFunctionURLRequestContext{
+					HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET", SourceIP: "10.0.0.2"},
+				},
+			},
+			want: map[string]string{"method": "GET", "path": "/v1/types", "query": "", "accept": "", "body": "", "remote": "10.0.0.2"},
+		},
+		{
+			name: "rest api",
+			event: events.APIGatewayProxyRequest{
+				HTTPMethod: "POST", Path: "/v1/validate", Body: `{}`,
+				QueryStringParameters:           map[string]string{"import": "b"},
+				MultiValueQueryStringParameters: map[string][]string{"import": {"a,b"}},
+				Headers:                         map[string]string{"Accept": "text/html"},
+				MultiValueHeaders:               map[string][]string{"Accept": {"application/json"}},
+				RequestContext:                  events.APIGatewayProxyRequestContext{Identity: events.APIGatewayRequestIdentity{SourceIP: "10.0.0.3"}},
+			},
+			want: map[string]string{"method": "POST", "path": "/v1/validate", "query": "import=a%2Cb", "accept": "application/json", "body": "{}", "remote": "10.0.0.3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, ok := Handle(context.Background(), echo, marshal(t, tt.event))
+			if !ok {
+				t.Fatal("not recognized as an HTTP event")
+			}
+			if resp.StatusCode != http.StatusOK || resp.IsBase64Encoded {
+				t.Fatalf("response = %+v", resp)
+			}
+			var got map[string]string
+			if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleOtherPayloads(t *testing.T) {
+	for _, raw := range []string{`{"body": "{}", "isBase64": false}`, `"text"`} {
+		if _, ok := Handle(context.Background(), echo, []byte(raw)); ok {
+			t.Errorf("%s recognized as an HTTP event", raw)
+		}
+	}
+
+	bad := events.APIGatewayV2HTTPRequest{Body: "%%%", IsBase64Encoded: true}
+	bad.RequestContext.HTTP.Method = "POST"
+	resp, ok := Handle(context.Background(), echo, marshal(t, bad))
+	if !ok || resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad base64 body: %+v, %v", resp, ok)
+	}
+}
+
+func TestNewResponse(t *testing.T) {
+	resp := NewResponse(http.StatusOK, http.Header{"Content-Type": {"application/zip"}, "Vary": {"A", "B"}}, []byte{0x50, 0x4b})
+	if !resp.IsBase64Encoded || resp.Body != "UEs=" || resp.Headers["Vary"] != "A, B" {
+		t.Errorf("zip response = %+v", resp)
+	}
+	resp = NewResponse(http.StatusOK, http.Header{"Content-Type": {"application/json"}, "Content-Encoding": {"gzip"}}, []byte{0x1f, 0x8b})
+	if !resp.IsBase64Encoded {
+		t.Errorf("gzip response = %+v", resp)
+	}
+	resp = NewResponse(http.StatusOK, http.Header{"Content-Type": {"application/schema+json"}}, []byte(`{}`))
+	if resp.IsBase64Encoded || resp.Body != "{}" {
+		t.Errorf("json response = %+v", resp)
+	}
+}
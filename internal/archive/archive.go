@@ -0,0 +1,107 @@
+// Package archive writes generated files to a single stream: a zip or gzip-compressed tar
+// archive, or a plain-text multi-file stream. Output is reproducible: entries are written in
+// name order with a fixed modification time and mode, so the same files always give the
+// same bytes.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Format is a single-stream output format.
+type Format struct {
+	Name      string // as selected by -format: stdout, zip, tar.gz
+	Ext       string // file name extension of an archive, "" for the stream
+	MediaType string
+	Write     func(w io.Writer, files map[string][]byte) error
+}
+
+var (
+	StreamFormat = Format{Name: "stdout", MediaType: "text/plain; charset=utf-8", Write: Stream}
+	ZipFormat    = Format{Name: "zip", Ext: ".zip", MediaType: "application/zip", Write: Zip}
+	TarGzFormat  = Format{Name: "tar.gz", Ext: ".tar.gz", MediaType: "application/gzip", Write: TarGz}
+)
+
+// Lookup returns the format with the given name.
+func Lookup(name string) (Format, bool) {
+	for _, f := range []Format{StreamFormat, ZipFormat, TarGzFormat} {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Format{}, false
+}
+
+// Stream writes files (name -> content) as one text stream to w, each file preceded by a
+// "-- name --" line (the txtar layout). A file not ending in a newline gets one, so every
+// marker starts a line.
+func Stream(w io.Writer, files map[string][]byte) error {
+	var buf bytes.Buffer
+	for _, name := range sortedNames(files) {
+		fmt.Fprintf(&buf, "-- %s --\n", name)
+		buf.Write(files[name])
+		if n := len(files[name]); n > 0 && files[name][n-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// modTime is the modification time of every entry (the earliest time zip can represent).
+var modTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Zip writes files (name -> content) as a zip archive to w.
+func Zip(w io.Writer, files map[string][]byte) error {
+	zw := zip.NewWriter(w)
+	for _, name := range sortedNames(files) {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modTime}
+		hdr.SetMode(0644)
+		f, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// TarGz writes files (name -> content) as a gzip-compressed tar archive to w.
+func TarGz(w io.Writer, files map[string][]byte) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, name := range sortedNames(files) {
+		hdr := &tar.Header{
+			Name: name, Mode: 0644, Size: int64(len(files[name])),
+			ModTime: modTime, Typeflag: tar.TypeReg, Format: tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func sortedNames(files map[string][]byte) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
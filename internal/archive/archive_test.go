@@ -0,0 +1,104 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+)
+
+var files = map[string][]byte{
+	"versions.tf": []byte("terraform {}\n"),
+	"main.tf":     []byte("resource \"aws_vpc\" \"main\" {}\n"),
+}
+
+func TestZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Zip(&buf, files); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]byte)
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	if !reflect.DeepEqual(names, []string{"main.tf", "versions.tf"}) || !reflect.DeepEqual(got, files) {
+		t.Errorf("zip entries %v = %q", names, got)
+	}
+
+	var again bytes.Buffer
+	Zip(&again, files)
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("zip archive is not reproducible")
+	}
+}
+
+func TestTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	if err := TarGz(&buf, files); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	got := make(map[string][]byte)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		got[hdr.Name], _ = io.ReadAll(tr)
+	}
+	if !reflect.DeepEqual(names, []string{"main.tf", "versions.tf"}) || !reflect.DeepEqual(got, files) {
+		t.Errorf("tar entries %v = %q", names, got)
+	}
+
+	var again bytes.Buffer
+	TarGz(&again, files)
+	if !bytes.Equal(buf.Bytes(), again.Bytes()) {
+		t.Error("tar.gz archive is not reproducible")
+	}
+}
+
+func TestStream(t *testing.T) {
+	var buf bytes.Buffer
+	files := map[string][]byte{"b.tf": []byte("b"), "a.tf": []byte("a\n"), "empty.tf": nil}
+	if err := Stream(&buf, files); err != nil {
+		t.Fatal(err)
+	}
+	want := "-- a.tf --\na\n-- b.tf --\nb\n-- empty.tf --\n"
+	if buf.String() != want {
+		t.Errorf("Stream() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"stdout", "zip", "tar.gz"} {
+		if f, ok := Lookup(name); !ok || f.Name != name || f.Write == nil {
+			t.Errorf("Lookup(%q) = %+v, %v", name, f, ok)
+		}
+	}
+	if _, ok := Lookup("rar"); ok {
+		t.Error("Lookup(rar) succeeded")
+	}
+}
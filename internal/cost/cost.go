@@ -0,0 +1,237 @@
+// Package cost estimates the monthly cost of generated resources from an embedded, offline
+// price table (on-demand prices for one region). The estimate works on the plan model, so
+// it prices exactly what was generated, and attributes every line to the node it came from.
+//
+// Only resources with a fixed price or an assumed usage are priced: EC2 instances, RDS
+// instances and their storage, NAT gateways, Secrets Manager secrets and Lambda functions.
+// Usage-based charges (S3 storage and requests, data transfer, NAT data processing) are not
+// estimated.
+package cost
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/json-to-terraform/parser/internal/plan"
+)
+
+// pricesJSON is the bundled price table (see PriceTable).
+//
+//go:embed prices.json
+var pricesJSON []byte
+
+// PriceTable holds on-demand prices for one region.
+type PriceTable struct {
+	Currency      string  `json:"currency"`
+	Region        string  `json:"region"`
+	Effective     string  `json:"effective"` // date the prices were taken
+	HoursPerMonth float64 `json:"hours_per_month"`
+	// EC2Hourly is the Linux price per hour by instance type.
+	EC2Hourly map[string]float64 `json:"ec2_instance_hourly"`
+	// RDSHourly is the single-AZ price per hour by instance class for the open-source
+	// engines (postgres, mysql, mariadb); Multi-AZ doubles it.
+	RDSHourly map[string]float64 `json:"rds_instance_hourly"`
+	// RDSStorageGBMonth is the price per GB-month by storage type; Multi-AZ doubles it.
+	RDSStorageGBMonth map[string]float64 `json:"rds_storage_gb_month"`
+	NATGatewayHourly  float64            `json:"nat_gateway_hourly"`
+	SecretMonthly     float64            `json:"secret_monthly"` // Secrets Manager, per secret
+	Lambda            LambdaPrices       `json:"lambda"`
+}
+
+// LambdaPrices are the Lambda compute and request prices and the usage they are applied to.
+type LambdaPrices struct {
+	GBSecond           float64 `json:"gb_second"`
+	PerMillionRequests float64 `json:"per_million_requests"`
+	// MonthlyRequests is the assumed number of invocations per function; each is assumed
+	// to run for the function's full timeout, so the estimate is an upper bound.
+	MonthlyRequests float64 `json:"assumed_monthly_requests"`
+}
+
+var (
+	bundledOnce  sync.Once
+	bundledTable *PriceTable
+	bundledErr   error
+)
+
+// BundledPrices returns the embedded price table (parsed once).
+func BundledPrices() (*PriceTable, error) {
+	bundledOnce.Do(func() {
+		bundledTable, bundledErr = LoadPrices(pricesJSON)
+	})
+	return bundledTable, bundledErr
+}
+
+// LoadPrices parses a price table in the format of the bundled prices.json.
+func LoadPrices(data []byte) (*PriceTable, error) {
+	var t PriceTable
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse price table: %w", err)
+	}
+	return &t, nil
+}
+
+// Estimate is the monthly cost of a plan.
+type Estimate struct {
+	Currency     string     `json:"currency"`
+	PriceRegion  string     `json:"price_region"`
+	PricesAsOf   string     `json:"prices_as_of"`
+	MonthlyTotal float64    `json:"monthly_total"`
+	Nodes        []NodeCost `json:"nodes"`
+	Unpriced     []Unpriced `json:"unpriced,omitempty"`
+	Assumptions  []string   `json:"assumptions"`
+}
+
+// NodeCost is the cost of the priced resources generated for one node.
+type NodeCost struct {
+	NodeID  string  `json:"node_id"`
+	Monthly float64 `json:"monthly"`
+	Items   []Item  `json:"items"`
+}
+
+// Item is one priced component of a resource (an instance, its storage, requests).
+type Item struct {
+	Address     string  `json:"address"`
+	Description string  `json:"description"`
+	Monthly     float64 `json:"monthly"`
+}
+
+// Unpriced is a resource that may cost money but could not be estimated.
+type Unpriced struct {
+	Address string `json:"address"`
+	NodeID  string `json:"node_id,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// freeTypes have no charge of their own.
+var freeTypes = map[string]bool{
+	"aws_vpc": true, "aws_subnet": true, "aws_security_group": true,
+	"aws_db_subnet_group": true, "aws_s3_bucket_public_access_block": true, "aws_s3_bucket_versioning": true,
+	"aws_s3_bucket_server_side_encryption_configuration": true,
+	"random_password": true, "aws_secretsmanager_secret_version": true,
+}
+
+// Estimate prices every resource of pl. region is the diagram's region; when it differs
+// from the table's, a note is added to the assumptions. Nodes keep the order in which their
+// first resource appears in the plan; amounts are rounded to cents per item.
+func (t *PriceTable) Estimate(pl *plan.Plan, region string) *Estimate {
+	e := &Estimate{
+		Currency:    t.Currency,
+		PriceRegion: t.Region,
+		PricesAsOf:  t.Effective,
+		Nodes:       []NodeCost{},
+		Assumptions: []string{
+			fmt.Sprintf("on-demand prices in %s as of %s, %g hours per month", t.Region, t.Effective, t.HoursPerMonth),
+			fmt.Sprintf("each Lambda function is invoked %.0f times a month and runs for its full timeout", t.Lambda.MonthlyRequests),
+			"free tiers, EBS volumes, data transfer and other usage-based charges are not included",
+		},
+	}
+	if region != "" && region != t.Region {
+		e.Assumptions = append(e.Assumptions, fmt.Sprintf("the diagram targets %s; prices there may differ", region))
+	}
+
+	index := make(map[string]int) // node ID -> position in e.Nodes
+	for _, r := range pl.Resources {
+		items, reason := t.price(r)
+		if reason != "" {
+			e.Unpriced = append(e.Unpriced, Unpriced{Address: r.Address, NodeID: r.NodeID, Reason: reason})
+		}
+		if len(items) == 0 {
+			continue
+		}
+		i, ok := index[r.NodeID]
+		if !ok {
+			i = len(e.Nodes)
+			index[r.NodeID] = i
+			e.Nodes = append(e.Nodes, NodeCost{NodeID: r.NodeID})
+		}
+		n := &e.Nodes[i]
+		for _, it := range items {
+			it.Monthly = cents(it.Monthly)
+			n.Items = append(n.Items, it)
+			n.Monthly = cents(n.Monthly + it.Monthly)
+		}
+	}
+	for _, n := range e.Nodes {
+		e.MonthlyTotal = cents(e.MonthlyTotal + n.Monthly)
+	}
+	return e
+}
+
+// price returns the priced items of a resource, or the reason it could not be priced.
+func (t *PriceTable) price(r plan.Resource) ([]Item, string) {
+	hours := t.HoursPerMonth
+	switch r.Type {
+	case "aws_instance":
+		typ, _ := r.Attributes["instance_type"].(string)
+		hourly, ok := t.EC2Hourly[typ]
+		if !ok {
+			return nil, fmt.Sprintf("no price for instance type %q", typ)
+		}
+		return []Item{{r.Address, fmt.Sprintf("%s instance, %g h", typ, hours), hourly * hours}}, ""
+
+	case "aws_db_instance":
+		engine, _ := r.Attributes["engine"].(string)
+		switch engine {
+		case "postgres", "mysql", "mariadb":
+		default:
+			return nil, fmt.Sprintf("no price for engine %q (licensed engines are not in the table)", engine)
+		}
+		class, _ := r.Attributes["instance_class"].(string)
+		hourly, ok := t.RDSHourly[class]
+		if !ok {
+			return nil, fmt.Sprintf("no price for instance class %q", class)
+		}
+		factor, deployment := 1.0, "single-AZ"
+		if multiAZ, _ := r.Attributes["multi_az"].(bool); multiAZ {
+			factor, deployment = 2, "Multi-AZ"
+		}
+		items := []Item{{r.Address, fmt.Sprintf("%s %s %s, %g h", class, engine, deployment, hours), factor * hourly * hours}}
+		storage := "gp2" // the AWS default
+		if s, ok := r.Attributes["storage_type"].(string); ok {
+			storage = s
+		}
+		gb, _ := r.Attributes["allocated_storage"].(float64)
+		if perGB, ok := t.RDSStorageGBMonth[storage]; ok && gb > 0 {
+			items = append(items, Item{r.Address, fmt.Sprintf("%g GB %s storage, %s", gb, storage, deployment), factor * perGB * gb})
+		}
+		return items, ""
+
+	case "aws_nat_gateway":
+		return []Item{{r.Address, fmt.Sprintf("NAT gateway, %g h (data processing not included)", hours), t.NATGatewayHourly * hours}}, ""
+
+	case "aws_lambda_function":
+		memory := number(r.Attributes["memory_size"], 128) // Terraform defaults
+		timeout := number(r.Attributes["timeout"], 3)
+		requests := t.Lambda.MonthlyRequests
+		gbSeconds := requests * timeout * memory / 1024
+		return []Item{
+			{r.Address, fmt.Sprintf("%g MB x %g s x %.0f invocations", memory, timeout, requests), gbSeconds * t.Lambda.GBSecond},
+			{r.Address, fmt.Sprintf("%.0f requests", requests), requests / 1e6 * t.Lambda.PerMillionRequests},
+		}, ""
+
+	case "aws_s3_bucket":
+		return nil, "storage and requests are usage-based"
+
+	case "aws_secretsmanager_secret":
+		return []Item{{r.Address, "Secrets Manager secret (API calls not included)", t.SecretMonthly}}, ""
+	}
+	if freeTypes[r.Type] {
+		return nil, ""
+	}
+	return nil, "resource type not in the price table"
+}
+
+// number returns v as a float, or def when v is not a literal number.
+func number(v any, def float64) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return def
+}
+
+func cents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,81 @@
+package cost
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/plan"
+)
+
+func TestEstimate(t *testing.T) {
+	table, err := BundledPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pl := &plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "vpc"},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web",
+			Attributes: map[string]any{"instance_type": "t3.micro"}},
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", NodeID: "db",
+			Attributes: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0, "storage_type": "gp3", "multi_az": true}},
+		{Address: "aws_lambda_function.fn", Type: "aws_lambda_function", NodeID: "fn",
+			Attributes: map[string]any{"memory_size": 128.0}},
+		{Address: "aws_instance.gpu", Type: "aws_instance", NodeID: "gpu",
+			Attributes: map[string]any{"instance_type": "p5.48xlarge"}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", NodeID: "logs"},
+	}}
+	e := table.Estimate(pl, "eu-west-1")
+
+	var got []string
+	for _, n := range e.Nodes {
+		for _, it := range n.Items {
+			got = append(got, n.NodeID+" "+it.Description)
+		}
+	}
+	want := []string{
+		"web t3.micro instance, 730 h",
+		"db db.t3.micro postgres Multi-AZ, 730 h",
+		"db 20 GB gp3 storage, Multi-AZ",
+		"fn 128 MB x 3 s x 1000000 invocations",
+		"fn 1000000 requests",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("items =\n%q\nwant\n%q", got, want)
+	}
+
+	// t3.micro: 0.0104 * 730; db: 2 * 0.018 * 730 + 2 * 20 * 0.115; fn: 375000 GB-s + 1M requests
+	monthly := map[string]float64{"web": 7.59, "db": 30.88, "fn": 6.45}
+	for _, n := range e.Nodes {
+		if n.Monthly != monthly[n.NodeID] {
+			t.Errorf("%s monthly = %.2f, want %.2f", n.NodeID, n.Monthly, monthly[n.NodeID])
+		}
+	}
+	if e.MonthlyTotal != 44.92 {
+		t.Errorf("total = %.2f, want 44.92", e.MonthlyTotal)
+	}
+
+	if len(e.Unpriced) != 2 || e.Unpriced[0].NodeID != "gpu" || e.Unpriced[1].NodeID != "logs" {
+		t.Errorf("unpriced = %+v, want gpu and logs", e.Unpriced)
+	}
+	if last := e.Assumptions[len(e.Assumptions)-1]; !strings.Contains(last, "eu-west-1") {
+		t.Errorf("no note about the region: %q", e.Assumptions)
+	}
+}
+
+func TestEstimateLicensedEngine(t *testing.T) {
+	table, err := BundledPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := table.Estimate(&plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", NodeID: "db",
+			Attributes: map[string]any{"engine": "oracle-ee", "instance_class": "db.t3.micro"}},
+	}}, "us-east-1")
+	if len(e.Nodes) != 0 || len(e.Unpriced) != 1 || e.MonthlyTotal != 0 {
+		t.Errorf("estimate = %+v, want the instance unpriced", e)
+	}
+	if len(e.Assumptions) != 3 {
+		t.Errorf("assumptions = %q, want no region note", e.Assumptions)
+	}
+}
@@ -0,0 +1,129 @@
+package dependency
+
+import (
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+// findCycles returns one cycle for each strongly connected component of the nodes that
+// Kahn's algorithm could not order (inDegree > 0). Components and cycles are reported
+// in diagram order so the error is stable between runs.
+func findCycles(g *diagram.Graph, inDegree []int) [][]CycleStep {
+	n := g.Len()
+	blocked := make([]bool, n)
+	for v := 0; v < n; v++ {
+		blocked[v] = inDegree[v] > 0
+	}
+
+	// Tarjan's SCC over the blocked subgraph.
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	for v := range index {
+		index[v] = -1
+	}
+	comp := make([]int, n)
+	for v := range comp {
+		comp[v] = -1
+	}
+	var stack []int
+	next, ncomp := 0, 0
+	var strongConnect func(v int)
+	strongConnect = func(v int) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range successors(g, v, blocked) {
+			if index[w] < 0 {
+				strongConnect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp[w] = ncomp
+				if w == v {
+					break
+				}
+			}
+			ncomp++
+		}
+	}
+	for v := 0; v < n; v++ {
+		if blocked[v] && index[v] < 0 {
+			strongConnect(v)
+		}
+	}
+
+	// For each component (visited via its first node in diagram order), find the shortest
+	// cycle through that node with a BFS restricted to the component.
+	var cycles [][]CycleStep
+	reported := make(map[int]bool)
+	for s := 0; s < n; s++ {
+		c := comp[s]
+		if c < 0 || reported[c] {
+			continue
+		}
+		if cycle := shortestCycle(g, s, func(v int) bool { return comp[v] == c }); cycle != nil {
+			reported[c] = true
+			cycles = append(cycles, cycle)
+		}
+	}
+	return cycles
+}
+
+// successors returns targets of non-self edges from v that satisfy keep.
+func successors(g *diagram.Graph, v int, keep []bool) []int {
+	var out []int
+	for _, ei := range g.Out(v) {
+		if selfEdge(g, ei) {
+			continue
+		}
+		if w, ok := g.Index(g.EdgeAt(ei).Target); ok && keep[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+func shortestCycle(g *diagram.Graph, start int, inComp func(int) bool) []CycleStep {
+	type hop struct{ from, edge int }
+	prev := map[int]hop{}
+	queue := []int{start}
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		for _, ei := range g.Out(u) {
+			if selfEdge(g, ei) {
+				continue
+			}
+			w, ok := g.Index(g.EdgeAt(ei).Target)
+			if !ok || !inComp(w) {
+				continue
+			}
+			if w == start {
+				// Walk back from u to start, then reverse.
+				steps := []CycleStep{{NodeID: g.NodeAt(u).ID, EdgeID: g.EdgeAt(ei).ID}}
+				for v := u; v != start; {
+					h := prev[v]
+					steps = append(steps, CycleStep{NodeID: g.NodeAt(h.from).ID, EdgeID: g.EdgeAt(h.edge).ID})
+					v = h.from
+				}
+				for i, j := 0, len(steps)-1; i < j; i, j = i+1, j-1 {
+					steps[i], steps[j] = steps[j], steps[i]
+				}
+				return steps
+			}
+			if _, seen := prev[w]; !seen {
+				prev[w] = hop{from: u, edge: ei}
+				queue = append(queue, w)
+			}
+		}
+	}
+	return nil
+}
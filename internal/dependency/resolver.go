@@ -2,73 +2,128 @@ package dependency
 
 import (
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/json-to-terraform/parser/internal/diagram"
 )
 
 // ErrCycle is returned when the dependency graph contains a cycle.
+// Resolve wraps it in a *CycleError that lists the offending paths.
 var ErrCycle = errors.New("dependency cycle detected")
 
+// CycleStep is one hop in a cycle: the node and the edge leaving it towards the next step.
+type CycleStep struct {
+	NodeID string
+	EdgeID string
+}
+
+// CycleError reports every strongly connected group of nodes that prevents ordering,
+// with one concrete cycle per group. errors.Is(err, ErrCycle) is true.
+type CycleError struct {
+	Cycles [][]CycleStep
+}
+
+func (e *CycleError) Error() string {
+	parts := make([]string, len(e.Cycles))
+	for i, c := range e.Cycles {
+		parts[i] = FormatCycle(c)
+	}
+	return ErrCycle.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap lets errors.Is match ErrCycle.
+func (e *CycleError) Unwrap() error { return ErrCycle }
+
+// FormatCycle renders a cycle as "a --[e1]--> b --[e2]--> a".
+func FormatCycle(c []CycleStep) string {
+	var b strings.Builder
+	for _, s := range c {
+		b.WriteString(s.NodeID)
+		if s.EdgeID != "" {
+			b.WriteString(" --[" + s.EdgeID + "]--> ")
+		} else {
+			b.WriteString(" --> ")
+		}
+	}
+	if len(c) > 0 {
+		b.WriteString(c[0].NodeID)
+	}
+	return b.String()
+}
+
 // Resolve builds the dependency graph from edges and returns:
 // - ordered: node IDs in topological order (dependencies first)
 // - tiers: node IDs grouped by depth (tier 0 = no deps, tier 1 = depend only on tier 0, etc.)
+//
+// Ordering contract: nodes within a tier keep the order in which they appear in d.Nodes,
+// and ordered is the concatenation of the tiers. The result depends only on the diagram,
+// never on map iteration order.
+//
+// Resolve visits each node and edge once using the diagram's Graph index (plus sorting
+// each tier). On a cycle it returns a *CycleError.
 func Resolve(d *diagram.Diagram) (ordered []string, tiers [][]string, err error) {
 	if d == nil || len(d.Nodes) == 0 {
 		return nil, nil, nil
 	}
-
-	nodeSet := make(map[string]bool)
-	for i := range d.Nodes {
-		nodeSet[d.Nodes[i].ID] = true
-	}
+	g := d.Graph()
+	n := g.Len()
 
 	// target depends on source => inDegree[target] = number of edges into target
-	inDegree := make(map[string]int)
-	for id := range nodeSet {
-		inDegree[id] = 0
-	}
-	for _, e := range d.Edges {
-		if !nodeSet[e.Source] || !nodeSet[e.Target] || e.Source == e.Target {
-			continue
+	inDegree := make([]int, n)
+	for v := 0; v < n; v++ {
+		for _, ei := range g.In(v) {
+			if !selfEdge(g, ei) {
+				inDegree[v]++
+			}
 		}
-		inDegree[e.Target]++
 	}
 
-	var queue []string
-	for id := range nodeSet {
-		if inDegree[id] == 0 {
-			queue = append(queue, id)
+	var queue []int
+	for v := 0; v < n; v++ {
+		if inDegree[v] == 0 {
+			queue = append(queue, v)
 		}
 	}
 
-	ordered = make([]string, 0, len(d.Nodes))
-	tiers = nil
+	ordered = make([]string, 0, n)
+	done := 0
 	for len(queue) > 0 {
 		tier := make([]string, len(queue))
-		copy(tier, queue)
+		for i, v := range queue {
+			tier[i] = g.NodeAt(v).ID
+		}
 		tiers = append(tiers, tier)
-		var nextQueue []string
+		ordered = append(ordered, tier...)
+		done += len(queue)
+
+		var next []int
 		for _, u := range queue {
-			ordered = append(ordered, u)
-			for _, e := range d.Edges {
-				if e.Source != u {
+			for _, ei := range g.Out(u) {
+				if selfEdge(g, ei) {
 					continue
 				}
-				v := e.Target
-				if !nodeSet[v] {
+				v, ok := g.Index(g.EdgeAt(ei).Target)
+				if !ok {
 					continue
 				}
 				inDegree[v]--
 				if inDegree[v] == 0 {
-					nextQueue = append(nextQueue, v)
+					next = append(next, v)
 				}
 			}
 		}
-		queue = nextQueue
+		sort.Ints(next) // node index == diagram position
+		queue = next
 	}
 
-	if len(ordered) != len(nodeSet) {
-		return nil, nil, ErrCycle
+	if done != n {
+		return nil, nil, &CycleError{Cycles: findCycles(g, inDegree)}
 	}
 	return ordered, tiers, nil
 }
+
+func selfEdge(g *diagram.Graph, ei int) bool {
+	e := g.EdgeAt(ei)
+	return e.Source == e.Target
+}
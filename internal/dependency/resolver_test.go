@@ -0,0 +1,173 @@
+package dependency
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func nodes(ids ...string) []diagram.Node {
+	out := make([]diagram.Node, len(ids))
+	for i, id := range ids {
+		out[i] = diagram.Node{ID: id}
+	}
+	return out
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		nodes       []diagram.Node
+		edges       []diagram.Edge
+		wantOrdered []string
+		wantTiers   [][]string
+	}{
+		{
+			name:        "independent nodes keep diagram order",
+			nodes:       nodes("c", "a", "b"),
+			wantOrdered: []string{"c", "a", "b"},
+			wantTiers:   [][]string{{"c", "a", "b"}},
+		},
+		{
+			name:  "tiers sorted by diagram position, not edge order",
+			nodes: nodes("vpc", "sub-b", "sub-a", "web"),
+			edges: []diagram.Edge{
+				{Source: "vpc", Target: "sub-a"},
+				{Source: "vpc", Target: "sub-b"},
+				{Source: "sub-a", Target: "web"},
+			},
+			wantOrdered: []string{"vpc", "sub-b", "sub-a", "web"},
+			wantTiers:   [][]string{{"vpc"}, {"sub-b", "sub-a"}, {"web"}},
+		},
+		{
+			name:        "self edges are ignored",
+			nodes:       nodes("a"),
+			edges:       []diagram.Edge{{Source: "a", Target: "a"}},
+			wantOrdered: []string{"a"},
+			wantTiers:   [][]string{{"a"}},
+		},
+		{
+			name:  "edges to or from unknown nodes are ignored",
+			nodes: nodes("vpc", "web"),
+			edges: []diagram.Edge{
+				{Source: "ghost", Target: "vpc"},
+				{Source: "vpc", Target: "ghost"},
+				{Source: "vpc", Target: "web"},
+			},
+			wantOrdered: []string{"vpc", "web"},
+			wantTiers:   [][]string{{"vpc"}, {"web"}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ordered, tiers, err := Resolve(&diagram.Diagram{Nodes: tc.nodes, Edges: tc.edges})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ordered, tc.wantOrdered) {
+				t.Errorf("ordered = %v, want %v", ordered, tc.wantOrdered)
+			}
+			if !reflect.DeepEqual(tiers, tc.wantTiers) {
+				t.Errorf("tiers = %v, want %v", tiers, tc.wantTiers)
+			}
+		})
+	}
+}
+
+func TestResolveCycle(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []diagram.Node
+		edges []diagram.Edge
+		want  []string
+	}{
+		{
+			name:  "two nodes",
+			nodes: nodes("a", "b"),
+			edges: []diagram.Edge{{ID: "e1", Source: "a", Target: "b"}, {ID: "e2", Source: "b", Target: "a"}},
+			want:  []string{"a --[e1]--> b --[e2]--> a"},
+		},
+		{
+			name:  "shortest cycle through first node, downstream nodes not reported",
+			nodes: nodes("x", "a", "b", "c", "tail"),
+			edges: []diagram.Edge{
+				{ID: "e0", Source: "x", Target: "a"},
+				{ID: "e1", Source: "a", Target: "b"},
+				{ID: "e2", Source: "b", Target: "c"},
+				{ID: "e3", Source: "c", Target: "a"},
+				{ID: "e4", Source: "b", Target: "a"},
+				{ID: "e5", Source: "c", Target: "tail"},
+			},
+			want: []string{"a --[e1]--> b --[e4]--> a"},
+		},
+		{
+			name:  "independent cycles in diagram order",
+			nodes: nodes("p", "q", "m", "n"),
+			edges: []diagram.Edge{
+				{ID: "1", Source: "n", Target: "m"},
+				{ID: "2", Source: "m", Target: "n"},
+				{Source: "p", Target: "q"},
+				{Source: "q", Target: "p"},
+			},
+			want: []string{"p --> q --> p", "m --[2]--> n --[1]--> m"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := Resolve(&diagram.Diagram{Nodes: tc.nodes, Edges: tc.edges})
+			if !errors.Is(err, ErrCycle) {
+				t.Fatalf("Resolve() error = %v, want ErrCycle", err)
+			}
+			var ce *CycleError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Resolve() error is %T, want *CycleError", err)
+			}
+			var got []string
+			for _, c := range ce.Cycles {
+				got = append(got, FormatCycle(c))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("cycles = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// chain returns a diagram of n nodes where node i depends on node i-1 and on node 0.
+func chain(n int) *diagram.Diagram {
+	d := &diagram.Diagram{}
+	for i := 0; i < n; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: fmt.Sprintf("n%d", i)})
+		if i > 0 {
+			d.Edges = append(d.Edges,
+				diagram.Edge{Source: fmt.Sprintf("n%d", i-1), Target: fmt.Sprintf("n%d", i)},
+				diagram.Edge{Source: "n0", Target: fmt.Sprintf("n%d", i)},
+			)
+		}
+	}
+	return d
+}
+
+func TestResolveLargeChain(t *testing.T) {
+	ordered, tiers, err := Resolve(chain(5000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ordered) != 5000 || len(tiers) != 5000 || ordered[4999] != "n4999" {
+		t.Fatalf("got %d ordered, %d tiers", len(ordered), len(tiers))
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	d := chain(2000)
+	d = diagram.Normalize(d)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Resolve(d); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
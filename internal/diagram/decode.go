@@ -0,0 +1,539 @@
+package diagram
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Location is where a value starts in the source JSON. Line and Column are 1-based;
+// Column counts characters, not bytes.
+type Location struct {
+	Path   string `json:"path"` // JSON pointer, e.g. /nodes/2/properties/ami
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// Locations maps JSON pointers to the location of their value.
+type Locations map[string]Location
+
+// DecodeError is a problem found while decoding diagram JSON.
+type DecodeError struct {
+	Location
+	Message string
+}
+
+func (e *DecodeError) Error() string {
+	where := fmt.Sprintf("line %d, column %d", e.Line, e.Column)
+	if e.Path != "" {
+		where += " (" + e.Path + ")"
+	}
+	return where + ": " + e.Message
+}
+
+// DecodeErrors collects every problem found in one document.
+type DecodeErrors []*DecodeError
+
+func (e DecodeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, de := range e {
+		msgs[i] = de.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Decoder reads a diagram token by token, recording the location of every node,
+// edge and property so later errors can point back into the source. It is not a
+// streaming decoder: the input is read into memory first, to map offsets to lines.
+type Decoder struct {
+	// Strict rejects fields that are not part of the diagram format. Keys inside
+	// node and edge properties are never checked here; handlers own those.
+	Strict bool
+
+	r   io.Reader
+	src []byte // whole input, for offset -> line/column
+	// lines holds the offset at which each line of src starts; col caches the column of
+	// offset colOff, so the values along a long line are located without rescanning it.
+	lines  []int
+	colOff int
+	col    int
+	dec    *json.Decoder
+	locs   Locations
+	errs   DecodeErrors
+}
+
+// NewDecoder returns a decoder reading from r. The input is buffered in memory so
+// every offset can be mapped to a line and column.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, locs: make(Locations)}
+}
+
+// Decode parses data as a diagram. See Decoder for the meaning of strict.
+func Decode(data []byte, strict bool) (*Diagram, error) {
+	dec := NewDecoder(bytes.NewReader(data))
+	dec.Strict = strict
+	return dec.Decode()
+}
+
+// Decode reads one diagram. Syntax errors stop decoding; type mismatches and (in strict
+// mode) unknown fields are collected. Any problem is returned as DecodeErrors.
+func (d *Decoder) Decode() (*Diagram, error) {
+	src, err := io.ReadAll(d.r)
+	if err != nil {
+		return nil, err
+	}
+	d.src = src
+	d.lines = append(d.lines[:0], 0)
+	for i, b := range src {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	d.colOff, d.col = 0, 1
+	d.dec = json.NewDecoder(bytes.NewReader(src))
+	d.dec.UseNumber()
+
+	var out Diagram
+	err = d.object("", func(key, ptr string) error {
+		switch key {
+		case "metadata":
+			return d.metadata(ptr, &out.Metadata)
+		case "nodes":
+			return d.array(ptr, func(ptr string) error {
+				out.Nodes = append(out.Nodes, Node{})
+				return d.node(ptr, &out.Nodes[len(out.Nodes)-1])
+			})
+		case "edges":
+			return d.array(ptr, func(ptr string) error {
+				out.Edges = append(out.Edges, Edge{})
+				return d.edge(ptr, &out.Edges[len(out.Edges)-1])
+			})
+		default:
+			return d.unknown(ptr)
+		}
+	})
+	if err == nil {
+		if _, tokErr := d.dec.Token(); tokErr != io.EOF {
+			d.fail(d.dec.InputOffset(), "", "unexpected data after the diagram object")
+		}
+	} else {
+		d.syntaxError(err)
+	}
+	if len(d.errs) > 0 {
+		return nil, d.errs
+	}
+	out.Locations = d.locs
+	return &out, nil
+}
+
+func (d *Decoder) metadata(ptr string, m *Metadata) error {
+	return d.object(ptr, func(key, ptr string) error {
+		switch key {
+		case "version":
+			return d.str(ptr, &m.Version)
+		case "name":
+			return d.str(ptr, &m.Name)
+		case "description":
+			return d.str(ptr, &m.Description)
+		case "environment":
+			return d.str(ptr, &m.Environment)
+		case "region":
+			return d.str(ptr, &m.Region)
+		default:
+			return d.unknown(ptr)
+		}
+	})
+}
+
+func (d *Decoder) node(ptr string, n *Node) error {
+	return d.object(ptr, func(key, ptr string) error {
+		switch key {
+		case "id":
+			return d.str(ptr, &n.ID)
+		case "type":
+			return d.str(ptr, &n.Type)
+		case "label":
+			return d.str(ptr, &n.Label)
+		case "position":
+			return d.object(ptr, func(key, ptr string) error {
+				switch key {
+				case "x":
+					return d.num(ptr, &n.Position.X)
+				case "y":
+					return d.num(ptr, &n.Position.Y)
+				default:
+					return d.unknown(ptr)
+				}
+			})
+		case "properties":
+			return d.properties(ptr, &n.Properties)
+		case "suppress":
+			return d.array(ptr, func(ptr string) error {
+				var id string
+				err := d.str(ptr, &id)
+				n.Suppress = append(n.Suppress, id)
+				return err
+			})
+		default:
+			return d.unknown(ptr)
+		}
+	})
+}
+
+func (d *Decoder) edge(ptr string, e *Edge) error {
+	return d.object(ptr, func(key, ptr string) error {
+		switch key {
+		case "id":
+			return d.str(ptr, &e.ID)
+		case "source":
+			return d.str(ptr, &e.Source)
+		case "target":
+			return d.str(ptr, &e.Target)
+		case "type":
+			return d.str(ptr, &e.Type)
+		case "properties":
+			return d.properties(ptr, &e.Properties)
+		default:
+			return d.unknown(ptr)
+		}
+	})
+}
+
+func (d *Decoder) properties(ptr string, dst *map[string]any) error {
+	start := d.mark(ptr)
+	v, err := d.value(ptr, false)
+	if err != nil {
+		return err
+	}
+	switch m := v.(type) {
+	case map[string]any:
+		*dst = m
+	case nil: // null leaves properties unset
+	default:
+		d.fail(start, ptr, "properties must be an object, got "+describeValue(v))
+	}
+	return nil
+}
+
+// object reads a JSON object, calling field for each key with the member's pointer.
+// A null value is accepted and leaves the destination untouched.
+func (d *Decoder) object(ptr string, field func(key, ptr string) error) error {
+	start := d.mark(ptr)
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		d.fail(start, ptr, "expected an object, got "+describe(tok))
+		return d.skipRest(tok)
+	}
+	for d.dec.More() {
+		keyTok, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		key := keyTok.(string)
+		if err := field(key, ptr+"/"+escapePointer(key)); err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token() // '}'
+	return err
+}
+
+func (d *Decoder) array(ptr string, elem func(ptr string) error) error {
+	start := d.mark(ptr)
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		d.fail(start, ptr, "expected an array, got "+describe(tok))
+		return d.skipRest(tok)
+	}
+	for i := 0; d.dec.More(); i++ {
+		if err := elem(ptr + "/" + strconv.Itoa(i)); err != nil {
+			return err
+		}
+	}
+	_, err = d.dec.Token() // ']'
+	return err
+}
+
+func (d *Decoder) str(ptr string, dst *string) error {
+	start := d.mark(ptr)
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case string:
+		*dst = v
+	case nil:
+	default:
+		d.fail(start, ptr, "expected a string, got "+describe(tok))
+		return d.skipRest(tok)
+	}
+	return nil
+}
+
+func (d *Decoder) num(ptr string, dst *float64) error {
+	start := d.mark(ptr)
+	tok, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch v := tok.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		*dst = f
+	case nil:
+	default:
+		d.fail(start, ptr, "expected a number, got "+describe(tok))
+		return d.skipRest(tok)
+	}
+	return nil
+}
+
+// unknown skips a field that is not part of the format, reporting it in strict mode.
+func (d *Decoder) unknown(ptr string) error {
+	start := d.mark(ptr)
+	if d.Strict {
+		key := ptr[strings.LastIndex(ptr, "/")+1:]
+		d.fail(start, ptr, fmt.Sprintf("unknown field %q", unescapePointer(key)))
+	}
+	_, err := d.value(ptr, true)
+	return err
+}
+
+// value decodes any JSON value into the same Go types as encoding/json
+// (numbers become float64), recording locations of nested values unless skip is set.
+func (d *Decoder) value(ptr string, skip bool) (any, error) {
+	if !skip {
+		d.mark(ptr)
+	}
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		if n, isNum := tok.(json.Number); isNum {
+			f, _ := n.Float64()
+			return f, nil
+		}
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		m := make(map[string]any)
+		for d.dec.More() {
+			keyTok, err := d.dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			v, err := d.value(ptr+"/"+escapePointer(key), skip)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		}
+		_, err = d.dec.Token()
+		return m, err
+	case '[':
+		list := []any{}
+		for i := 0; d.dec.More(); i++ {
+			v, err := d.value(ptr+"/"+strconv.Itoa(i), skip)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		_, err = d.dec.Token()
+		return list, err
+	}
+	return nil, fmt.Errorf("unexpected %v", delim)
+}
+
+// skipRest consumes the remainder of a value whose first token was already read.
+func (d *Decoder) skipRest(tok json.Token) error {
+	delim, ok := tok.(json.Delim)
+	if !ok || (delim != '{' && delim != '[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		t, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		if dl, ok := t.(json.Delim); ok {
+			if dl == '{' || dl == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// mark records the location of the next value under ptr and returns its offset.
+func (d *Decoder) mark(ptr string) int64 {
+	off := d.nextValueOffset()
+	line, col := d.position(int64(off))
+	d.locs[ptr] = Location{Path: ptr, Line: line, Column: col}
+	return int64(off)
+}
+
+// nextValueOffset skips whitespace and separators after the last token.
+func (d *Decoder) nextValueOffset() int {
+	src := d.src
+	off := int(d.dec.InputOffset())
+	for off < len(src) {
+		switch src[off] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+			off++
+			continue
+		}
+		break
+	}
+	return off
+}
+
+// position converts a byte offset into a 1-based line and character column. Offsets mostly
+// increase while decoding, so the column is counted on from the previous one on the same line.
+func (d *Decoder) position(off int64) (line, col int) {
+	o := int(off)
+	if o > len(d.src) {
+		o = len(d.src)
+	}
+	line = sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > o })
+	lineStart := d.lines[line-1]
+	if d.colOff >= lineStart && d.colOff <= o {
+		col = d.col + utf8.RuneCount(d.src[d.colOff:o])
+	} else {
+		col = utf8.RuneCount(d.src[lineStart:o]) + 1
+	}
+	d.colOff, d.col = o, col
+	return line, col
+}
+
+func (d *Decoder) fail(off int64, ptr, msg string) {
+	line, col := d.position(off)
+	d.errs = append(d.errs, &DecodeError{Location: Location{Path: ptr, Line: line, Column: col}, Message: msg})
+}
+
+// syntaxError records a fatal error from encoding/json at the closest known location.
+func (d *Decoder) syntaxError(err error) {
+	off := d.dec.InputOffset()
+	msg := err.Error()
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		off = se.Offset - 1 // Offset counts the offending byte
+		msg = "invalid JSON: " + se.Error()
+	} else if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		off = int64(len(d.src))
+		msg = "invalid JSON: unexpected end of input"
+	}
+	d.fail(off, d.enclosing(off), msg)
+}
+
+// enclosing returns the pointer of the last value that starts at or before off.
+func (d *Decoder) enclosing(off int64) string {
+	line, col := d.position(off)
+	var best Location
+	for _, l := range d.locs {
+		if l.Line < line || (l.Line == line && l.Column <= col) {
+			if l.Line > best.Line || (l.Line == best.Line && l.Column > best.Column) {
+				best = l
+			}
+		}
+	}
+	return best.Path
+}
+
+func describe(tok json.Token) string {
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '{' {
+			return "object"
+		}
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", tok)
+}
+
+func describeValue(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return "null"
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
+
+// Locate returns the location of the value at ptr, or of its closest recorded ancestor.
+func (l Locations) Locate(ptr string) (Location, bool) {
+	for {
+		if loc, ok := l[ptr]; ok {
+			return loc, true
+		}
+		i := strings.LastIndex(ptr, "/")
+		if i < 0 {
+			return Location{}, false
+		}
+		ptr = ptr[:i]
+	}
+}
+
+// Pointers returns all recorded pointers in document order.
+func (l Locations) Pointers() []string {
+	out := make([]string, 0, len(l))
+	for p := range l {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := l[out[i]], l[out[j]]
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return out
+}
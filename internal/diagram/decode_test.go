@@ -0,0 +1,154 @@
+package diagram
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const decodeSrc = `{
+  "metadata": {"version": "1.0", "environment": "dev"},
+  "nodes": [
+    {"id": "vpc", "type": "vpc", "properties": {"cidr_block": "10.0.0.0/16", "tags": {"Name": "main"}}},
+    {"id": "web", "type": "ec2_instance", "extra": true}
+  ],
+  "edges": [{"id": "e1", "source": "vpc", "target": "web"}]
+}`
+
+func decodeErrs(t *testing.T, err error) DecodeErrors {
+	t.Helper()
+	var errs DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error %v is not DecodeErrors", err)
+	}
+	return errs
+}
+
+func TestDecodeMatchesStructure(t *testing.T) {
+	d, err := Decode([]byte(decodeSrc), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Metadata.Environment != "dev" || len(d.Nodes) != 2 || len(d.Edges) != 1 {
+		t.Fatalf("decoded %+v", d)
+	}
+	want := map[string]any{"cidr_block": "10.0.0.0/16", "tags": map[string]any{"Name": "main"}}
+	if !reflect.DeepEqual(d.Nodes[0].Properties, want) {
+		t.Errorf("properties = %v, want %v", d.Nodes[0].Properties, want)
+	}
+}
+
+func TestDecodeLocations(t *testing.T) {
+	d, err := Decode([]byte(decodeSrc), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]Location{
+		"/nodes/0/properties/cidr_block": {Line: 4, Column: 63},
+		"/nodes/1":                       {Line: 5, Column: 5},
+		"/edges/0/target":                {Line: 7, Column: 53},
+		// Pointers without their own entry fall back to the nearest ancestor.
+		"/nodes/1/properties/ami": {Line: 5, Column: 5},
+	}
+	for ptr, want := range cases {
+		got, ok := d.Locations.Locate(ptr)
+		if !ok || got.Line != want.Line || got.Column != want.Column {
+			t.Errorf("Locate(%s) = %+v, %v; want line %d column %d", ptr, got, ok, want.Line, want.Column)
+		}
+	}
+}
+
+func TestDecodeStrictRejectsUnknownFields(t *testing.T) {
+	_, err := Decode([]byte(decodeSrc), true)
+	errs := decodeErrs(t, err)
+	if len(errs) != 1 || errs[0].Path != "/nodes/1/extra" || errs[0].Line != 5 {
+		t.Fatalf("errors = %v", err)
+	}
+}
+
+func TestDecodeCollectsTypeMismatches(t *testing.T) {
+	src := `{"metadata": {"version": 1}, "nodes": [{"id": "a", "type": 7, "properties": []}]}`
+	_, err := Decode([]byte(src), false)
+	errs := decodeErrs(t, err)
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	want := []string{"/metadata/version", "/nodes/0/type", "/nodes/0/properties"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+}
+
+func TestDecodeSyntaxErrorLocation(t *testing.T) {
+	src := "{\n  \"nodes\": [\n    {\"id\": \"a\",}\n  ]\n}"
+	_, err := Decode([]byte(src), false)
+	errs := decodeErrs(t, err)
+	if len(errs) != 1 || errs[0].Line != 3 || errs[0].Column != 15 {
+		t.Fatalf("errors = %v", err)
+	}
+}
+
+// largeDiagram returns a diagram of n nodes chained by edges, on one line when compact.
+func largeDiagram(n int, compact bool) []byte {
+	var b strings.Builder
+	b.WriteString(`{"metadata": {"version": "1.0"}, "nodes": [`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		if !compact {
+			b.WriteString("\n  ")
+		}
+		fmt.Fprintf(&b, `{"id": "n%d", "type": "s3_bucket", "label": "Bücket %d", "properties": {"bucket": "b-%d", "tags": {"Name": "é%d"}}}`, i, i, i, i)
+	}
+	b.WriteString(`], "edges": [`)
+	for i := 1; i < n; i++ {
+		if i > 1 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id": "e%d", "source": "n%d", "target": "n%d"}`, i, i-1, i)
+	}
+	b.WriteString("]}")
+	return []byte(b.String())
+}
+
+func TestDecodeLocationsMatchOffsets(t *testing.T) {
+	for _, compact := range []bool{false, true} {
+		src := largeDiagram(50, compact)
+		d, err := Decode(src, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for ptr, loc := range d.Locations {
+			// the value of a node's label starts with a quote followed by "Bücket"
+			if !strings.HasSuffix(ptr, "/label") {
+				continue
+			}
+			lines := bytes.Split(src, []byte("\n"))
+			line := lines[loc.Line-1]
+			var off, col int
+			for col = 1; col < loc.Column; col++ {
+				_, size := utf8.DecodeRune(line[off:])
+				off += size
+			}
+			if !bytes.HasPrefix(line[off:], []byte(`"Bücket`)) {
+				t.Errorf("compact=%v: %s at line %d column %d points at %.20q", compact, ptr, loc.Line, loc.Column, line[off:])
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeLarge(b *testing.B) {
+	src := largeDiagram(5000, false)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(src, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package diagram
+
+// Graph is an index over a diagram's nodes and edges: node lookup by id and per-node
+// incoming/outgoing edge lists, each in diagram order. Build it once with NewGraph and
+// share it; it is read-only and safe for concurrent use.
+type Graph struct {
+	d        *Diagram
+	position map[string]int // node ID -> index in d.Nodes (first occurrence)
+	in       [][]int        // node index -> indexes into d.Edges with that node as target
+	out      [][]int        // node index -> indexes into d.Edges with that node as source
+}
+
+// NewGraph indexes d. Edges whose source or target is not a node are left out of the
+// adjacency lists (and so of EdgesWithTarget and EdgesWithSource) but are still visible
+// through d.Edges.
+func NewGraph(d *Diagram) *Graph {
+	g := &Graph{
+		d:        d,
+		position: make(map[string]int, len(d.Nodes)),
+		in:       make([][]int, len(d.Nodes)),
+		out:      make([][]int, len(d.Nodes)),
+	}
+	for i := range d.Nodes {
+		if _, dup := g.position[d.Nodes[i].ID]; !dup {
+			g.position[d.Nodes[i].ID] = i
+		}
+	}
+	for i, e := range d.Edges {
+		s, okS := g.position[e.Source]
+		t, okT := g.position[e.Target]
+		if !okS || !okT {
+			continue
+		}
+		g.out[s] = append(g.out[s], i)
+		g.in[t] = append(g.in[t], i)
+	}
+	return g
+}
+
+// Len returns the number of nodes.
+func (g *Graph) Len() int { return len(g.d.Nodes) }
+
+// Index returns the position of the node in the diagram's node list.
+func (g *Graph) Index(id string) (int, bool) {
+	i, ok := g.position[id]
+	return i, ok
+}
+
+// Node returns the node with the given id, or nil.
+func (g *Graph) Node(id string) *Node {
+	if i, ok := g.position[id]; ok {
+		return &g.d.Nodes[i]
+	}
+	return nil
+}
+
+// NodeAt returns the node at position i.
+func (g *Graph) NodeAt(i int) *Node { return &g.d.Nodes[i] }
+
+// EdgeAt returns the edge at position i.
+func (g *Graph) EdgeAt(i int) *Edge { return &g.d.Edges[i] }
+
+// In returns the indexes of edges targeting the node at position i.
+func (g *Graph) In(i int) []int { return g.in[i] }
+
+// Out returns the indexes of edges leaving the node at position i.
+func (g *Graph) Out(i int) []int { return g.out[i] }
+
+// EdgesWithTarget returns edges whose target is the given node id.
+func (g *Graph) EdgesWithTarget(id string) []Edge {
+	i, ok := g.position[id]
+	if !ok {
+		return nil
+	}
+	return g.edges(g.in[i])
+}
+
+// EdgesWithSource returns edges whose source is the given node id.
+func (g *Graph) EdgesWithSource(id string) []Edge {
+	i, ok := g.position[id]
+	if !ok {
+		return nil
+	}
+	return g.edges(g.out[i])
+}
+
+func (g *Graph) edges(idx []int) []Edge {
+	if len(idx) == 0 {
+		return nil
+	}
+	out := make([]Edge, len(idx))
+	for k, i := range idx {
+		out[k] = g.d.Edges[i]
+	}
+	return out
+}
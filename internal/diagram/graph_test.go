@@ -0,0 +1,78 @@
+package diagram
+
+import (
+	"reflect"
+	"testing"
+)
+
+func edgeIDs(edges []Edge) []string {
+	var out []string
+	for _, e := range edges {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestGraphMatchesLinearLookups(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: "vpc"}, {ID: "subnet"}, {ID: "web"}},
+		Edges: []Edge{
+			{ID: "e1", Source: "vpc", Target: "subnet"},
+			{ID: "e2", Source: "subnet", Target: "web"},
+			{ID: "e3", Source: "vpc", Target: "web"},
+		},
+	}
+	g := NewGraph(d)
+	for _, n := range d.Nodes {
+		if got, want := edgeIDs(g.EdgesWithTarget(n.ID)), edgeIDs(d.EdgesWithTarget(n.ID)); !reflect.DeepEqual(got, want) {
+			t.Errorf("EdgesWithTarget(%s) = %v, want %v", n.ID, got, want)
+		}
+		if got, want := edgeIDs(g.EdgesWithSource(n.ID)), edgeIDs(d.EdgesWithSource(n.ID)); !reflect.DeepEqual(got, want) {
+			t.Errorf("EdgesWithSource(%s) = %v, want %v", n.ID, got, want)
+		}
+		if g.Node(n.ID) != d.NodeByID(n.ID) {
+			t.Errorf("Node(%s) returned a different node", n.ID)
+		}
+	}
+	if g.Node("ghost") != nil || g.EdgesWithTarget("ghost") != nil {
+		t.Error("unknown ids must not resolve")
+	}
+}
+
+func TestGraphSkipsDanglingEdges(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: "vpc"}, {ID: "web"}},
+		Edges: []Edge{
+			{ID: "e1", Source: "vpc", Target: "web"},
+			{ID: "e2", Source: "ghost", Target: "web"},
+			{ID: "e3", Source: "vpc", Target: "ghost"},
+		},
+	}
+	g := NewGraph(d)
+	if got := edgeIDs(g.EdgesWithTarget("web")); !reflect.DeepEqual(got, []string{"e1"}) {
+		t.Errorf("EdgesWithTarget(web) = %v, want [e1]", got)
+	}
+	if got := edgeIDs(g.EdgesWithSource("vpc")); !reflect.DeepEqual(got, []string{"e1"}) {
+		t.Errorf("EdgesWithSource(vpc) = %v, want [e1]", got)
+	}
+}
+
+func TestNormalizeIndexesCopy(t *testing.T) {
+	d := &Diagram{
+		Nodes: []Node{{ID: "a"}, {ID: "b"}},
+		Edges: []Edge{{ID: "e1", Source: "a", Target: "b"}},
+	}
+	nd := Normalize(d)
+	if nd.graph == nil {
+		t.Fatal("Normalize did not build the graph index")
+	}
+	if got := nd.NodeByID("b"); got != &nd.Nodes[1] {
+		t.Error("NodeByID must return a pointer into the normalized copy")
+	}
+	if got := nd.EdgesWithTarget("b"); len(got) != 1 || got[0].Type != "depends_on" {
+		t.Errorf("EdgesWithTarget(b) = %+v, want one depends_on edge", got)
+	}
+	if d.Edges[0].Type != "" || d.Nodes[0].Properties != nil || d.graph != nil {
+		t.Error("Normalize modified its input")
+	}
+}
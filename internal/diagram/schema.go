@@ -1,10 +1,17 @@
 package diagram
 
+import "strings"
+
 // Diagram is the root structure of the infrastructure diagram JSON.
 type Diagram struct {
-	Metadata Metadata  `json:"metadata"`
-	Nodes    []Node    `json:"nodes"`
-	Edges    []Edge    `json:"edges"`
+	Metadata Metadata `json:"metadata"`
+	Nodes    []Node   `json:"nodes"`
+	Edges    []Edge   `json:"edges"`
+
+	// Locations records where each value was found when decoded with Decode; nil otherwise.
+	Locations Locations `json:"-"`
+
+	graph *Graph // index set by Normalize; nil for diagrams built elsewhere
 }
 
 // Metadata holds diagram-level information.
@@ -13,15 +20,37 @@ type Metadata struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Environment string `json:"environment"`
+	Region      string `json:"region,omitempty"` // AWS region; DefaultRegion when empty
+}
+
+// DefaultRegion is the AWS region used when metadata.region is not set.
+const DefaultRegion = "us-east-1"
+
+// AWSRegion returns the configured region, or DefaultRegion.
+func (m Metadata) AWSRegion() string {
+	if m.Region != "" {
+		return m.Region
+	}
+	return DefaultRegion
+}
+
+// IsProduction reports whether the environment is production ("production" or "prod", any case).
+func (m Metadata) IsProduction() bool {
+	switch strings.ToLower(m.Environment) {
+	case "production", "prod":
+		return true
+	}
+	return false
 }
 
 // Node represents a single resource in the diagram.
 type Node struct {
-	ID         string            `json:"id"`
-	Type       string            `json:"type"`
-	Label      string            `json:"label"`
-	Position   Position         `json:"position"`
-	Properties map[string]any    `json:"properties"`
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Label      string         `json:"label"`
+	Position   Position       `json:"position"`
+	Properties map[string]any `json:"properties"`
+	Suppress   []string       `json:"suppress,omitempty"` // lint rule ids not reported for this node
 }
 
 // Position holds x,y coordinates (used by the diagram UI).
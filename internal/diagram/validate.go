@@ -9,11 +9,50 @@ type ValidationError struct {
 	Type       string `json:"type"`
 	Severity   string `json:"severity"` // error
 	NodeID     string `json:"node_id,omitempty"`
+	Path       string `json:"path,omitempty"` // JSON pointer to the offending value
 	Message    string `json:"message"`
 	Suggestion string `json:"suggestion,omitempty"`
 }
 
-// Validate checks required fields and structure of the diagram.
+// Normalize returns a copy of the diagram with defaults applied: nil node properties become
+// empty maps and edges without a type become "depends_on". The input is not modified, so
+// callers may share one Diagram between concurrent parses. Property maps are shared with the input.
+// The copy carries a prebuilt Graph, so NodeByID and the Edges* lookups are constant time;
+// it must not be modified afterwards.
+func Normalize(d *Diagram) *Diagram {
+	if d == nil {
+		return nil
+	}
+	out := &Diagram{
+		Metadata: d.Metadata,
+		Nodes:    make([]Node, len(d.Nodes)),
+		Edges:    make([]Edge, len(d.Edges)),
+	}
+	copy(out.Nodes, d.Nodes)
+	copy(out.Edges, d.Edges)
+	for i := range out.Nodes {
+		if out.Nodes[i].Properties == nil {
+			out.Nodes[i].Properties = make(map[string]any)
+		}
+	}
+	for i := range out.Edges {
+		if out.Edges[i].Type == "" {
+			out.Edges[i].Type = "depends_on"
+		}
+	}
+	out.graph = NewGraph(out)
+	return out
+}
+
+// Graph returns the diagram's index: the one built by Normalize, or a fresh one otherwise.
+func (d *Diagram) Graph() *Graph {
+	if d.graph != nil {
+		return d.graph
+	}
+	return NewGraph(d)
+}
+
+// Validate checks required fields and structure of the diagram. It does not modify d.
 // Resource-specific validation is done by handlers.
 func Validate(d *Diagram) []ValidationError {
 	var errs []ValidationError
@@ -24,7 +63,7 @@ func Validate(d *Diagram) []ValidationError {
 
 	if d.Metadata.Version == "" {
 		errs = append(errs, ValidationError{
-			Type: "schema_error", Severity: "error",
+			Type: "schema_error", Severity: "error", Path: "/metadata/version",
 			Message: "metadata.version is required", Suggestion: "Set metadata.version (e.g. \"1.0\")",
 		})
 	}
@@ -34,12 +73,12 @@ func Validate(d *Diagram) []ValidationError {
 		n := &d.Nodes[i]
 		if n.ID == "" {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error", NodeID: n.ID,
+				Type: "schema_error", Severity: "error", NodeID: n.ID, Path: fmt.Sprintf("/nodes/%d/id", i),
 				Message: fmt.Sprintf("node at index %d has empty id", i), Suggestion: "Set node.id",
 			})
 		} else if seenNodeIDs[n.ID] {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error", NodeID: n.ID,
+				Type: "schema_error", Severity: "error", NodeID: n.ID, Path: fmt.Sprintf("/nodes/%d/id", i),
 				Message: "duplicate node id: " + n.ID, Suggestion: "Use unique ids for each node",
 			})
 		} else {
@@ -47,39 +86,33 @@ func Validate(d *Diagram) []ValidationError {
 		}
 		if n.Type == "" {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error", NodeID: n.ID,
+				Type: "schema_error", Severity: "error", NodeID: n.ID, Path: fmt.Sprintf("/nodes/%d/type", i),
 				Message: "node.type is required", Suggestion: "Set node.type (e.g. ec2_instance, vpc)",
 			})
 		}
-		if n.Properties == nil {
-			n.Properties = make(map[string]any)
-		}
 	}
 
 	for i := range d.Edges {
 		e := &d.Edges[i]
 		if e.Source == "" || e.Target == "" {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error",
-				Message: fmt.Sprintf("edge at index %d must have source and target", i),
+				Type: "schema_error", Severity: "error", Path: fmt.Sprintf("/edges/%d", i),
+				Message:    fmt.Sprintf("edge at index %d must have source and target", i),
 				Suggestion: "Set edge.source and edge.target to node ids",
 			})
 		} else if !seenNodeIDs[e.Source] {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error",
-				Message: "edge source node not found: " + e.Source,
+				Type: "schema_error", Severity: "error", Path: fmt.Sprintf("/edges/%d/source", i),
+				Message:    "edge source node not found: " + e.Source,
 				Suggestion: "Reference an existing node id",
 			})
 		} else if !seenNodeIDs[e.Target] {
 			errs = append(errs, ValidationError{
-				Type: "schema_error", Severity: "error",
-				Message: "edge target node not found: " + e.Target,
+				Type: "schema_error", Severity: "error", Path: fmt.Sprintf("/edges/%d/target", i),
+				Message:    "edge target node not found: " + e.Target,
 				Suggestion: "Reference an existing node id",
 			})
 		}
-		if e.Type == "" {
-			e.Type = "depends_on"
-		}
 	}
 
 	return errs
@@ -87,6 +120,9 @@ func Validate(d *Diagram) []ValidationError {
 
 // NodeByID returns the node with the given id, or nil.
 func (d *Diagram) NodeByID(id string) *Node {
+	if d.graph != nil {
+		return d.graph.Node(id)
+	}
 	for i := range d.Nodes {
 		if d.Nodes[i].ID == id {
 			return &d.Nodes[i]
@@ -97,6 +133,9 @@ func (d *Diagram) NodeByID(id string) *Node {
 
 // EdgesWithTarget returns edges whose target is the given node id.
 func (d *Diagram) EdgesWithTarget(targetID string) []Edge {
+	if d.graph != nil {
+		return d.graph.EdgesWithTarget(targetID)
+	}
 	var out []Edge
 	for _, e := range d.Edges {
 		if e.Target == targetID {
@@ -108,6 +147,9 @@ func (d *Diagram) EdgesWithTarget(targetID string) []Edge {
 
 // EdgesWithSource returns edges whose source is the given node id.
 func (d *Diagram) EdgesWithSource(sourceID string) []Edge {
+	if d.graph != nil {
+		return d.graph.EdgesWithSource(sourceID)
+	}
 	var out []Edge
 	for _, e := range d.Edges {
 		if e.Source == sourceID {
@@ -118,6 +160,9 @@ func (d *Diagram) EdgesWithSource(sourceID string) []Edge {
 }
 
 // GetStr gets a string property; empty if missing or not a string.
+//
+// Deprecated: handlers decode properties with properties.Decode, which reports
+// mistyped values instead of treating them as missing.
 func GetStr(m map[string]any, key string) string {
 	if m == nil {
 		return ""
@@ -131,6 +176,9 @@ func GetStr(m map[string]any, key string) string {
 }
 
 // GetBool gets a bool property.
+//
+// Deprecated: handlers decode properties with properties.Decode, which reports
+// mistyped values instead of treating them as missing.
 func GetBool(m map[string]any, key string) bool {
 	if m == nil {
 		return false
@@ -144,6 +192,9 @@ func GetBool(m map[string]any, key string) bool {
 }
 
 // GetInt gets an int property (from float64 JSON number).
+//
+// Deprecated: handlers decode properties with properties.Decode, which reports
+// mistyped values instead of treating them as missing.
 func GetInt(m map[string]any, key string) int {
 	if m == nil {
 		return 0
@@ -176,6 +227,9 @@ func GetMap(m map[string]any, key string) map[string]any {
 }
 
 // GetStrMap returns a map of string -> string (e.g. tags).
+//
+// Deprecated: handlers decode properties with properties.Decode, which reports
+// mistyped values instead of treating them as missing.
 func GetStrMap(m map[string]any, key string) map[string]string {
 	raw := GetMap(m, key)
 	if raw == nil {
@@ -189,4 +243,3 @@ func GetStrMap(m map[string]any, key string) map[string]string {
 	}
 	return out
 }
-
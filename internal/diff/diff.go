@@ -0,0 +1,194 @@
+// Package diff compares two versions of a diagram: nodes are matched by id and edges by id,
+// and property changes are reported with paths relative to the node, as in result.Error.
+// Resources compares the plan models generated from both versions to tell which Terraform
+// resources would be created, updated, replaced or deleted, and which of those changes
+// destroy infrastructure.
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/secrets"
+)
+
+// Actions on nodes and edges.
+const (
+	Added    = "added"
+	Removed  = "removed"
+	Modified = "modified"
+)
+
+// Report lists the changes between two diagrams.
+type Report struct {
+	Metadata  []Change         `json:"metadata,omitempty"`
+	Nodes     []NodeChange     `json:"nodes,omitempty"`
+	Edges     []EdgeChange     `json:"edges,omitempty"`
+	Resources []ResourceChange `json:"resources,omitempty"`
+}
+
+// Change is one changed value. Path is relative to the node or edge ("properties/cidr_block",
+// "properties/ingress/0/from_port", "label") or, for metadata, to the document ("metadata/region").
+// Old is null for added values and New for removed ones.
+type Change struct {
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// NodeChange is an added, removed or modified node. Changes is set for modified nodes only.
+type NodeChange struct {
+	NodeID  string   `json:"node_id"`
+	Type    string   `json:"type"` // the new type, or the old one for removed nodes
+	Action  string   `json:"action"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// EdgeChange is an added, removed or modified edge, described by its new version (the old
+// one for removed edges).
+type EdgeChange struct {
+	EdgeID  string   `json:"edge_id"`
+	Source  string   `json:"source"`
+	Target  string   `json:"target"`
+	Type    string   `json:"type"`
+	Action  string   `json:"action"`
+	Changes []Change `json:"changes,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (r *Report) Empty() bool {
+	return len(r.Metadata) == 0 && len(r.Nodes) == 0 && len(r.Edges) == 0 && len(r.Resources) == 0
+}
+
+// Diagrams compares two diagrams. Nodes and edges are reported in the order of the new
+// diagram, followed by removed ones in the order of the old diagram. Node positions are
+// layout only and are ignored. Values of secret properties (see secrets) are replaced by
+// secrets.Redacted.
+func Diagrams(old, new *diagram.Diagram, reg *registry.Registry) *Report {
+	r := &Report{}
+	r.Metadata = compare("metadata", metadataMap(old.Metadata), metadataMap(new.Metadata), nil)
+
+	oldNodes := make(map[string]*diagram.Node, len(old.Nodes))
+	for i := range old.Nodes {
+		oldNodes[old.Nodes[i].ID] = &old.Nodes[i]
+	}
+	seen := make(map[string]bool)
+	for i := range new.Nodes {
+		n := &new.Nodes[i]
+		seen[n.ID] = true
+		o, ok := oldNodes[n.ID]
+		if !ok {
+			r.Nodes = append(r.Nodes, NodeChange{NodeID: n.ID, Type: n.Type, Action: Added})
+			continue
+		}
+		secret := make(map[string]bool)
+		for _, name := range reg.SecretProperties(n.Type) {
+			secret["properties/"+name] = true
+		}
+		var changes []Change
+		changes = append(changes, compare("type", o.Type, n.Type, nil)...)
+		changes = append(changes, compare("label", o.Label, n.Label, nil)...)
+		changes = append(changes, compare("properties", props(o.Properties), props(n.Properties), secret)...)
+		if len(changes) > 0 {
+			r.Nodes = append(r.Nodes, NodeChange{NodeID: n.ID, Type: n.Type, Action: Modified, Changes: changes})
+		}
+	}
+	for _, o := range old.Nodes {
+		if !seen[o.ID] {
+			r.Nodes = append(r.Nodes, NodeChange{NodeID: o.ID, Type: o.Type, Action: Removed})
+		}
+	}
+
+	oldEdges := make(map[string]*diagram.Edge, len(old.Edges))
+	for i := range old.Edges {
+		oldEdges[old.Edges[i].ID] = &old.Edges[i]
+	}
+	seen = make(map[string]bool)
+	for _, e := range new.Edges {
+		seen[e.ID] = true
+		c := EdgeChange{EdgeID: e.ID, Source: e.Source, Target: e.Target, Type: e.Type, Action: Added}
+		if o, ok := oldEdges[e.ID]; ok {
+			c.Action = Modified
+			c.Changes = append(c.Changes, compare("source", o.Source, e.Source, nil)...)
+			c.Changes = append(c.Changes, compare("target", o.Target, e.Target, nil)...)
+			c.Changes = append(c.Changes, compare("type", o.Type, e.Type, nil)...)
+			c.Changes = append(c.Changes, compare("properties", props(o.Properties), props(e.Properties), nil)...)
+			if len(c.Changes) == 0 {
+				continue
+			}
+		}
+		r.Edges = append(r.Edges, c)
+	}
+	for _, o := range old.Edges {
+		if !seen[o.ID] {
+			r.Edges = append(r.Edges, EdgeChange{EdgeID: o.ID, Source: o.Source, Target: o.Target, Type: o.Type, Action: Removed})
+		}
+	}
+	return r
+}
+
+func metadataMap(m diagram.Metadata) map[string]any {
+	return map[string]any{
+		"version": m.Version, "name": m.Name, "description": m.Description,
+		"environment": m.Environment, "region": m.AWSRegion(),
+	}
+}
+
+// props treats a missing properties object like an empty one.
+func props(p map[string]any) map[string]any {
+	if p == nil {
+		return map[string]any{}
+	}
+	return p
+}
+
+// compare returns the changes between two JSON values at path. Objects are compared key by
+// key in sorted order and lists of equal length element by element; anything else is
+// reported as a whole. Values at paths in secret are redacted.
+func compare(path string, old, new any, secret map[string]bool) []Change {
+	if reflect.DeepEqual(old, new) {
+		return nil
+	}
+	om, oldIsMap := old.(map[string]any)
+	nm, newIsMap := new.(map[string]any)
+	if oldIsMap && newIsMap {
+		keys := make(map[string]bool)
+		for k := range om {
+			keys[k] = true
+		}
+		for k := range nm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		var out []Change
+		for _, k := range sorted {
+			out = append(out, compare(path+"/"+k, om[k], nm[k], secret)...)
+		}
+		return out
+	}
+	ol, oldIsList := old.([]any)
+	nl, newIsList := new.([]any)
+	if oldIsList && newIsList && len(ol) == len(nl) {
+		var out []Change
+		for i := range ol {
+			out = append(out, compare(fmt.Sprintf("%s/%d", path, i), ol[i], nl[i], secret)...)
+		}
+		return out
+	}
+	if secret[path] {
+		if old != nil {
+			old = secrets.Redacted
+		}
+		if new != nil {
+			new = secrets.Redacted
+		}
+	}
+	return []Change{{Path: path, Old: old, New: new}}
+}
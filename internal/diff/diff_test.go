@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	_ "github.com/json-to-terraform/parser/internal/handler"
+	"github.com/json-to-terraform/parser/internal/moved"
+	"github.com/json-to-terraform/parser/internal/plan"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/secrets"
+)
+
+func TestDiagrams(t *testing.T) {
+	old := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "vpc", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+			{ID: "sg", Type: "security_group", Properties: map[string]any{"ingress": []any{
+				map[string]any{"from_port": 22.0, "to_port": 22.0},
+			}}},
+			{ID: "db", Type: "rds_instance", Properties: map[string]any{"password": "hunter22"}},
+			{ID: "old", Type: "s3_bucket"},
+		},
+		Edges: []diagram.Edge{
+			{ID: "e1", Source: "vpc", Target: "sg", Type: "contains"},
+			{ID: "e2", Source: "sg", Target: "db", Type: "connects_to"},
+		},
+	}
+	new := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0", Region: "eu-west-1"},
+		Nodes: []diagram.Node{
+			{ID: "vpc", Type: "vpc", Position: diagram.Position{X: 50}, Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+			{ID: "sg", Type: "security_group", Label: "web", Properties: map[string]any{"ingress": []any{
+				map[string]any{"from_port": 443.0, "to_port": 22.0},
+			}}},
+			{ID: "db", Type: "rds_instance", Properties: map[string]any{"password": "hunter23"}},
+			{ID: "fn", Type: "lambda_function"},
+		},
+		Edges: []diagram.Edge{
+			{ID: "e1", Source: "vpc", Target: "sg", Type: "contains"},
+			{ID: "e2", Source: "sg", Target: "fn", Type: "connects_to"},
+		},
+	}
+	r := Diagrams(old, new, registry.Default)
+
+	want := &Report{
+		Metadata: []Change{{Path: "metadata/region", Old: "us-east-1", New: "eu-west-1"}},
+		Nodes: []NodeChange{
+			{NodeID: "sg", Type: "security_group", Action: Modified, Changes: []Change{
+				{Path: "label", Old: "", New: "web"},
+				{Path: "properties/ingress/0/from_port", Old: 22.0, New: 443.0},
+			}},
+			{NodeID: "db", Type: "rds_instance", Action: Modified, Changes: []Change{
+				{Path: "properties/password", Old: secrets.Redacted, New: secrets.Redacted},
+			}},
+			{NodeID: "fn", Type: "lambda_function", Action: Added},
+			{NodeID: "old", Type: "s3_bucket", Action: Removed},
+		},
+		Edges: []EdgeChange{
+			{EdgeID: "e2", Source: "sg", Target: "fn", Type: "connects_to", Action: Modified, Changes: []Change{
+				{Path: "target", Old: "db", New: "fn"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Diagrams() =\n%+v\nwant\n%+v", r, want)
+	}
+}
+
+func TestResources(t *testing.T) {
+	old := &plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "vpc", Attributes: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{Address: "aws_subnet.a", Type: "aws_subnet", NodeID: "a", Attributes: map[string]any{"vpc_id": "${aws_vpc.main.id}", "cidr_block": "10.0.1.0/24"}},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Attributes: map[string]any{"subnet_id": "${aws_subnet.a.id}", "instance_type": "t3.micro"}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", NodeID: "logs", Attributes: map[string]any{"bucket": "logs"}},
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", NodeID: "db", Attributes: map[string]any{"multi_az": false}},
+	}}
+	new := &plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "vpc", Attributes: map[string]any{"cidr_block": "10.1.0.0/16"}},
+		{Address: "aws_subnet.a", Type: "aws_subnet", NodeID: "a", Attributes: map[string]any{"vpc_id": "${aws_vpc.main.id}", "cidr_block": "10.0.1.0/24"}},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Attributes: map[string]any{"subnet_id": "${aws_subnet.a.id}", "instance_type": "t3.micro"}},
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", NodeID: "db", Attributes: map[string]any{"multi_az": true}},
+		{Address: "aws_lambda_function.fn", Type: "aws_lambda_function", NodeID: "fn"},
+	}}
+	got := Resources(old, new, nil)
+	want := []ResourceChange{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "vpc", Action: Replace, Destructive: true,
+			Reason:     "cidr_block cannot be changed in place",
+			Attributes: []AttributeChange{{Name: "cidr_block", Old: "10.0.0.0/16", New: "10.1.0.0/16", ForcesReplacement: true}}},
+		{Address: "aws_subnet.a", Type: "aws_subnet", NodeID: "a", Action: Replace, Destructive: true,
+			Reason: "vpc_id refers to aws_vpc.main, which is replaced"},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Action: Replace, Destructive: true,
+			Reason: "subnet_id refers to aws_subnet.a, which is replaced"},
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", NodeID: "db", Action: Update,
+			Attributes: []AttributeChange{{Name: "multi_az", Old: false, New: true}}},
+		{Address: "aws_lambda_function.fn", Type: "aws_lambda_function", NodeID: "fn", Action: Create},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", NodeID: "logs", Action: Delete, Destructive: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestResourcesFollowsMoves(t *testing.T) {
+	old := &plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_vpc.vpc_main", Type: "aws_vpc", NodeID: "vpc-main", Attributes: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{Address: "aws_subnet.subnet_a", Type: "aws_subnet", NodeID: "subnet-a", Attributes: map[string]any{"vpc_id": "${aws_vpc.vpc_main.id}"}},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Attributes: map[string]any{
+			"subnet_id": "${aws_subnet.subnet_a.id}", "instance_type": "t3.micro",
+		}},
+		{Address: "aws_secretsmanager_secret.vpc_main", Type: "aws_secretsmanager_secret", NodeID: "vpc-main",
+			Attributes: map[string]any{"name_prefix": "vpc-main-"}, IgnoreChanges: []string{"name_prefix"}},
+	}}
+	new := &plan.Plan{Resources: []plan.Resource{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "main", Attributes: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{Address: "aws_subnet.a", Type: "aws_subnet", NodeID: "a", Attributes: map[string]any{"vpc_id": "${aws_vpc.main.id}"}},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Attributes: map[string]any{
+			"subnet_id": "${aws_subnet.a.id}", "instance_type": "t3.small",
+		}},
+		{Address: "aws_secretsmanager_secret.main", Type: "aws_secretsmanager_secret", NodeID: "main",
+			Attributes: map[string]any{"name_prefix": "main-"}, IgnoreChanges: []string{"name_prefix"}},
+	}}
+	moves := []moved.Move{
+		{From: "aws_vpc.vpc_main", To: "aws_vpc.main", NodeID: "main"},
+		{From: "aws_subnet.subnet_a", To: "aws_subnet.a", NodeID: "a"},
+		{From: "aws_secretsmanager_secret.vpc_main", To: "aws_secretsmanager_secret.main", NodeID: "main"},
+	}
+	got := Resources(old, new, moves)
+	want := []ResourceChange{
+		{Address: "aws_vpc.main", Type: "aws_vpc", NodeID: "main", Action: Move, MovedFrom: "aws_vpc.vpc_main"},
+		{Address: "aws_subnet.a", Type: "aws_subnet", NodeID: "a", Action: Move, MovedFrom: "aws_subnet.subnet_a"},
+		{Address: "aws_instance.web", Type: "aws_instance", NodeID: "web", Action: Update,
+			Attributes: []AttributeChange{{Name: "instance_type", Old: "t3.micro", New: "t3.small"}}},
+		{Address: "aws_secretsmanager_secret.main", Type: "aws_secretsmanager_secret", NodeID: "main", Action: Move,
+			MovedFrom: "aws_secretsmanager_secret.vpc_main"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Resources() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,246 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/moved"
+	"github.com/json-to-terraform/parser/internal/plan"
+)
+
+// Actions on resources, as in terraform plan.
+const (
+	Create  = "create"
+	Update  = "update"
+	Replace = "replace"
+	Delete  = "delete"
+	Move    = "move" // moved to a new address, with no other change
+)
+
+// ResourceChange is a Terraform resource that would change. Deleting or replacing a resource
+// is destructive: the existing infrastructure (and any data in it) is destroyed.
+type ResourceChange struct {
+	Address     string            `json:"address"`
+	Type        string            `json:"type"`
+	NodeID      string            `json:"node_id,omitempty"`
+	Action      string            `json:"action"`
+	MovedFrom   string            `json:"moved_from,omitempty"` // previous address, per a moved block
+	Destructive bool              `json:"destructive"`
+	Reason      string            `json:"reason,omitempty"` // why a replacement is needed
+	Attributes  []AttributeChange `json:"attributes,omitempty"`
+}
+
+// AttributeChange is a changed top-level attribute or nested block of an updated or
+// replaced resource.
+type AttributeChange struct {
+	Name              string `json:"name"`
+	Old               any    `json:"old"`
+	New               any    `json:"new"`
+	ForcesReplacement bool   `json:"forces_replacement,omitempty"`
+}
+
+// forceNew lists, by resource type, the arguments that cannot be updated in place: the AWS
+// provider destroys and recreates the resource when they change.
+var forceNew = map[string][]string{
+	"aws_db_instance":     {"availability_zone", "character_set_name", "db_name", "engine", "identifier", "kms_key_id", "storage_encrypted", "username"},
+	"aws_db_subnet_group": {"name"},
+	"aws_instance":        {"ami", "associate_public_ip_address", "availability_zone", "key_name", "private_ip", "subnet_id"},
+	"aws_lambda_function": {"function_name"},
+	"aws_nat_gateway":     {"allocation_id", "connectivity_type", "subnet_id"},
+	"aws_s3_bucket":       {"bucket", "bucket_prefix", "object_lock_enabled"},
+	"aws_s3_bucket_server_side_encryption_configuration": {"bucket", "expected_bucket_owner"},
+	"aws_s3_bucket_versioning":                           {"bucket", "expected_bucket_owner"},
+	"aws_secretsmanager_secret":                          {"name", "name_prefix"},
+	"aws_secretsmanager_secret_version":                  {"secret_id"},
+	"aws_security_group":                                 {"description", "name", "name_prefix", "vpc_id"},
+	"aws_subnet":                                         {"availability_zone", "availability_zone_id", "cidr_block", "vpc_id"},
+	"aws_vpc":                                            {"cidr_block"},
+	"random_password":                                    {"length", "lower", "numeric", "override_special", "special", "upper"},
+}
+
+func forcesNew(resourceType, attr string) bool {
+	for _, a := range forceNew[resourceType] {
+		if a == attr {
+			return true
+		}
+	}
+	return false
+}
+
+// Resources compares the resources of two plans. moves are the moved blocks of the new
+// generation: a moved resource is compared with the old resource at its previous address
+// (references to moved resources included), so Terraform moves it instead of destroying
+// it. Changes are reported in the order of the new plan, followed by deletions in the
+// order of the old one. A replacement propagates to resources that reference the replaced
+// one from an argument that forces replacement (a subnet whose vpc_id points at a replaced
+// VPC is replaced too).
+func Resources(old, new *plan.Plan, moves []moved.Move) []ResourceChange {
+	oldByAddr := make(map[string]*plan.Resource, len(old.Resources))
+	for i := range old.Resources {
+		oldByAddr[old.Resources[i].Address] = &old.Resources[i]
+	}
+	renames := make(map[string]string) // old address -> new address
+	from := make(map[string]string)    // new address -> old address
+	for _, m := range moves {
+		if _, ok := oldByAddr[m.From]; ok {
+			renames[m.From], from[m.To] = m.To, m.From
+		}
+	}
+
+	var out []ResourceChange
+	index := make(map[string]int) // address -> position in out, for updated resources
+	for _, n := range new.Resources {
+		prev := n.Address
+		if addr, ok := from[n.Address]; ok {
+			prev = addr
+		}
+		o, ok := oldByAddr[prev]
+		if !ok {
+			out = append(out, ResourceChange{Address: n.Address, Type: n.Type, NodeID: n.NodeID, Action: Create})
+			continue
+		}
+		c := ResourceChange{Address: n.Address, Type: n.Type, NodeID: n.NodeID, Action: Update}
+		if prev != n.Address {
+			c.MovedFrom = prev
+		}
+		for _, name := range attributeNames(o.Attributes, n.Attributes) {
+			if ignored(n.IgnoreChanges, name) {
+				continue
+			}
+			ov, nv := renamed(o.Attributes[name], renames), n.Attributes[name]
+			if reflect.DeepEqual(ov, nv) {
+				continue
+			}
+			ac := AttributeChange{Name: name, Old: ov, New: nv, ForcesReplacement: forcesNew(n.Type, name)}
+			if ac.ForcesReplacement && c.Action != Replace {
+				c.Action, c.Destructive, c.Reason = Replace, true, name+" cannot be changed in place"
+			}
+			c.Attributes = append(c.Attributes, ac)
+		}
+		index[n.Address] = len(out)
+		out = append(out, c)
+	}
+
+	// Propagate replacements through force-new references until nothing changes.
+	for changed := true; changed; {
+		changed = false
+		replaced := make(map[string]bool)
+		for _, c := range out {
+			if c.Action == Replace {
+				replaced[c.Address] = true
+			}
+		}
+		for _, n := range new.Resources {
+			i, ok := index[n.Address]
+			if !ok || out[i].Action == Replace {
+				continue
+			}
+			for _, name := range sortedKeys(n.Attributes) {
+				target := referencedResource(n.Attributes[name])
+				if forcesNew(n.Type, name) && replaced[target] {
+					out[i].Action, out[i].Destructive = Replace, true
+					out[i].Reason = name + " refers to " + target + ", which is replaced"
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	var kept []ResourceChange
+	for _, c := range out {
+		if c.Action == Update && len(c.Attributes) == 0 {
+			if c.MovedFrom == "" {
+				continue
+			}
+			c.Action = Move
+		}
+		kept = append(kept, c)
+	}
+	seen := make(map[string]bool, len(new.Resources))
+	for _, n := range new.Resources {
+		seen[n.Address] = true
+		if addr, ok := from[n.Address]; ok {
+			seen[addr] = true
+		}
+	}
+	for _, o := range old.Resources {
+		if !seen[o.Address] {
+			kept = append(kept, ResourceChange{Address: o.Address, Type: o.Type, NodeID: o.NodeID, Action: Delete, Destructive: true})
+		}
+	}
+	return kept
+}
+
+// ignored reports whether name is one of the attributes Terraform ignores changes to.
+func ignored(ignoreChanges []string, name string) bool {
+	for _, a := range ignoreChanges {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renamed returns v with references to moved resources ("${type.name.attr}") rewritten to
+// their new address.
+func renamed(v any, renames map[string]string) any {
+	if len(renames) == 0 {
+		return v
+	}
+	switch v := v.(type) {
+	case string:
+		target := referencedResource(v)
+		if to, ok := renames[target]; ok {
+			return "${" + to + strings.TrimPrefix(v, "${"+target)
+		}
+		return v
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = renamed(item, renames)
+		}
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = renamed(item, renames)
+		}
+		return out
+	}
+	return v
+}
+
+// referencedResource returns the address a "${type.name.attr}" value refers to, or "".
+func referencedResource(v any) string {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "${") || !strings.HasSuffix(s, "}") {
+		return ""
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(s, "${"), "}"), ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+func attributeNames(a, b map[string]any) []string {
+	keys := make(map[string]any, len(a)+len(b))
+	for k := range a {
+		keys[k] = nil
+	}
+	for k := range b {
+		keys[k] = nil
+	}
+	return sortedKeys(keys)
+}
+
+func sortedKeys(m map[string]any) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
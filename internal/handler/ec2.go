@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -16,34 +20,38 @@ func init() {
 
 func (ec2Handler) ResourceType() string { return "ec2_instance" }
 
-func (ec2Handler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "ami") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "ami is required", Suggestion: "Set properties.ami",
-		})
-	}
-	if diagram.GetStr(p, "instance_type") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "instance_type is required", Suggestion: "Set properties.instance_type (e.g. t3.micro)",
-		})
-	}
-	return errs, warns
+// ec2Props are the properties of an ec2_instance node. Instance types are <family>.<size>,
+// and the size must be one EC2 offers.
+type ec2Props struct {
+	commonProps
+	AMI          string            `json:"ami" schema:"required,nonempty" pattern:"^ami-[0-9a-f]+$" desc:"AMI id, e.g. ami-0c55b159cbfafe1f0"`
+	InstanceType string            `json:"instance_type" schema:"required,nonempty" pattern:"^[a-z][a-z0-9-]*\\.(nano|micro|small|medium|large|xlarge|[0-9]+xlarge|metal(-[0-9]+xl)?)$" desc:"Instance type, e.g. t3.micro"`
+	KeyName      string            `json:"key_name" desc:"Name of an existing EC2 key pair."`
+	Tags         map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
 }
 
-func (ec2Handler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+// ec2ImportID is the format of import_id: the instance id of an existing resource.
+var ec2ImportID = importFormat{"instance id", regexp.MustCompile(`^i-[0-9a-f]+$`), "i-0a1b2c3d4e5f67890"}
+
+func (ec2Handler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(ec2Props{}, "Properties of an ec2_instance node (aws_instance).")
+}
+
+func (ec2Handler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(ec2Props)
+	errs := properties.Decode(node, p)
+	return p, append(errs, ec2ImportID.check(node, p.ImportID)...), nil
+}
+
+func (ec2Handler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_instance", name)
 	body := block.Body()
 
-	p := node.Properties
-	terraform.SetAttributeStr(body, "ami", diagram.GetStr(p, "ami"))
-	terraform.SetAttributeStr(body, "instance_type", diagram.GetStr(p, "instance_type"))
-	terraform.SetAttributeStr(body, "key_name", diagram.GetStr(p, "key_name"))
+	p := props.(*ec2Props)
+	terraform.SetAttributeStr(body, "ami", p.AMI)
+	terraform.SetAttributeStr(body, "instance_type", p.InstanceType)
+	terraform.SetAttributeStr(body, "key_name", p.KeyName)
 
 	var sgRefs []string
 	for _, e := range d.EdgesWithTarget(node.ID) {
@@ -68,8 +76,7 @@ func (ec2Handler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMa
 		}
 		body.SetAttributeRaw("vpc_security_group_ids", hclwrite.TokensForTuple(tokens))
 	}
-
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
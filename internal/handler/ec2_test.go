@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestEC2Validate(t *testing.T) {
+	runValidateCases(t, ec2Handler{}, []validateCase{
+		{name: "valid", props: map[string]any{"ami": "ami-123", "instance_type": "t3.micro"}},
+		{name: "missing ami", props: map[string]any{"instance_type": "t3.micro"}, want: []string{"ami is required"}},
+		{name: "missing both", props: map[string]any{}, want: []string{"ami is required", "instance_type is required"}},
+		{name: "invalid import id", props: map[string]any{"ami": "ami-123", "instance_type": "t3.micro", "import_id": "web-1"},
+			want: []string{`import_id "web-1" is not a valid instance id`}},
+	})
+}
+
+func TestEC2GenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: "subnet-1", Type: "subnet"},
+			{ID: "sg-a", Type: "security_group"},
+			{ID: "sg-b", Type: "security_group"},
+			{ID: "web-1", Type: "ec2_instance", Properties: map[string]any{"ami": "ami-123", "instance_type": "t3.micro"}},
+		},
+		Edges: []diagram.Edge{
+			{ID: "e1", Source: "subnet-1", Target: "web-1", Type: "contains"},
+			{ID: "e2", Source: "sg-a", Target: "web-1", Type: "connects_to"},
+			{ID: "e3", Source: "sg-b", Target: "web-1", Type: "connects_to"},
+		},
+	}
+	refs := RefMap{
+		"subnet-1": "aws_subnet.subnet_1",
+		"sg-a":     "aws_security_group.sg_a",
+		"sg-b":     "aws_security_group.sg_b",
+	}
+	body := resource(t, generate(t, ec2Handler{}, d, "web-1", refs), "aws_instance", "web_1")
+
+	if got := attr(body, "subnet_id"); got != "aws_subnet.subnet_1.id" {
+		t.Errorf("subnet_id = %q", got)
+	}
+	want := "[aws_security_group.sg_a.id, aws_security_group.sg_b.id]"
+	if got := attr(body, "vpc_security_group_ids"); got != want {
+		t.Errorf("vpc_security_group_ids = %q, want %q", got, want)
+	}
+	if got := attr(body, "tags"); got != "" {
+		t.Errorf("tags = %s, want unset without label or tags", got)
+	}
+}
+
+func TestEC2ContainsFromNonSubnetIgnored(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: "vpc-1", Type: "vpc"},
+			{ID: "web-1", Type: "ec2_instance", Properties: map[string]any{"ami": "ami-123", "instance_type": "t3.micro"}},
+		},
+		Edges: []diagram.Edge{{ID: "e1", Source: "vpc-1", Target: "web-1", Type: "contains"}},
+	}
+	body := resource(t, generate(t, ec2Handler{}, d, "web-1", RefMap{"vpc-1": "aws_vpc.vpc_1"}), "aws_instance", "web_1")
+	if got := attr(body, "subnet_id"); got != "" {
+		t.Errorf("subnet_id = %q, want unset for vpc parent", got)
+	}
+}
@@ -1,10 +1,62 @@
 package handler
 
 import (
+	"fmt"
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2"
+	"github.com/json-to-terraform/parser/internal/diagram"
 	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
 )
 
+// commonProps are the properties every node type accepts; each handler's property struct embeds it.
+type commonProps struct {
+	TerraformName string `json:"terraform_name" pattern:"^[A-Za-z_][A-Za-z0-9_-]*$" desc:"Name of the node's resources in Terraform addresses, instead of one derived from the node id. Pin it to keep addresses stable when the node is renamed."`
+	ImportID      string `json:"import_id" desc:"Id of an existing AWS resource to adopt instead of creating one (Terraform 1.5+ import block in imports.tf); the format depends on the node type."`
+}
+
+// importFormat is the format of the import id of a handler's main resource, as the AWS
+// provider expects it.
+type importFormat struct {
+	what    string // e.g. "VPC id"
+	pattern *regexp.Regexp
+	example string
+}
+
+// check reports an import_id that does not have the format.
+func (f importFormat) check(node *diagram.Node, id string) []result.Error {
+	if id == "" || f.pattern.MatchString(id) {
+		return nil
+	}
+	return []result.Error{{
+		Type: "validation_error", Severity: "error", NodeID: node.ID, Path: "properties/import_id",
+		Message:    fmt.Sprintf("import_id %q is not a valid %s", id, f.what),
+		Suggestion: fmt.Sprintf("Use the %s of the existing resource, e.g. %s", f.what, f.example),
+	}}
+}
+
+// importName resolves the name argument (bucket, function_name) of a resource whose import
+// id is that name: the explicit name, else the import id, else the label. An explicit name
+// other than the import id is an error on prop, since Terraform would import the resource
+// and then plan to replace it.
+func importName(node *diagram.Node, prop, explicit, importID string) (string, []result.Error) {
+	switch {
+	case importID == "":
+		if explicit == "" {
+			return node.Label, nil
+		}
+		return explicit, nil
+	case explicit == "" || explicit == importID:
+		return importID, nil
+	}
+	return explicit, []result.Error{{
+		Type: "validation_error", Severity: "error", NodeID: node.ID, Path: "properties/" + prop,
+		Message:    fmt.Sprintf("%s %q differs from import_id %q; Terraform would replace the imported resource", prop, explicit, importID),
+		Suggestion: fmt.Sprintf("Remove %s (it defaults to import_id) or set it to %q", prop, importID),
+	}}
+}
+
 // refTraversal builds hcl.Traversal for a resource address and attribute (e.g. aws_vpc.node_3.id).
 // HCL requires the first step to be TraverseRoot by value (not pointer) for an absolute traversal.
 func refTraversal(addr, attr string) hcl.Traversal {
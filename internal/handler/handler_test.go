@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// validateCase is a table entry for ResourceHandler.Validate tests.
+type validateCase struct {
+	name  string
+	label string
+	props map[string]any
+	want  []string // expected error messages, in order
+}
+
+func runValidateCases(t *testing.T, h registry.ResourceHandler, cases []validateCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := &diagram.Node{ID: "n1", Type: h.ResourceType(), Label: tc.label, Properties: tc.props}
+			_, errs, _ := h.Validate(node)
+			if got := messages(errs); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Validate() errors = %q, want %q", got, tc.want)
+			}
+			for _, e := range errs {
+				if e.NodeID != node.ID || e.Type != "validation_error" {
+					t.Errorf("error %+v: want node_id %q and type validation_error", e, node.ID)
+				}
+			}
+		})
+	}
+}
+
+func messages(errs []result.Error) []string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, e.Message)
+	}
+	return out
+}
+
+// generate validates the node with the given id, runs GenerateHCL with its decoded properties
+// and returns the parsed output.
+func generate(t *testing.T, h registry.ResourceHandler, d *diagram.Diagram, id string, refs RefMap) *hclwrite.File {
+	t.Helper()
+	node := d.NodeByID(id)
+	if node == nil {
+		t.Fatalf("node %q not in diagram", id)
+	}
+	if node.Properties == nil {
+		node.Properties = map[string]any{}
+	}
+	props, errs, _ := h.Validate(node)
+	if len(errs) > 0 {
+		t.Fatalf("Validate: %q", messages(errs))
+	}
+	src, err := h.GenerateHCL(node, props, d, refs)
+	if err != nil {
+		t.Fatalf("GenerateHCL: %v", err)
+	}
+	f, diags := hclwrite.ParseConfig(src, "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("generated invalid HCL: %s\n%s", diags.Error(), src)
+	}
+	return f
+}
+
+// resource returns the body of the resource block with the given type and name.
+func resource(t *testing.T, f *hclwrite.File, resourceType, name string) *hclwrite.Body {
+	t.Helper()
+	for _, b := range f.Body().Blocks() {
+		if b.Type() == "resource" && reflect.DeepEqual(b.Labels(), []string{resourceType, name}) {
+			return b.Body()
+		}
+	}
+	t.Fatalf("resource %s.%s not generated:\n%s", resourceType, name, f.Bytes())
+	return nil
+}
+
+// attr returns the source text of an attribute expression, or "" when the attribute is unset.
+func attr(body *hclwrite.Body, name string) string {
+	a := body.GetAttribute(name)
+	if a == nil {
+		return ""
+	}
+	return strings.TrimSpace(string(a.Expr().BuildTokens(nil).Bytes()))
+}
+
+func TestRefTraversal(t *testing.T) {
+	tests := []struct {
+		addr, attr, want string
+	}{
+		{"aws_vpc.node_3", "id", "aws_vpc.node_3.id"},
+		{"aws_db_subnet_group.grp", "name", "aws_db_subnet_group.grp.name"},
+		{"aws_vpc.node_3", "", "aws_vpc.node_3"},
+	}
+	for _, tc := range tests {
+		tr := refTraversal(tc.addr, tc.attr)
+		if tr.IsRelative() {
+			t.Errorf("refTraversal(%q, %q) is relative", tc.addr, tc.attr)
+		}
+		got := strings.TrimSpace(string(hclwrite.TokensForTraversal(tr).Bytes()))
+		if got != tc.want {
+			t.Errorf("refTraversal(%q, %q) = %q, want %q", tc.addr, tc.attr, got, tc.want)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -17,51 +21,51 @@ func init() {
 
 func (lambdaHandler) ResourceType() string { return "lambda_function" }
 
-func (lambdaHandler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "runtime") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "runtime is required", Suggestion: "Set properties.runtime (e.g. python3.9)",
-		})
-	}
-	if diagram.GetStr(p, "handler") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "handler is required", Suggestion: "Set properties.handler (e.g. index.handler)",
-		})
+// lambdaProps are the properties of a lambda_function node.
+type lambdaProps struct {
+	commonProps
+	Runtime              string            `json:"runtime" schema:"required,enum=nodejs18.x|nodejs20.x|nodejs22.x|python3.9|python3.10|python3.11|python3.12|python3.13|java11|java17|java21|dotnet8|ruby3.2|ruby3.3|provided.al2|provided.al2023" desc:"Lambda runtime identifier, e.g. python3.12"`
+	Handler              string            `json:"handler" schema:"required,nonempty" desc:"Function entry point, e.g. index.handler"`
+	MemorySize           int               `json:"memory_size" schema:"min=128,max=10240,default=128" desc:"Memory in MB."`
+	Timeout              int               `json:"timeout" schema:"min=1,max=900,default=3" desc:"Timeout in seconds."`
+	Filename             string            `json:"filename" desc:"Path to the deployment package."`
+	FunctionName         string            `json:"function_name" desc:"Function name; defaults to import_id, else the node label."`
+	EnvironmentVariables map[string]string `json:"environment_variables" desc:"Environment variables."`
+	Tags                 map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// lambdaImportID is the format of import_id: the function name of an existing resource.
+var lambdaImportID = importFormat{"function name", regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`), "my-function"}
+
+func (lambdaHandler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(lambdaProps{}, "Properties of a lambda_function node (aws_lambda_function).")
+}
+
+func (lambdaHandler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(lambdaProps)
+	errs := properties.Decode(node, p)
+	if idErrs := lambdaImportID.check(node, p.ImportID); len(idErrs) > 0 {
+		return p, append(errs, idErrs...), nil
 	}
-	return errs, warns
+	_, nameErrs := importName(node, "function_name", p.FunctionName, p.ImportID)
+	return p, append(errs, nameErrs...), nil
 }
 
-func (lambdaHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (lambdaHandler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_lambda_function", name)
 	body := block.Body()
 
-	p := node.Properties
-	terraform.SetAttributeStr(body, "runtime", diagram.GetStr(p, "runtime"))
-	terraform.SetAttributeStr(body, "handler", diagram.GetStr(p, "handler"))
-	mem := diagram.GetInt(p, "memory_size")
-	if mem == 0 {
-		mem = 128
-	}
-	terraform.SetAttributeInt(body, "memory_size", mem)
-	timeout := diagram.GetInt(p, "timeout")
-	if timeout == 0 {
-		timeout = 3
-	}
-	terraform.SetAttributeInt(body, "timeout", timeout)
-	terraform.SetAttributeStr(body, "filename", diagram.GetStr(p, "filename"))
-	fnName := diagram.GetStr(p, "function_name")
-	if fnName == "" && node.Label != "" {
-		fnName = node.Label
-	}
+	p := props.(*lambdaProps)
+	terraform.SetAttributeStr(body, "runtime", p.Runtime)
+	terraform.SetAttributeStr(body, "handler", p.Handler)
+	terraform.SetAttributeInt(body, "memory_size", p.MemorySize)
+	terraform.SetAttributeInt(body, "timeout", p.Timeout)
+	terraform.SetAttributeStr(body, "filename", p.Filename)
+	fnName, _ := importName(node, "function_name", p.FunctionName, p.ImportID)
 	terraform.SetAttributeStr(body, "function_name", fnName)
 
-	env := diagram.GetStrMap(p, "environment_variables")
+	env := p.EnvironmentVariables
 	if len(env) > 0 {
 		envBlock := body.AppendNewBlock("environment", nil)
 		envBody := envBlock.Body()
@@ -72,7 +76,7 @@ func (lambdaHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs Re
 		envBody.SetAttributeValue("variables", cty.MapVal(ctyVars))
 	}
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestLambdaValidate(t *testing.T) {
+	runValidateCases(t, lambdaHandler{}, []validateCase{
+		{name: "valid", props: map[string]any{"runtime": "python3.9", "handler": "index.handler"}},
+		{name: "missing handler", props: map[string]any{"runtime": "python3.9"}, want: []string{"handler is required"}},
+		{name: "missing both", props: map[string]any{}, want: []string{"runtime is required", "handler is required"}},
+		{name: "function name other than import id", props: map[string]any{"runtime": "python3.9", "handler": "index.handler", "function_name": "fn", "import_id": "legacy-fn"},
+			want: []string{`function_name "fn" differs from import_id "legacy-fn"; Terraform would replace the imported resource`}},
+	})
+}
+
+func TestLambdaGenerateHCL(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		props map[string]any
+		want  map[string]string
+	}{
+		{
+			name:  "defaults",
+			label: "Processor",
+			props: map[string]any{"runtime": "python3.9", "handler": "index.handler"},
+			want:  map[string]string{"memory_size": "128", "timeout": "3", "function_name": `"Processor"`},
+		},
+		{
+			name: "explicit values",
+			props: map[string]any{
+				"runtime": "nodejs18.x", "handler": "index.handler",
+				"memory_size": 512.0, "timeout": 30.0, "function_name": "fn",
+			},
+			want: map[string]string{"memory_size": "512", "timeout": "30", "function_name": `"fn"`},
+		},
+		{
+			name:  "imported",
+			label: "Processor",
+			props: map[string]any{"runtime": "python3.9", "handler": "index.handler", "import_id": "legacy-fn"},
+			want:  map[string]string{"function_name": `"legacy-fn"`},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "fn-1", Type: "lambda_function", Label: tc.label, Properties: tc.props}}}
+			body := resource(t, generate(t, lambdaHandler{}, d, "fn-1", RefMap{}), "aws_lambda_function", "fn_1")
+			for name, want := range tc.want {
+				if got := attr(body, name); got != want {
+					t.Errorf("%s = %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLambdaEnvironmentBlock(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "fn-1", Type: "lambda_function", Properties: map[string]any{
+		"runtime": "python3.9", "handler": "index.handler",
+		"environment_variables": map[string]any{"STAGE": "prod"},
+	}}}}
+	body := resource(t, generate(t, lambdaHandler{}, d, "fn-1", RefMap{}), "aws_lambda_function", "fn_1")
+	env := body.FirstMatchingBlock("environment", nil)
+	if env == nil {
+		t.Fatal("environment block not generated")
+	}
+	if got := attr(env.Body(), "variables"); got != "{\n      STAGE = \"prod\"\n    }" {
+		t.Errorf("variables = %s", got)
+	}
+}
@@ -1,8 +1,14 @@
 package handler
 
 import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -17,54 +23,96 @@ func init() {
 
 func (rdsHandler) ResourceType() string { return "rds_instance" }
 
-func (rdsHandler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "engine") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "engine is required", Suggestion: "Set properties.engine (e.g. postgres)",
-		})
-	}
-	if diagram.GetStr(p, "instance_class") == "" {
+// RDSProps are the properties of an rds_instance node.
+type RDSProps struct {
+	commonProps
+	Engine                string            `json:"engine" schema:"required,enum=postgres|mysql|mariadb|aurora-mysql|aurora-postgresql|oracle-ee|oracle-ee-cdb|oracle-se2|oracle-se2-cdb|sqlserver-ee|sqlserver-se|sqlserver-ex|sqlserver-web|db2-ae|db2-se|custom-oracle-ee|custom-oracle-ee-cdb|custom-oracle-se2|custom-oracle-se2-cdb|custom-sqlserver-ee|custom-sqlserver-se|custom-sqlserver-web|custom-sqlserver-dev" desc:"Database engine, e.g. postgres"`
+	InstanceClass         string            `json:"instance_class" schema:"required,nonempty" pattern:"^db\\.[a-z0-9-]+\\.[a-z0-9]+$" desc:"DB instance class, e.g. db.t3.micro"`
+	AllocatedStorage      int               `json:"allocated_storage" schema:"required,min=5,max=65536" desc:"Storage in GiB: at least 20, or 5 with storage_type standard."`
+	EngineVersion         string            `json:"engine_version" desc:"Engine version, e.g. 15.4"`
+	StorageType           string            `json:"storage_type" schema:"enum=standard|gp2|gp3|io1|io2" desc:"Storage type."`
+	DBName                string            `json:"db_name" desc:"Name of the initial database."`
+	Username              string            `json:"username" desc:"Master username."`
+	Password              string            `json:"password" schema:"secret" desc:"Master password. Never written to the generated Terraform; it becomes a sensitive variable without a default."`
+	GeneratePassword      bool              `json:"generate_password" desc:"Generate the master password (random_password) and store it in AWS Secrets Manager."`
+	SkipFinalSnapshot     bool              `json:"skip_final_snapshot" desc:"Skip the final snapshot on destroy."`
+	BackupRetentionPeriod int               `json:"backup_retention_period" schema:"min=0,max=35" desc:"Days to keep automated backups."`
+	MultiAZ               bool              `json:"multi_az" desc:"Run a standby in another availability zone."`
+	StorageEncrypted      bool              `json:"storage_encrypted" desc:"Encrypt the DB storage at rest."`
+	Tags                  map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// minSSDStorage is the smallest allocated_storage (GiB) AWS accepts on SSD storage (gp2, the
+// default, and gp3; io1 and io2 need more). Only standard (magnetic) storage goes down to 5.
+const minSSDStorage = 20
+
+// rdsImportID is the format of import_id: the DB instance identifier of an existing resource.
+var rdsImportID = importFormat{"DB instance identifier", regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]{0,62}$`), "app-db"}
+
+func (rdsHandler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(RDSProps{}, "Properties of an rds_instance node (aws_db_instance).")
+}
+
+func (rdsHandler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(RDSProps)
+	errs := properties.Decode(node, p)
+	if p.Password != "" && p.GeneratePassword {
 		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "instance_class is required", Suggestion: "Set properties.instance_class (e.g. db.t3.micro)",
+			Type: "validation_error", Severity: "error", NodeID: node.ID, Path: "properties/password",
+			Message: "password and generate_password are mutually exclusive", Suggestion: "Remove password to use the generated one",
 		})
 	}
-	if diagram.GetInt(p, "allocated_storage") == 0 {
+	if p.AllocatedStorage > 0 && p.AllocatedStorage < minSSDStorage && p.StorageType != "standard" {
 		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "allocated_storage is required", Suggestion: "Set properties.allocated_storage (GB)",
+			Type: "validation_error", Severity: "error", NodeID: node.ID, Path: "properties/allocated_storage",
+			Message:    fmt.Sprintf("allocated_storage: must be at least %d for SSD storage, got %d", minSSDStorage, p.AllocatedStorage),
+			Suggestion: fmt.Sprintf("Allocate at least %d GiB, or set storage_type to standard (magnetic, from 5 GiB)", minSSDStorage),
 		})
 	}
-	return errs, warns
+	errs = append(errs, rdsImportID.check(node, p.ImportID)...)
+	return p, errs, nil
 }
 
-func (rdsHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (rdsHandler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_db_instance", name)
 	body := block.Body()
 
-	p := node.Properties
-	terraform.SetAttributeStr(body, "engine", diagram.GetStr(p, "engine"))
-	terraform.SetAttributeStr(body, "engine_version", diagram.GetStr(p, "engine_version"))
-	terraform.SetAttributeStr(body, "instance_class", diagram.GetStr(p, "instance_class"))
-	terraform.SetAttributeInt(body, "allocated_storage", diagram.GetInt(p, "allocated_storage"))
-	terraform.SetAttributeStr(body, "storage_type", diagram.GetStr(p, "storage_type"))
-	terraform.SetAttributeStr(body, "db_name", diagram.GetStr(p, "db_name"))
-	terraform.SetAttributeStr(body, "username", diagram.GetStr(p, "username"))
-	if pw := diagram.GetStr(p, "password"); pw != "" {
-		body.SetAttributeValue("password", cty.StringVal(pw))
+	p := props.(*RDSProps)
+	// The identifier is otherwise generated by Terraform, and a different one replaces the
+	// imported instance
+	terraform.SetAttributeStr(body, "identifier", p.ImportID)
+	terraform.SetAttributeStr(body, "engine", p.Engine)
+	terraform.SetAttributeStr(body, "engine_version", p.EngineVersion)
+	terraform.SetAttributeStr(body, "instance_class", p.InstanceClass)
+	terraform.SetAttributeInt(body, "allocated_storage", p.AllocatedStorage)
+	terraform.SetAttributeStr(body, "storage_type", p.StorageType)
+	terraform.SetAttributeStr(body, "db_name", p.DBName)
+	terraform.SetAttributeStr(body, "username", p.Username)
+
+	// The password itself never reaches main.tf: it is either generated and kept in Secrets
+	// Manager, or declared as a sensitive variable the operator supplies at apply time.
+	f := hclwrite.NewEmptyFile()
+	switch {
+	case p.GeneratePassword:
+		body.SetAttributeTraversal("password", refTraversal("random_password."+name, "result"))
+		appendGeneratedPassword(f.Body(), name, node.ID)
+	case p.Password != "":
+		variable := name + "_password"
+		body.SetAttributeTraversal("password", hcl.Traversal{hcl.TraverseRoot{Name: "var"}, hcl.TraverseAttr{Name: variable}})
+		f.Body().AppendBlock(terraform.VariableBlock(variable, "Master password of "+node.ID, true))
+		f.Body().AppendNewline()
 	}
-	if diagram.GetBool(p, "skip_final_snapshot") {
+	if p.SkipFinalSnapshot {
 		body.SetAttributeValue("skip_final_snapshot", cty.BoolVal(true))
 	}
-	if n := diagram.GetInt(p, "backup_retention_period"); n > 0 {
-		terraform.SetAttributeInt(body, "backup_retention_period", n)
+	if p.BackupRetentionPeriod > 0 {
+		terraform.SetAttributeInt(body, "backup_retention_period", p.BackupRetentionPeriod)
+	}
+	terraform.SetAttributeBool(body, "multi_az", p.MultiAZ)
+	if p.StorageEncrypted {
+		body.SetAttributeValue("storage_encrypted", cty.BoolVal(true))
 	}
-	terraform.SetAttributeBool(body, "multi_az", diagram.GetBool(p, "multi_az"))
 
 	// db_subnet_group_name from "contains" edge (source = db_subnet_group); vpc_security_group_ids from "connects_to" (source = security_group)
 	var sgRefs []string
@@ -92,7 +140,7 @@ func (rdsHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMa
 		body.SetAttributeRaw("vpc_security_group_ids", hclwrite.TokensForTuple(tokens))
 	}
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
@@ -103,7 +151,29 @@ func (rdsHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMa
 	}
 	terraform.SetAttributeMap(body, "tags", tags)
 
-	f := hclwrite.NewEmptyFile()
 	f.Body().AppendBlock(block)
 	return f.Bytes(), nil
 }
+
+// appendGeneratedPassword appends a random_password and the Secrets Manager secret holding it.
+// RDS rejects '/', '@', '"' and spaces in master passwords, so those are left out.
+func appendGeneratedPassword(body *hclwrite.Body, name, nodeID string) {
+	pw := body.AppendNewBlock("resource", []string{"random_password", name}).Body()
+	pw.SetAttributeValue("length", cty.NumberIntVal(32))
+	pw.SetAttributeValue("special", cty.True)
+	pw.SetAttributeValue("override_special", cty.StringVal("!#$%&*()-_=+[]{}<>:?"))
+	body.AppendNewline()
+
+	secret := body.AppendNewBlock("resource", []string{"aws_secretsmanager_secret", name + "_password"}).Body()
+	secret.SetAttributeValue("name_prefix", cty.StringVal(nodeID+"-master-password-"))
+	secret.SetAttributeValue("description", cty.StringVal("Master password of "+nodeID))
+	// name_prefix forces a replacement, which a renamed node (a moved block) must not cause
+	lifecycle := secret.AppendNewBlock("lifecycle", nil).Body()
+	lifecycle.SetAttributeRaw("ignore_changes", hclwrite.TokensForTuple([]hclwrite.Tokens{hclwrite.TokensForIdentifier("name_prefix")}))
+	body.AppendNewline()
+
+	version := body.AppendNewBlock("resource", []string{"aws_secretsmanager_secret_version", name + "_password"}).Body()
+	version.SetAttributeTraversal("secret_id", refTraversal("aws_secretsmanager_secret."+name+"_password", "id"))
+	version.SetAttributeTraversal("secret_string", refTraversal("random_password."+name, "result"))
+	body.AppendNewline()
+}
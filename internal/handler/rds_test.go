@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestRDSValidate(t *testing.T) {
+	valid := map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0}
+	runValidateCases(t, rdsHandler{}, []validateCase{
+		{name: "valid", props: valid},
+		{name: "missing all", props: map[string]any{}, want: []string{
+			"engine is required", "instance_class is required", "allocated_storage is required",
+		}},
+		{name: "zero storage", props: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 0.0},
+			want: []string{"allocated_storage: must be at least 5, got 0"}},
+		{name: "small gp2 storage", props: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 10.0},
+			want: []string{"allocated_storage: must be at least 20 for SSD storage, got 10"}},
+		{name: "small standard storage", props: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 5.0, "storage_type": "standard"}},
+		{name: "aurora engine", props: map[string]any{"engine": "aurora-postgresql", "instance_class": "db.r6g.large", "allocated_storage": 20.0}},
+		{name: "storage as string", props: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": "20"},
+			want: []string{"allocated_storage: expected an integer, got string"}},
+		{name: "unknown engine", props: map[string]any{"engine": "postgress", "instance_class": "db.t3.micro", "allocated_storage": 20.0},
+			want: []string{`engine: "postgress" is not an allowed value`}},
+		{name: "password and generated password", props: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0, "password": "hunter22", "generate_password": true},
+			want: []string{"password and generate_password are mutually exclusive"}},
+	})
+}
+
+func TestRDSGenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: "sg-db", Type: "security_group"},
+			{ID: "grp", Type: "db_subnet_group"},
+			{ID: "db", Type: "rds_instance", Properties: map[string]any{
+				"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0,
+				"backup_retention_period": 7.0, "storage_encrypted": true,
+			}},
+		},
+		Edges: []diagram.Edge{
+			{ID: "e1", Source: "sg-db", Target: "db", Type: "connects_to"},
+			{ID: "e2", Source: "grp", Target: "db", Type: "contains"},
+		},
+	}
+	refs := RefMap{"sg-db": "aws_security_group.sg_db", "grp": "aws_db_subnet_group.grp"}
+	body := resource(t, generate(t, rdsHandler{}, d, "db", refs), "aws_db_instance", "db")
+
+	tests := map[string]string{
+		"vpc_security_group_ids":  "[aws_security_group.sg_db.id]",
+		"db_subnet_group_name":    "aws_db_subnet_group.grp.name",
+		"allocated_storage":       "20",
+		"backup_retention_period": "7",
+		"multi_az":                "false",
+		"storage_encrypted":       "true",
+		"skip_final_snapshot":     "",
+		"identifier":              "",
+	}
+	for name, want := range tests {
+		if got := attr(body, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRDSImportSetsIdentifier(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "db", Type: "rds_instance", Properties: map[string]any{
+		"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0, "import_id": "app-db",
+	}}}}
+	body := resource(t, generate(t, rdsHandler{}, d, "db", RefMap{}), "aws_db_instance", "db")
+	if got := attr(body, "identifier"); got != `"app-db"` {
+		t.Errorf("identifier = %s, want the import id", got)
+	}
+}
+
+func TestRDSPasswordIsNeverWritten(t *testing.T) {
+	props := func(extra map[string]any) map[string]any {
+		p := map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0}
+		for k, v := range extra {
+			p[k] = v
+		}
+		return p
+	}
+	d := &diagram.Diagram{Nodes: []diagram.Node{
+		{ID: "db", Type: "rds_instance", Properties: props(map[string]any{"password": "hunter22"})},
+		{ID: "gen", Type: "rds_instance", Properties: props(map[string]any{"generate_password": true})},
+	}}
+
+	f := generate(t, rdsHandler{}, d, "db", RefMap{})
+	if strings.Contains(string(f.Bytes()), "hunter22") {
+		t.Errorf("password literal written:\n%s", f.Bytes())
+	}
+	if got := attr(resource(t, f, "aws_db_instance", "db"), "password"); got != "var.db_password" {
+		t.Errorf("password = %q, want var.db_password", got)
+	}
+	if v := f.Body().FirstMatchingBlock("variable", []string{"db_password"}); v == nil || attr(v.Body(), "sensitive") != "true" {
+		t.Errorf("no sensitive db_password variable:\n%s", f.Bytes())
+	}
+
+	f = generate(t, rdsHandler{}, d, "gen", RefMap{})
+	if got := attr(resource(t, f, "aws_db_instance", "gen"), "password"); got != "random_password.gen.result" {
+		t.Errorf("password = %q, want random_password.gen.result", got)
+	}
+	resource(t, f, "random_password", "gen")
+	version := resource(t, f, "aws_secretsmanager_secret_version", "gen_password")
+	if got := attr(version, "secret_string"); got != "random_password.gen.result" {
+		t.Errorf("secret_string = %q", got)
+	}
+}
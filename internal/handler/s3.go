@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -17,45 +21,57 @@ func init() {
 
 func (s3Handler) ResourceType() string { return "s3_bucket" }
 
-func (s3Handler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "bucket") == "" && node.Label == "" {
+// S3Props are the properties of an s3_bucket node.
+type S3Props struct {
+	commonProps
+	Bucket            string            `json:"bucket" desc:"Globally unique bucket name; defaults to import_id, else the node label."`
+	Versioning        bool              `json:"versioning" desc:"Enable object versioning."`
+	BlockPublicACLs   bool              `json:"block_public_acls" desc:"Block public ACLs (aws_s3_bucket_public_access_block)."`
+	BlockPublicPolicy bool              `json:"block_public_policy" desc:"Block public bucket policies (aws_s3_bucket_public_access_block)."`
+	ForceDestroy      bool              `json:"force_destroy" desc:"Allow destroying a non-empty bucket."`
+	SSEAlgorithm      string            `json:"sse_algorithm" schema:"enum=AES256|aws:kms" desc:"Default server-side encryption: AES256 (S3-managed keys) or aws:kms."`
+	Tags              map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// s3ImportID is the format of import_id: the bucket name of an existing resource.
+var s3ImportID = importFormat{"bucket name", regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`), "my-app-data"}
+
+func (s3Handler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(S3Props{}, "Properties of an s3_bucket node (aws_s3_bucket).")
+}
+
+func (s3Handler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(S3Props)
+	errs := properties.Decode(node, p)
+	bucket, nameErrs := importName(node, "bucket", p.Bucket, p.ImportID)
+	if bucket == "" {
 		errs = append(errs, result.Error{
 			Type: "validation_error", Severity: "error", NodeID: node.ID,
 			Message: "bucket name or label is required", Suggestion: "Set properties.bucket or node.label",
 		})
 	}
-	return errs, warns
+	if idErrs := s3ImportID.check(node, p.ImportID); len(idErrs) > 0 {
+		errs = append(errs, idErrs...)
+	} else {
+		errs = append(errs, nameErrs...)
+	}
+	return p, errs, nil
 }
 
-func (s3Handler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (s3Handler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_s3_bucket", name)
 	body := block.Body()
 
-	p := node.Properties
-	bucketName := diagram.GetStr(p, "bucket")
-	if bucketName == "" {
-		bucketName = node.Label
-	}
+	p := props.(*S3Props)
+	bucketName, _ := importName(node, "bucket", p.Bucket, p.ImportID)
 	terraform.SetAttributeStr(body, "bucket", bucketName)
 
-	if diagram.GetBool(p, "versioning") {
-		ver := body.AppendNewBlock("versioning", nil)
-		ver.Body().SetAttributeValue("enabled", cty.BoolVal(true))
-	}
-	if diagram.GetBool(p, "block_public_acls") || diagram.GetBool(p, "block_public_policy") {
-		acl := body.AppendNewBlock("public_access_block", nil)
-		acl.Body().SetAttributeValue("block_public_acls", cty.BoolVal(diagram.GetBool(p, "block_public_acls")))
-		acl.Body().SetAttributeValue("block_public_policy", cty.BoolVal(diagram.GetBool(p, "block_public_policy")))
-	}
-	if diagram.GetBool(p, "force_destroy") {
+	if p.ForceDestroy {
 		body.SetAttributeValue("force_destroy", cty.BoolVal(true))
 	}
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
@@ -68,5 +84,33 @@ func (s3Handler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap
 
 	f := hclwrite.NewEmptyFile()
 	f.Body().AppendBlock(block)
+
+	// Versioning, encryption and the public access block are separate resources in provider
+	// v4+ (the inline versioning and encryption blocks are deprecated). Terraform cannot move state between resource
+	// types, but the bucket keeps its address and aws_s3_bucket_versioning adopts the existing
+	// setting, so diagrams generated with the inline block upgrade without a replacement.
+	if p.Versioning {
+		ver := terraform.ResourceBlock("aws_s3_bucket_versioning", name)
+		ver.Body().SetAttributeTraversal("bucket", refTraversal("aws_s3_bucket."+name, "id"))
+		ver.Body().AppendNewBlock("versioning_configuration", nil).Body().SetAttributeValue("status", cty.StringVal("Enabled"))
+		f.Body().AppendNewline()
+		f.Body().AppendBlock(ver)
+	}
+	if p.SSEAlgorithm != "" {
+		sse := terraform.ResourceBlock("aws_s3_bucket_server_side_encryption_configuration", name)
+		sse.Body().SetAttributeTraversal("bucket", refTraversal("aws_s3_bucket."+name, "id"))
+		byDefault := sse.Body().AppendNewBlock("rule", nil).Body().AppendNewBlock("apply_server_side_encryption_by_default", nil)
+		byDefault.Body().SetAttributeValue("sse_algorithm", cty.StringVal(p.SSEAlgorithm))
+		f.Body().AppendNewline()
+		f.Body().AppendBlock(sse)
+	}
+	if p.BlockPublicACLs || p.BlockPublicPolicy {
+		pab := terraform.ResourceBlock("aws_s3_bucket_public_access_block", name)
+		pab.Body().SetAttributeTraversal("bucket", refTraversal("aws_s3_bucket."+name, "id"))
+		pab.Body().SetAttributeValue("block_public_acls", cty.BoolVal(p.BlockPublicACLs))
+		pab.Body().SetAttributeValue("block_public_policy", cty.BoolVal(p.BlockPublicPolicy))
+		f.Body().AppendNewline()
+		f.Body().AppendBlock(pab)
+	}
 	return f.Bytes(), nil
 }
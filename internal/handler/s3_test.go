@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestS3Validate(t *testing.T) {
+	runValidateCases(t, s3Handler{}, []validateCase{
+		{name: "bucket property", props: map[string]any{"bucket": "my-bucket"}},
+		{name: "label fallback", label: "assets", props: map[string]any{}},
+		{name: "missing bucket and label", props: map[string]any{}, want: []string{"bucket name or label is required"}},
+		{name: "import id", props: map[string]any{"bucket": "my-bucket", "import_id": "my-bucket"}},
+		{name: "invalid import id", props: map[string]any{"bucket": "my-bucket", "import_id": "My_Bucket"},
+			want: []string{`import_id "My_Bucket" is not a valid bucket name`}},
+		{name: "import id names the bucket", props: map[string]any{"import_id": "my-bucket"}},
+		{name: "bucket other than import id", label: "assets", props: map[string]any{"bucket": "new-bucket", "import_id": "my-bucket"},
+			want: []string{`bucket "new-bucket" differs from import_id "my-bucket"; Terraform would replace the imported resource`}},
+	})
+}
+
+func TestS3ImportNamesBucket(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "s3-data", Type: "s3_bucket", Label: "Data", Properties: map[string]any{"import_id": "legacy-data"}}}}
+	bucket := resource(t, generate(t, s3Handler{}, d, "s3-data", RefMap{}), "aws_s3_bucket", "s3_data")
+	if got := attr(bucket, "bucket"); got != `"legacy-data"` {
+		t.Errorf("bucket = %s, want the import id rather than the label", got)
+	}
+}
+
+func TestS3GenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "s3-data", Type: "s3_bucket", Properties: map[string]any{
+		"bucket": "my-bucket", "versioning": true, "block_public_acls": true, "sse_algorithm": "aws:kms",
+	}}}}
+	f := generate(t, s3Handler{}, d, "s3-data", RefMap{})
+
+	bucket := resource(t, f, "aws_s3_bucket", "s3_data")
+	if got := attr(bucket, "bucket"); got != `"my-bucket"` {
+		t.Errorf("bucket = %s", got)
+	}
+	if bucket.FirstMatchingBlock("versioning", nil) != nil {
+		t.Error("versioning must not be inlined in aws_s3_bucket")
+	}
+	if bucket.FirstMatchingBlock("server_side_encryption_configuration", nil) != nil {
+		t.Error("server_side_encryption_configuration must not be inlined in aws_s3_bucket")
+	}
+	if bucket.FirstMatchingBlock("public_access_block", nil) != nil {
+		t.Error("public_access_block must not be inlined in aws_s3_bucket")
+	}
+
+	sse := resource(t, f, "aws_s3_bucket_server_side_encryption_configuration", "s3_data")
+	if got := attr(sse, "bucket"); got != "aws_s3_bucket.s3_data.id" {
+		t.Errorf("encryption configuration bucket = %q", got)
+	}
+	if got := attr(sse.FirstMatchingBlock("rule", nil).Body().FirstMatchingBlock("apply_server_side_encryption_by_default", nil).Body(), "sse_algorithm"); got != `"aws:kms"` {
+		t.Errorf("sse_algorithm = %s", got)
+	}
+
+	pab := resource(t, f, "aws_s3_bucket_public_access_block", "s3_data")
+	if got := attr(pab, "bucket"); got != "aws_s3_bucket.s3_data.id" {
+		t.Errorf("public access block bucket = %q", got)
+	}
+	if got := attr(pab, "block_public_policy"); got != "false" {
+		t.Errorf("block_public_policy = %s", got)
+	}
+
+	ver := resource(t, f, "aws_s3_bucket_versioning", "s3_data")
+	if got := attr(ver, "bucket"); got != "aws_s3_bucket.s3_data.id" {
+		t.Errorf("versioning bucket = %q", got)
+	}
+	if cfg := ver.FirstMatchingBlock("versioning_configuration", nil); cfg == nil {
+		t.Error("versioning_configuration block not generated")
+	} else if got := attr(cfg.Body(), "status"); got != `"Enabled"` {
+		t.Errorf("status = %s", got)
+	}
+}
+
+func TestS3NoPublicAccessBlockByDefault(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{ID: "s3-data", Type: "s3_bucket", Label: "assets"}}}
+	f := generate(t, s3Handler{}, d, "s3-data", RefMap{})
+	if n := len(f.Body().Blocks()); n != 1 {
+		t.Errorf("got %d blocks, want only the bucket", n)
+	}
+}
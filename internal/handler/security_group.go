@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -17,31 +21,69 @@ func init() {
 
 func (securityGroupHandler) ResourceType() string { return "security_group" }
 
-func (securityGroupHandler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "name") == "" && node.Label == "" {
+// SGRule is one ingress or egress rule.
+type SGRule struct {
+	FromPort    int      `json:"from_port" schema:"required,min=0,max=65535" desc:"Start of the port range; 0 with protocol -1 for all."`
+	ToPort      int      `json:"to_port" schema:"required,min=0,max=65535" desc:"End of the port range."`
+	Protocol    string   `json:"protocol" schema:"required,nonempty" desc:"Protocol: tcp, udp, icmp or -1 for all."`
+	CIDRBlocks  []string `json:"cidr_blocks" schema:"required" desc:"Source or destination CIDRs, e.g. 0.0.0.0/0"`
+	Description string   `json:"description" desc:"Rule description."`
+}
+
+// SecurityGroupProps are the properties of a security_group node.
+type SecurityGroupProps struct {
+	commonProps
+	Name        string            `json:"name" desc:"Security group name; defaults to the node label."`
+	Description string            `json:"description" desc:"Security group description."`
+	Ingress     []SGRule          `json:"ingress" desc:"Inbound rules."`
+	Egress      []SGRule          `json:"egress" desc:"Outbound rules; all outbound traffic is allowed when omitted."`
+	Tags        map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// securityGroupImportID is the format of import_id: the security group id of an existing resource.
+var securityGroupImportID = importFormat{"security group id", regexp.MustCompile(`^sg-[0-9a-f]+$`), "sg-0a1b2c3d4e5f67890"}
+
+func (securityGroupHandler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(SecurityGroupProps{}, "Properties of a security_group node (aws_security_group).")
+}
+
+func (securityGroupHandler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(SecurityGroupProps)
+	errs := properties.Decode(node, p)
+	if p.Name == "" && node.Label == "" {
 		errs = append(errs, result.Error{
 			Type: "validation_error", Severity: "error", NodeID: node.ID,
 			Message: "name or label is required", Suggestion: "Set properties.name or node.label",
 		})
 	}
-	return errs, warns
+	errs = append(errs, securityGroupImportID.check(node, p.ImportID)...)
+	if p.ImportID != "" {
+		// Name and description cannot change in place, and the id does not tell them
+		for _, prop := range []struct{ name, value string }{{"name", p.Name}, {"description", p.Description}} {
+			if prop.value == "" {
+				errs = append(errs, result.Error{
+					Type: "validation_error", Severity: "error", NodeID: node.ID, Path: "properties/" + prop.name,
+					Message:    prop.name + " is required with import_id; Terraform would replace a security group whose " + prop.name + " differs",
+					Suggestion: "Set " + prop.name + " to the existing security group's " + prop.name,
+				})
+			}
+		}
+	}
+	return p, errs, nil
 }
 
-func (securityGroupHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (securityGroupHandler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_security_group", name)
 	body := block.Body()
 
-	p := node.Properties
-	sgName := diagram.GetStr(p, "name")
+	p := props.(*SecurityGroupProps)
+	sgName := p.Name
 	if sgName == "" {
 		sgName = node.Label
 	}
 	terraform.SetAttributeStr(body, "name", sgName)
-	terraform.SetAttributeStr(body, "description", diagram.GetStr(p, "description"))
+	terraform.SetAttributeStr(body, "description", p.Description)
 
 	for _, e := range d.EdgesWithTarget(node.ID) {
 		if e.Type == "contains" {
@@ -52,69 +94,11 @@ func (securityGroupHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram,
 		}
 	}
 
-	// Ingress/egress: simplified as list of blocks from properties
-	if rules, ok := p["ingress"].([]any); ok && len(rules) > 0 {
-		for _, r := range rules {
-			rm, _ := r.(map[string]any)
-			if rm == nil {
-				continue
-			}
-			ing := body.AppendNewBlock("ingress", nil)
-			ingBody := ing.Body()
-			if v, ok := rm["from_port"].(float64); ok {
-				ingBody.SetAttributeValue("from_port", cty.NumberIntVal(int64(v)))
-			}
-			if v, ok := rm["to_port"].(float64); ok {
-				ingBody.SetAttributeValue("to_port", cty.NumberIntVal(int64(v)))
-			}
-			if v, ok := rm["protocol"].(string); ok {
-				ingBody.SetAttributeValue("protocol", cty.StringVal(v))
-			}
-			if v, ok := rm["cidr_blocks"].([]any); ok && len(v) > 0 {
-				var list []cty.Value
-				for _, c := range v {
-					if s, ok := c.(string); ok {
-						list = append(list, cty.StringVal(s))
-					}
-				}
-				if len(list) > 0 {
-					ingBody.SetAttributeValue("cidr_blocks", cty.ListVal(list))
-				}
-			}
-		}
-	}
-	if rules, ok := p["egress"].([]any); ok && len(rules) > 0 {
-		for _, r := range rules {
-			rm, _ := r.(map[string]any)
-			if rm == nil {
-				continue
-			}
-			eg := body.AppendNewBlock("egress", nil)
-			egBody := eg.Body()
-			if v, ok := rm["from_port"].(float64); ok {
-				egBody.SetAttributeValue("from_port", cty.NumberIntVal(int64(v)))
-			}
-			if v, ok := rm["to_port"].(float64); ok {
-				egBody.SetAttributeValue("to_port", cty.NumberIntVal(int64(v)))
-			}
-			if v, ok := rm["protocol"].(string); ok {
-				egBody.SetAttributeValue("protocol", cty.StringVal(v))
-			}
-			if v, ok := rm["cidr_blocks"].([]any); ok && len(v) > 0 {
-				var list []cty.Value
-				for _, c := range v {
-					if s, ok := c.(string); ok {
-						list = append(list, cty.StringVal(s))
-					}
-				}
-				if len(list) > 0 {
-					egBody.SetAttributeValue("cidr_blocks", cty.ListVal(list))
-				}
-			}
-		}
-	}
+	// Ingress/egress: one block per rule, in property order
+	appendRules(body, "ingress", p.Ingress)
+	appendRules(body, "egress", p.Egress)
 	// Default egress if none
-	if _, hasEgress := p["egress"]; !hasEgress {
+	if p.Egress == nil {
 		eg := body.AppendNewBlock("egress", nil)
 		eg.Body().SetAttributeValue("from_port", cty.NumberIntVal(0))
 		eg.Body().SetAttributeValue("to_port", cty.NumberIntVal(0))
@@ -122,7 +106,7 @@ func (securityGroupHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram,
 		eg.Body().SetAttributeValue("cidr_blocks", cty.ListVal([]cty.Value{cty.StringVal("0.0.0.0/0")}))
 	}
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" && (tags == nil || tags["Name"] == "") {
 		if tags == nil {
 			tags = make(map[string]string)
@@ -135,3 +119,19 @@ func (securityGroupHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram,
 	f.Body().AppendBlock(block)
 	return f.Bytes(), nil
 }
+
+func appendRules(body *hclwrite.Body, blockType string, rules []SGRule) {
+	for _, r := range rules {
+		rb := body.AppendNewBlock(blockType, nil).Body()
+		terraform.SetAttributeInt(rb, "from_port", r.FromPort)
+		terraform.SetAttributeInt(rb, "to_port", r.ToPort)
+		rb.SetAttributeValue("protocol", cty.StringVal(r.Protocol))
+		if len(r.CIDRBlocks) > 0 {
+			list := make([]cty.Value, len(r.CIDRBlocks))
+			for i, c := range r.CIDRBlocks {
+				list[i] = cty.StringVal(c)
+			}
+			rb.SetAttributeValue("cidr_blocks", cty.ListVal(list))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestSecurityGroupValidate(t *testing.T) {
+	runValidateCases(t, securityGroupHandler{}, []validateCase{
+		{name: "name property", props: map[string]any{"name": "web-sg"}},
+		{name: "label fallback", label: "Web SG", props: map[string]any{}},
+		{name: "missing name and label", props: map[string]any{}, want: []string{"name or label is required"}},
+		{name: "import id with name and description", props: map[string]any{"name": "web-sg", "description": "web", "import_id": "sg-0abc"}},
+		{name: "import id without name or description", label: "Web SG", props: map[string]any{"import_id": "sg-0abc"}, want: []string{
+			"name is required with import_id; Terraform would replace a security group whose name differs",
+			"description is required with import_id; Terraform would replace a security group whose description differs",
+		}},
+	})
+}
+
+func TestSecurityGroupGenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: "vpc-1", Type: "vpc"},
+			{ID: "sg-1", Type: "security_group", Label: "Web SG", Properties: map[string]any{
+				"ingress": []any{
+					map[string]any{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []any{"10.0.0.0/16"}},
+					map[string]any{"from_port": 80.0, "to_port": 80.0, "protocol": "tcp", "cidr_blocks": []any{"0.0.0.0/0"}},
+				},
+			}},
+		},
+		Edges: []diagram.Edge{{ID: "e1", Source: "vpc-1", Target: "sg-1", Type: "contains"}},
+	}
+	body := resource(t, generate(t, securityGroupHandler{}, d, "sg-1", RefMap{"vpc-1": "aws_vpc.vpc_1"}), "aws_security_group", "sg_1")
+
+	if got := attr(body, "name"); got != `"Web SG"` {
+		t.Errorf("name = %s, want label fallback", got)
+	}
+	if got := attr(body, "vpc_id"); got != "aws_vpc.vpc_1.id" {
+		t.Errorf("vpc_id = %q", got)
+	}
+
+	var ingress, egress int
+	for _, b := range body.Blocks() {
+		switch b.Type() {
+		case "ingress":
+			ingress++
+		case "egress":
+			egress++
+			if got := attr(b.Body(), "protocol"); got != `"-1"` {
+				t.Errorf("default egress protocol = %s, want \"-1\"", got)
+			}
+		}
+	}
+	if ingress != 2 {
+		t.Errorf("got %d ingress blocks, want 2", ingress)
+	}
+	if egress != 1 {
+		t.Errorf("got %d egress blocks, want 1 default rule", egress)
+	}
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -16,28 +20,37 @@ func init() {
 
 func (subnetHandler) ResourceType() string { return "subnet" }
 
-func (subnetHandler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "cidr_block") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "cidr_block is required", Suggestion: "Set properties.cidr_block",
-		})
-	}
-	return errs, warns
+// subnetProps are the properties of a subnet node.
+type subnetProps struct {
+	commonProps
+	CIDRBlock           string            `json:"cidr_block" schema:"required,nonempty" pattern:"^[0-9]{1,3}(\\.[0-9]{1,3}){3}/[0-9]{1,2}$" desc:"Subnet CIDR, e.g. 10.0.1.0/24"`
+	AvailabilityZone    string            `json:"availability_zone" pattern:"^[a-z]{2}(-gov)?-[a-z]+-[0-9][a-z]$" desc:"Availability zone, e.g. us-east-1a"`
+	MapPublicIPOnLaunch bool              `json:"map_public_ip_on_launch" desc:"Assign public IPs to instances launched in the subnet."`
+	Tags                map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// subnetImportID is the format of import_id: the subnet id of an existing resource.
+var subnetImportID = importFormat{"subnet id", regexp.MustCompile(`^subnet-[0-9a-f]+$`), "subnet-0a1b2c3d4e5f67890"}
+
+func (subnetHandler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(subnetProps{}, "Properties of a subnet node (aws_subnet).")
+}
+
+func (subnetHandler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(subnetProps)
+	errs := properties.Decode(node, p)
+	return p, append(errs, subnetImportID.check(node, p.ImportID)...), nil
 }
 
-func (subnetHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (subnetHandler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_subnet", name)
 	body := block.Body()
 
-	p := node.Properties
-	terraform.SetAttributeStr(body, "cidr_block", diagram.GetStr(p, "cidr_block"))
-	terraform.SetAttributeStr(body, "availability_zone", diagram.GetStr(p, "availability_zone"))
-	terraform.SetAttributeBool(body, "map_public_ip_on_launch", diagram.GetBool(p, "map_public_ip_on_launch"))
+	p := props.(*subnetProps)
+	terraform.SetAttributeStr(body, "cidr_block", p.CIDRBlock)
+	terraform.SetAttributeStr(body, "availability_zone", p.AvailabilityZone)
+	terraform.SetAttributeBool(body, "map_public_ip_on_launch", p.MapPublicIPOnLaunch)
 
 	// vpc_id from "contains" edge: source is VPC (refs store "aws_vpc.node_3")
 	for _, e := range d.EdgesWithTarget(node.ID) {
@@ -49,7 +62,7 @@ func (subnetHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs Re
 		}
 	}
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestSubnetValidate(t *testing.T) {
+	runValidateCases(t, subnetHandler{}, []validateCase{
+		{name: "valid", props: map[string]any{"cidr_block": "10.0.1.0/24"}},
+		{name: "missing cidr", props: map[string]any{"availability_zone": "us-east-1a"}, want: []string{"cidr_block is required"}},
+	})
+}
+
+func TestSubnetGenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{
+		Nodes: []diagram.Node{
+			{ID: "vpc-1", Type: "vpc"},
+			{ID: "subnet-1", Type: "subnet", Properties: map[string]any{
+				"cidr_block": "10.0.1.0/24", "availability_zone": "us-east-1a",
+			}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		edges     []diagram.Edge
+		refs      RefMap
+		wantVPCID string
+	}{
+		{
+			name:      "contained in vpc",
+			edges:     []diagram.Edge{{ID: "e1", Source: "vpc-1", Target: "subnet-1", Type: "contains"}},
+			refs:      RefMap{"vpc-1": "aws_vpc.vpc_1"},
+			wantVPCID: "aws_vpc.vpc_1.id",
+		},
+		{
+			name:  "depends_on edge does not set vpc_id",
+			edges: []diagram.Edge{{ID: "e1", Source: "vpc-1", Target: "subnet-1", Type: "depends_on"}},
+			refs:  RefMap{"vpc-1": "aws_vpc.vpc_1"},
+		},
+		{
+			name:  "unresolved source is skipped",
+			edges: []diagram.Edge{{ID: "e1", Source: "vpc-1", Target: "subnet-1", Type: "contains"}},
+			refs:  RefMap{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d.Edges = tc.edges
+			body := resource(t, generate(t, subnetHandler{}, d, "subnet-1", tc.refs), "aws_subnet", "subnet_1")
+			if got := attr(body, "vpc_id"); got != tc.wantVPCID {
+				t.Errorf("vpc_id = %q, want %q", got, tc.wantVPCID)
+			}
+			if got := attr(body, "availability_zone"); got != `"us-east-1a"` {
+				t.Errorf("availability_zone = %s", got)
+			}
+		})
+	}
+}
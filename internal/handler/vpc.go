@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"regexp"
+
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/properties"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
 	"github.com/json-to-terraform/parser/internal/terraform"
@@ -16,30 +20,39 @@ func init() {
 
 func (vpcHandler) ResourceType() string { return "vpc" }
 
-func (vpcHandler) Validate(node *diagram.Node) ([]result.Error, []result.Warning) {
-	var errs []result.Error
-	var warns []result.Warning
-	p := node.Properties
-	if diagram.GetStr(p, "cidr_block") == "" {
-		errs = append(errs, result.Error{
-			Type: "validation_error", Severity: "error", NodeID: node.ID,
-			Message: "cidr_block is required", Suggestion: "Set properties.cidr_block (e.g. 10.0.0.0/16)",
-		})
-	}
-	return errs, warns
+// vpcProps are the properties of a vpc node.
+type vpcProps struct {
+	commonProps
+	CIDRBlock          string            `json:"cidr_block" schema:"required,nonempty" pattern:"^[0-9]{1,3}(\\.[0-9]{1,3}){3}/[0-9]{1,2}$" desc:"VPC CIDR, e.g. 10.0.0.0/16"`
+	EnableDNSHostnames bool              `json:"enable_dns_hostnames" desc:"Enable DNS hostnames in the VPC."`
+	EnableDNSSupport   bool              `json:"enable_dns_support" desc:"Enable DNS resolution in the VPC."`
+	Tags               map[string]string `json:"tags" desc:"Resource tags as string key-value pairs."`
+}
+
+// vpcImportID is the format of import_id: the VPC id of an existing resource.
+var vpcImportID = importFormat{"VPC id", regexp.MustCompile(`^vpc-[0-9a-f]+$`), "vpc-0a1b2c3d4e5f67890"}
+
+func (vpcHandler) PropertySchema() *jsonschema.Schema {
+	return properties.Schema(vpcProps{}, "Properties of a vpc node (aws_vpc).")
+}
+
+func (vpcHandler) Validate(node *diagram.Node) (any, []result.Error, []result.Warning) {
+	p := new(vpcProps)
+	errs := properties.Decode(node, p)
+	return p, append(errs, vpcImportID.check(node, p.ImportID)...), nil
 }
 
-func (vpcHandler) GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error) {
-	name := terraform.SanitizeName(node.ID)
+func (vpcHandler) GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error) {
+	name := terraform.ResourceName(node)
 	block := terraform.ResourceBlock("aws_vpc", name)
 	body := block.Body()
 
-	p := node.Properties
-	terraform.SetAttributeStr(body, "cidr_block", diagram.GetStr(p, "cidr_block"))
-	terraform.SetAttributeBool(body, "enable_dns_hostnames", diagram.GetBool(p, "enable_dns_hostnames"))
-	terraform.SetAttributeBool(body, "enable_dns_support", diagram.GetBool(p, "enable_dns_support"))
+	p := props.(*vpcProps)
+	terraform.SetAttributeStr(body, "cidr_block", p.CIDRBlock)
+	terraform.SetAttributeBool(body, "enable_dns_hostnames", p.EnableDNSHostnames)
+	terraform.SetAttributeBool(body, "enable_dns_support", p.EnableDNSSupport)
 
-	tags := diagram.GetStrMap(p, "tags")
+	tags := p.Tags
 	if node.Label != "" {
 		if tags == nil {
 			tags = make(map[string]string)
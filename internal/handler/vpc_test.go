@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestVPCValidate(t *testing.T) {
+	runValidateCases(t, vpcHandler{}, []validateCase{
+		{name: "valid", props: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{name: "missing cidr", props: map[string]any{}, want: []string{"cidr_block is required"}},
+		{name: "cidr wrong type", props: map[string]any{"cidr_block": 16.0}, want: []string{"cidr_block: expected a string, got integer"}},
+		{name: "unknown key", props: map[string]any{"cidr_block": "10.0.0.0/16", "enable_dns_hostname": true},
+			want: []string{"unknown property enable_dns_hostname"}},
+		{name: "import id", props: map[string]any{"cidr_block": "10.0.0.0/16", "import_id": "vpc-0abc"}},
+		{name: "import id of another type", props: map[string]any{"cidr_block": "10.0.0.0/16", "import_id": "subnet-0abc"},
+			want: []string{`import_id "subnet-0abc" is not a valid VPC id`}},
+	})
+}
+
+func TestVPCGenerateHCL(t *testing.T) {
+	d := &diagram.Diagram{Nodes: []diagram.Node{{
+		ID: "vpc-main", Type: "vpc", Label: "Main VPC",
+		Properties: map[string]any{"cidr_block": "10.0.0.0/16", "enable_dns_hostnames": true},
+	}}}
+	body := resource(t, generate(t, vpcHandler{}, d, "vpc-main", RefMap{}), "aws_vpc", "vpc_main")
+
+	tests := map[string]string{
+		"cidr_block":           `"10.0.0.0/16"`,
+		"enable_dns_hostnames": "true",
+		"enable_dns_support":   "false",
+	}
+	for name, want := range tests {
+		if got := attr(body, name); got != want {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+	if got := attr(body, "tags"); got != "{\n    Name = \"Main VPC\"\n  }" {
+		t.Errorf("tags = %s, want Name from label", got)
+	}
+}
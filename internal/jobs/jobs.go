@@ -0,0 +1,293 @@
+// Package jobs runs generation requests asynchronously, for diagrams that take longer than
+// a synchronous request may (API Gateway gives up after 29 seconds). Submit stores the
+// request and returns a job id at once; a worker (Run) later serves the request with the
+// API's POST /v1/generate and stores the response, and Status and Result report on it.
+//
+// All state lives in a Store, so submitting and working may happen in different processes.
+// Each job has three objects:
+//
+//	jobs/<id>/job.json      the job's state
+//	jobs/<id>/request.json  the submitted request, written after job.json so that its
+//	                        creation can trigger the worker (an S3 event notification)
+//	jobs/<id>/result        the response body, once the job finished
+//	jobs/<id>/run-<n>       the claim of the job's nth run, created by one worker only
+//
+// A worker holds a job for a lease (its deadline, e.g. the Lambda timeout). A job still
+// running when the lease expires lost its worker (a crash or a timeout): it is reported
+// as failed, and a retried notification runs it again.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"time"
+
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// DefaultLease is how long a worker may hold a job when Run's context has no deadline.
+const DefaultLease = 15 * time.Minute
+
+// State is the progress of a job.
+type State string
+
+const (
+	Queued    State = "queued"    // submitted, waiting for a worker
+	Running   State = "running"   // a worker is generating
+	Succeeded State = "succeeded" // the response is a 200
+	Failed    State = "failed"    // the response is an error (the result lists the errors)
+)
+
+// Job is the state of a job, as stored in job.json.
+type Job struct {
+	ID       string     `json:"id"`
+	State    State      `json:"state"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	// LeaseUntil is when the worker of a running job is given up on.
+	LeaseUntil *time.Time `json:"lease_until,omitempty"`
+	// Attempt counts the runs of the job; a worker claims run Attempt+1 before starting it.
+	Attempt int `json:"attempt,omitempty"`
+	// StatusCode and ContentType describe the stored response of a finished job.
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+
+	abandoned bool // running past its lease; Status reports it as failed
+}
+
+// stale reports whether the job is running past its lease.
+func (j *Job) stale(now time.Time) bool {
+	return j.State == Running && j.LeaseUntil != nil && now.After(*j.LeaseUntil)
+}
+
+// Done reports whether the job has finished and its result is stored.
+func (j *Job) Done() bool {
+	return j.State == Succeeded || j.State == Failed
+}
+
+// Request is a submitted generation request, as stored in request.json.
+type Request struct {
+	Query  string `json:"query,omitempty"`  // query string of POST /v1/generate
+	Accept string `json:"accept,omitempty"` // Accept header selecting JSON or an archive
+	Body   []byte `json:"body"`             // diagram JSON
+}
+
+// Service submits and runs jobs.
+type Service struct {
+	Store Store
+	// API serves the stored requests as POST /v1/generate.
+	API http.Handler
+	// Dispatch starts a worker for a submitted job, e.g. by running Run in a goroutine. It
+	// is nil when the store triggers workers itself.
+	Dispatch func(id string)
+	// Lease is how long Run may hold a job when its context has no deadline; 0 means
+	// DefaultLease.
+	Lease time.Duration
+}
+
+var idPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+var requestKey = regexp.MustCompile(`(?:^|/)jobs/([0-9a-f]{32})/request\.json$`)
+
+// JobID returns the id of the job whose request.json has the given key (with any store
+// prefix), so a notification of its creation can start the worker.
+func JobID(key string) (string, bool) {
+	m := requestKey.FindStringSubmatch(key)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+func key(id, name string) string {
+	return "jobs/" + id + "/" + name
+}
+
+// Submit stores req as a new queued job and dispatches it.
+func (s *Service) Submit(ctx context.Context, req Request) (*Job, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	job := &Job{ID: hex.EncodeToString(b[:]), State: Queued, Created: time.Now().UTC()}
+	if err := s.putJob(ctx, job); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.Put(ctx, key(job.ID, "request.json"), data); err != nil {
+		return nil, err
+	}
+	if s.Dispatch != nil {
+		s.Dispatch(job.ID)
+	}
+	return job, nil
+}
+
+// Status returns the job with the given id, or ErrNotFound. A job running past its lease is
+// reported as failed with a 500.
+func (s *Service) Status(ctx context.Context, id string) (*Job, error) {
+	job, err := s.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.stale(time.Now()) {
+		job.State, job.Finished, job.abandoned = Failed, job.LeaseUntil, true
+		job.StatusCode, job.ContentType = http.StatusInternalServerError, "application/json"
+	}
+	return job, nil
+}
+
+// load reads the stored state of a job.
+func (s *Service) load(ctx context.Context, id string) (*Job, error) {
+	if !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	data, err := s.Store.Get(ctx, key(id, "job.json"))
+	if err != nil {
+		return nil, err
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+// Result returns the job and, once it is done, its response body.
+func (s *Service) Result(ctx context.Context, id string) (*Job, []byte, error) {
+	job, err := s.Status(ctx, id)
+	if err != nil || !job.Done() {
+		return job, nil, err
+	}
+	if job.abandoned {
+		return job, errorBody("the job's worker stopped before finishing; submit the job again"), nil
+	}
+	body, err := s.Store.Get(ctx, key(id, "result"))
+	return job, body, err
+}
+
+// Run is the worker: it generates the job's request and stores the response. Jobs that
+// are neither queued nor running past their lease are skipped, and so are runs another
+// worker claimed first (Store.Create of run-<n>), so a duplicate notification does not run
+// a job twice. Once the job is running, an error fails it.
+func (s *Service) Run(ctx context.Context, id string) error {
+	job, err := s.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	started := time.Now().UTC()
+	if job.State != Queued && !job.stale(started) {
+		return nil
+	}
+	attempt := job.Attempt + 1
+	err = s.Store.Create(ctx, key(id, fmt.Sprintf("run-%d", attempt)), []byte(started.Format(time.RFC3339Nano)))
+	if errors.Is(err, ErrExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lease := started.Add(s.lease())
+	if deadline, ok := ctx.Deadline(); ok {
+		lease = deadline.UTC()
+	}
+	job.State, job.Started, job.LeaseUntil, job.Attempt = Running, &started, &lease, attempt
+	if err := s.putJob(ctx, job); err != nil {
+		return err
+	}
+	if err := s.run(ctx, job); err != nil {
+		return s.fail(ctx, job, err)
+	}
+	return nil
+}
+
+// run serves the job's request and stores the response.
+func (s *Service) run(ctx context.Context, job *Job) error {
+	id := job.ID
+	data, err := s.Store.Get(ctx, key(id, "request.json"))
+	if err != nil {
+		return err
+	}
+	var req Request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return fmt.Errorf("job %s: %w", id, err)
+	}
+
+	target := "/v1/generate"
+	if req.Query != "" {
+		target += "?" + req.Query
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(req.Body))
+	if err != nil {
+		return fmt.Errorf("job %s: %w", id, err)
+	}
+	if req.Accept != "" {
+		r.Header.Set("Accept", req.Accept)
+	}
+	r.RemoteAddr = "job " + id
+	rec := httptest.NewRecorder()
+	s.API.ServeHTTP(rec, r)
+
+	if err := s.Store.Put(ctx, key(id, "result"), rec.Body.Bytes()); err != nil {
+		return err
+	}
+	finished := time.Now().UTC()
+	job.State, job.Finished = Failed, &finished
+	if rec.Code == http.StatusOK {
+		job.State = Succeeded
+	}
+	job.StatusCode, job.ContentType = rec.Code, rec.Header().Get("Content-Type")
+	return s.putJob(ctx, job)
+}
+
+// fail records a job whose worker hit err as failed, with err as its result. The context
+// may be done (a timeout), so the record is written without its cancellation.
+func (s *Service) fail(ctx context.Context, job *Job, err error) error {
+	ctx = context.WithoutCancel(ctx)
+	if perr := s.Store.Put(ctx, key(job.ID, "result"), errorBody("job: "+err.Error())); perr != nil {
+		return errors.Join(err, perr)
+	}
+	finished := time.Now().UTC()
+	job.State, job.Finished = Failed, &finished
+	job.StatusCode, job.ContentType = http.StatusInternalServerError, "application/json"
+	if perr := s.putJob(ctx, job); perr != nil {
+		return errors.Join(err, perr)
+	}
+	return err
+}
+
+func (s *Service) lease() time.Duration {
+	if s.Lease > 0 {
+		return s.Lease
+	}
+	return DefaultLease
+}
+
+// errorBody is a generate response reporting an internal error.
+func errorBody(msg string) []byte {
+	data, _ := json.Marshal(struct {
+		Success bool           `json:"success"`
+		Errors  []result.Error `json:"errors"`
+	}{Errors: []result.Error{{Type: "internal_error", Severity: "error", Message: msg}}})
+	return data
+}
+
+func (s *Service) putJob(ctx context.Context, job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.Store.Put(ctx, key(job.ID, "job.json"), data)
+}
@@ -0,0 +1,294 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeAPI answers POST /v1/generate with the request it received.
+var fakeAPI = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if strings.Contains(string(body), "invalid") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"success":false}`)
+		return
+	}
+	w.Header().Set("Content-Type", r.Header.Get("Accept"))
+	io.WriteString(w, r.Method+" "+r.URL.String()+" "+string(body))
+})
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	svc := &Service{Store: DirStore{Dir: t.TempDir()}, API: fakeAPI}
+
+	job, err := svc.Submit(ctx, Request{Query: "strict=true", Accept: "application/zip", Body: []byte(`{"nodes":[]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != Queued || len(job.ID) != 32 {
+		t.Fatalf("submitted job = %+v", job)
+	}
+	if _, body, err := svc.Result(ctx, job.ID); err != nil || body != nil {
+		t.Fatalf("result of a queued job = %q, %v", body, err)
+	}
+
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	job, body, err := svc.Result(ctx, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.State != Succeeded || job.StatusCode != 200 || job.ContentType != "application/zip" || job.Started == nil || job.Finished == nil {
+		t.Errorf("finished job = %+v", job)
+	}
+	if want := `POST /v1/generate?strict=true {"nodes":[]}`; string(body) != want {
+		t.Errorf("result = %q, want %q", body, want)
+	}
+
+	// a duplicate notification leaves the finished job alone
+	finished := *job.Finished
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if job, _ := svc.Status(ctx, job.ID); !job.Finished.Equal(finished) {
+		t.Error("finished job ran again")
+	}
+
+	for _, id := range []string{"nope", "../../etc", strings.Repeat("0", 32)} {
+		if _, err := svc.Status(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Status(%q) error = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestServiceFailedJob(t *testing.T) {
+	ctx := context.Background()
+	var dispatched []string
+	svc := &Service{Store: DirStore{Dir: t.TempDir()}, API: fakeAPI}
+	svc.Dispatch = func(id string) {
+		dispatched = append(dispatched, id)
+		if err := svc.Run(ctx, id); err != nil {
+			t.Error(err)
+		}
+	}
+	job, err := svc.Submit(ctx, Request{Body: []byte(`"invalid"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, body, _ := svc.Result(ctx, job.ID)
+	if len(dispatched) != 1 || job.State != Failed || job.StatusCode != 422 || string(body) != `{"success":false}` {
+		t.Errorf("job = %+v, result %q, dispatched %v", job, body, dispatched)
+	}
+}
+
+// failingStore is a DirStore whose Puts of the keys ending in failPut fail.
+type failingStore struct {
+	DirStore
+	failPut string
+}
+
+func (s failingStore) Put(ctx context.Context, key string, data []byte) error {
+	if s.failPut != "" && strings.HasSuffix(key, s.failPut) {
+		return errors.New("disk full")
+	}
+	return s.DirStore.Put(ctx, key, data)
+}
+
+func TestServiceStoreFailure(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	svc := &Service{Store: DirStore{Dir: dir}, API: fakeAPI}
+	job, err := svc.Submit(ctx, Request{Body: []byte(`{"nodes":[]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a corrupt request fails the job with the error as its result
+	svc.Store.Put(ctx, key(job.ID, "request.json"), []byte("not json"))
+	if err := svc.Run(ctx, job.ID); err == nil {
+		t.Fatal("Run() of a corrupt request succeeded")
+	}
+	got, body, err := svc.Result(ctx, job.ID)
+	if err != nil || got.State != Failed || got.StatusCode != 500 || !strings.Contains(string(body), "internal_error") {
+		t.Errorf("job = %+v, result %q, %v", got, body, err)
+	}
+
+	// when not even the failure can be stored, the job stays running until its lease expires
+	job, _ = svc.Submit(ctx, Request{Body: []byte(`{"nodes":[]}`)})
+	svc.Store = failingStore{DirStore: DirStore{Dir: dir}, failPut: "/result"}
+	if err := svc.Run(ctx, job.ID); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got, _ := svc.Status(ctx, job.ID); got.State != Running || got.LeaseUntil == nil {
+		t.Errorf("job = %+v", got)
+	}
+}
+
+func TestServiceStaleLease(t *testing.T) {
+	ctx := context.Background()
+	svc := &Service{Store: DirStore{Dir: t.TempDir()}, API: fakeAPI, Lease: time.Hour}
+	job, err := svc.Submit(ctx, Request{Body: []byte(`{"nodes":[]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a worker that is still within its lease is left alone
+	lease := time.Now().Add(time.Minute)
+	job.State, job.LeaseUntil = Running, &lease
+	svc.putJob(ctx, job)
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := svc.Status(ctx, job.ID); got.State != Running {
+		t.Fatalf("job within its lease = %+v", got)
+	}
+
+	// one that stopped is reported as failed, and a retry runs the job again
+	lease = time.Now().Add(-time.Minute)
+	svc.putJob(ctx, job)
+	got, body, err := svc.Result(ctx, job.ID)
+	if err != nil || got.State != Failed || got.StatusCode != 500 || !strings.Contains(string(body), "stopped before finishing") {
+		t.Fatalf("abandoned job = %+v, result %q, %v", got, body, err)
+	}
+	if err := svc.Run(ctx, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = svc.Status(ctx, job.ID)
+	if got.State != Succeeded || got.LeaseUntil == nil || got.LeaseUntil.Sub(*got.Started) != time.Hour {
+		t.Errorf("retried job = %+v", got)
+	}
+}
+
+func TestServiceConcurrentRuns(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var runs int
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		runs++
+		mu.Unlock()
+		fakeAPI(w, r)
+	})
+	svc := &Service{Store: DirStore{Dir: t.TempDir()}, API: api}
+	job, err := svc.Submit(ctx, Request{Body: []byte(`{"nodes":[]}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// workers that all read the job while it is queued: only the one that claims it runs it
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := svc.Run(ctx, job.ID); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs != 1 {
+		t.Errorf("job ran %d times, want 1", runs)
+	}
+	if got, _ := svc.Status(ctx, job.ID); got.State != Succeeded || got.Attempt != 1 {
+		t.Errorf("job = %+v", got)
+	}
+}
+
+func TestJobID(t *testing.T) {
+	id := strings.Repeat("ab", 16)
+	tests := map[string]bool{
+		"jobs/" + id + "/request.json":        true,
+		"prefix/jobs/" + id + "/request.json": true,
+		"jobs/" + id + "/job.json":            false,
+		"myjobs/" + id + "/request.json":      false,
+		"jobs/" + id[:30] + "/request.json":   false,
+		"jobs/" + id + "/request.json.tmp":    false,
+		"other/jobs/" + id + "x/request.json": false,
+	}
+	for key, want := range tests {
+		got, ok := JobID(key)
+		if ok != want || (ok && got != id) {
+			t.Errorf("JobID(%q) = %q, %v", key, got, ok)
+		}
+	}
+}
+
+// fakeS3 is a path-style S3 endpoint keeping objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		if _, ok := f.objects[r.URL.Path]; ok && r.Header.Get("If-None-Match") == "*" {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusPreconditionFailed)
+			io.WriteString(w, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+			return
+		}
+		f.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+	case http.MethodGet:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			return
+		}
+		w.Write(data)
+	}
+}
+
+func TestS3Store(t *testing.T) {
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	client := s3.New(s3.Options{
+		Region:                     "us-east-1",
+		BaseEndpoint:               aws.String(srv.URL),
+		UsePathStyle:               true,
+		Credentials:                credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+	})
+	store := S3Store{Client: client, Bucket: "bucket", Prefix: "json2tf/"}
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "jobs/x/job.json"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing key: %v", err)
+	}
+	if err := store.Put(ctx, "jobs/x/job.json", []byte(`{"id":"x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fake.objects["/bucket/json2tf/jobs/x/job.json"]; !ok {
+		t.Errorf("objects = %v", fake.objects)
+	}
+	data, err := store.Get(ctx, "jobs/x/job.json")
+	if err != nil || string(data) != `{"id":"x"}` {
+		t.Errorf("Get() = %q, %v", data, err)
+	}
+
+	if err := store.Create(ctx, "jobs/x/run-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(ctx, "jobs/x/run-1", nil); !errors.Is(err, ErrExists) {
+		t.Errorf("Create of an existing key: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package jsonschema builds and checks JSON Schema (draft 2020-12) documents.
+// It covers the keywords the diagram schema uses; see Validate for the supported set.
+package jsonschema
+
+// Draft is the $schema URI written into generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is one JSON Schema object. Only the fields that are set are marshalled.
+type Schema struct {
+	SchemaURI   string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type    string `json:"type,omitempty"`
+	Enum    []any  `json:"enum,omitempty"`
+	Const   any    `json:"const,omitempty"`
+	Default any    `json:"default,omitempty"`
+	// WriteOnly marks secrets: accepted as input but never echoed back.
+	WriteOnly bool `json:"writeOnly,omitempty"`
+
+	// Strings
+	MinLength *int   `json:"minLength,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+
+	// Numbers
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+
+	// Objects
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// Arrays
+	Items *Schema `json:"items,omitempty"`
+
+	// Composition
+	Not   *Schema   `json:"not,omitempty"`
+	AllOf []*Schema `json:"allOf,omitempty"`
+	If    *Schema   `json:"if,omitempty"`
+	Then  *Schema   `json:"then,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// String returns a schema for a string value.
+func String(description string) *Schema {
+	return &Schema{Type: "string", Description: description}
+}
+
+// NonEmptyString returns a schema for a string of at least one character.
+func NonEmptyString(description string) *Schema {
+	one := 1
+	return &Schema{Type: "string", Description: description, MinLength: &one}
+}
+
+// Enum returns a schema for a string restricted to values.
+func Enum(description string, values ...string) *Schema {
+	s := &Schema{Type: "string", Description: description}
+	for _, v := range values {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// Boolean returns a schema for a boolean value.
+func Boolean(description string) *Schema {
+	return &Schema{Type: "boolean", Description: description}
+}
+
+// Integer returns a schema for an integer between min and max inclusive.
+func Integer(description string, min, max float64) *Schema {
+	return &Schema{Type: "integer", Description: description, Minimum: &min, Maximum: &max}
+}
+
+// StringMap returns a schema for an object whose values are all strings (tags, environment variables).
+func StringMap(description string) *Schema {
+	return &Schema{Type: "object", Description: description, AdditionalProperties: &Schema{Type: "string"}}
+}
+
+// Array returns a schema for an array of items.
+func Array(description string, items *Schema) *Schema {
+	return &Schema{Type: "array", Description: description, Items: items}
+}
+
+// Object returns a schema for an object with the given properties, of which required must be present.
+func Object(description string, props map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: "object", Description: description, Properties: props, Required: required}
+}
+
+// False returns a schema no value satisfies ({"not": {}}, equivalent to the boolean schema false).
+func False() *Schema {
+	return &Schema{Not: &Schema{}}
+}
+
+// Closed disallows object members not listed in Properties and returns s.
+func (s *Schema) Closed() *Schema {
+	s.AdditionalProperties = False()
+	return s
+}
+
+// WithDefault sets the documented default value and returns s.
+func (s *Schema) WithDefault(v any) *Schema {
+	s.Default = v
+	return s
+}
+
+// WithPattern sets a regular expression strings must match and returns s.
+func (s *Schema) WithPattern(re string) *Schema {
+	s.Pattern = re
+	return s
+}
@@ -0,0 +1,302 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agext/levenshtein"
+)
+
+// Violation is one value that does not satisfy the schema.
+type Violation struct {
+	Path       string // JSON pointer to the value, relative to the validated root
+	Keyword    string // schema keyword that failed (type, enum, required, ...)
+	Message    string
+	Suggestion string
+}
+
+// Validate checks v against s and returns every violation in document order (object
+// members by key). v holds the values encoding/json produces: map[string]any, []any,
+// string, bool, float64 (other Go numeric types are accepted) and nil.
+//
+// Supported keywords: $ref (to "#/$defs/..." of s), type, enum, const, minLength, pattern,
+// minimum, maximum, properties, required, additionalProperties, items, not, allOf and if/then.
+// Violations inside "if" and "not" are not reported; they only decide whether the keyword holds.
+// A member rejected by additionalProperties False() is reported as an unknown property, with
+// the closest declared property name as suggestion.
+func (s *Schema) Validate(v any) []Violation {
+	c := &checker{root: s}
+	c.check(s, v, "")
+	return c.out
+}
+
+type checker struct {
+	root *Schema
+	out  []Violation
+}
+
+func (c *checker) add(ptr, keyword, suggestion, format string, args ...any) {
+	c.out = append(c.out, Violation{Path: ptr, Keyword: keyword, Message: fmt.Sprintf(format, args...), Suggestion: suggestion})
+}
+
+func (c *checker) check(s *Schema, v any, ptr string) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		c.check(c.resolve(s.Ref), v, ptr)
+	}
+	if s.Type != "" && !hasType(v, s.Type) {
+		c.add(ptr, "type", "", "expected %s, got %s", article(s.Type), typeName(v))
+		return
+	}
+	if len(s.Enum) > 0 && !contains(s.Enum, v) {
+		c.add(ptr, "enum", "Use one of: "+join(s.Enum), "%s is not an allowed value", quote(v))
+	}
+	if s.Const != nil && !equal(s.Const, v) {
+		c.add(ptr, "const", "", "must be %s", quote(s.Const))
+	}
+
+	switch x := v.(type) {
+	case string:
+		n := len([]rune(x))
+		if s.MinLength != nil && n < *s.MinLength {
+			if *s.MinLength == 1 {
+				c.add(ptr, "minLength", s.Description, "must not be empty")
+			} else {
+				c.add(ptr, "minLength", s.Description, "must be at least %d characters", *s.MinLength)
+			}
+		}
+		if s.Pattern != "" && !compile(s.Pattern).MatchString(x) {
+			c.add(ptr, "pattern", s.Description, "%q does not match the expected format %s", x, s.Pattern)
+		}
+	case map[string]any:
+		c.object(s, x, ptr)
+	case []any:
+		if s.Items != nil {
+			for i, item := range x {
+				c.check(s.Items, item, fmt.Sprintf("%s/%d", ptr, i))
+			}
+		}
+	default:
+		if f, ok := number(v); ok {
+			if s.Minimum != nil && f < *s.Minimum {
+				c.add(ptr, "minimum", s.Description, "must be at least %v, got %v", *s.Minimum, f)
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				c.add(ptr, "maximum", s.Description, "must be at most %v, got %v", *s.Maximum, f)
+			}
+		}
+	}
+
+	if s.Not != nil && c.matches(s.Not, v, ptr) {
+		c.add(ptr, "not", "", "value is not allowed here")
+	}
+	for _, sub := range s.AllOf {
+		c.check(sub, v, ptr)
+	}
+	if s.If != nil && s.Then != nil && c.matches(s.If, v, ptr) {
+		c.check(s.Then, v, ptr)
+	}
+}
+
+// matches reports whether v satisfies s without recording violations.
+func (c *checker) matches(s *Schema, v any, ptr string) bool {
+	probe := &checker{root: c.root}
+	probe.check(s, v, ptr)
+	return len(probe.out) == 0
+}
+
+func (c *checker) object(s *Schema, m map[string]any, ptr string) {
+	for _, name := range s.Required {
+		if _, ok := m[name]; !ok {
+			suggestion := "Set " + name
+			if p := s.Properties[name]; p != nil && p.Description != "" {
+				suggestion += " (" + p.Description + ")"
+			}
+			c.add(ptr+"/"+escape(name), "required", suggestion, "%s is required", name)
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sub, ok := s.Properties[k]
+		if !ok {
+			sub = s.AdditionalProperties
+			if sub.isFalse() {
+				c.add(ptr+"/"+escape(k), "additionalProperties", didYouMean(k, s.Properties), "unknown property %q", k)
+				continue
+			}
+		}
+		c.check(sub, m[k], ptr+"/"+escape(k))
+	}
+}
+
+func (s *Schema) isFalse() bool {
+	return s != nil && s.Not != nil && reflect.DeepEqual(*s.Not, Schema{})
+}
+
+// didYouMean suggests the declared property closest to an unknown key, or lists them all
+// when none is close enough to be a likely typo.
+func didYouMean(key string, props map[string]*Schema) string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	best, bestDist := "", -1
+	for _, name := range names {
+		d := levenshtein.Distance(key, name, nil)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	if best != "" && bestDist <= max(2, len(key)/3) {
+		return fmt.Sprintf("Did you mean %q?", best)
+	}
+	if len(names) == 0 {
+		return "Remove it; no properties are allowed here"
+	}
+	return "Remove it or use one of: " + strings.Join(names, ", ")
+}
+
+func (c *checker) resolve(ref string) *Schema {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok || c.root.Defs[name] == nil {
+		panic("jsonschema: unresolvable $ref " + ref)
+	}
+	return c.root.Defs[name]
+}
+
+var patterns sync.Map // pattern -> *regexp.Regexp
+
+func compile(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
+
+func hasType(v any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := number(v)
+		return ok
+	case "integer":
+		f, ok := number(v)
+		return ok && f == math.Trunc(f)
+	}
+	return false
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if f, ok := number(v); ok {
+		if f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func article(typ string) string {
+	switch typ {
+	case "object", "array", "integer":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+func contains(values []any, v any) bool {
+	for _, e := range values {
+		if equal(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares JSON values; numbers by value whatever their Go type, and arrays and
+// objects deeply (== panics on them).
+func equal(a, b any) bool {
+	fa, aok := number(a)
+	fb, bok := number(b)
+	if aok && bok {
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func quote(v any) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func join(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escape encodes a key for use in a JSON pointer (RFC 6901).
+func escape(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,96 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	rule := Object("", map[string]*Schema{
+		"port":  Integer("", 0, 65535),
+		"proto": Enum("", "tcp", "udp"),
+	}, "port")
+	s := Object("", map[string]*Schema{
+		"name":  NonEmptyString(""),
+		"cidr":  String("").WithPattern(`^[0-9.]+/[0-9]+$`),
+		"rules": Array("", rule),
+		"tags":  StringMap(""),
+	}, "name")
+
+	cases := []struct {
+		name string
+		v    any
+		want []string // "path keyword"
+	}{
+		{name: "valid", v: map[string]any{"name": "a", "rules": []any{map[string]any{"port": 22.0, "proto": "tcp"}}}},
+		{name: "not an object", v: "x", want: []string{" type"}},
+		{name: "missing required", v: map[string]any{}, want: []string{"/name required"}},
+		{name: "empty string", v: map[string]any{"name": ""}, want: []string{"/name minLength"}},
+		{name: "pattern", v: map[string]any{"name": "a", "cidr": "10.0.0.0"}, want: []string{"/cidr pattern"}},
+		{name: "nested", v: map[string]any{"name": "a", "rules": []any{
+			map[string]any{"port": 70000.0, "proto": "icmp"},
+			map[string]any{"port": "22"},
+			map[string]any{"port": 1.5},
+		}}, want: []string{"/rules/0/port maximum", "/rules/0/proto enum", "/rules/1/port type", "/rules/2/port type"}},
+		{name: "additional properties", v: map[string]any{"name": "a", "tags": map[string]any{"Env": 1.0}}, want: []string{"/tags/Env type"}},
+		{name: "go ints", v: map[string]any{"name": "a", "rules": []any{map[string]any{"port": 22}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, v := range s.Validate(tc.v) {
+				got = append(got, v.Path+" "+v.Keyword)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("violations = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateConstAndEnumOfArrays(t *testing.T) {
+	s := &Schema{Const: []any{"a", "b"}}
+	if vs := s.Validate([]any{"a", "b"}); len(vs) != 0 {
+		t.Errorf("equal array: violations %+v", vs)
+	}
+	if vs := s.Validate([]any{"a"}); len(vs) != 1 || vs[0].Keyword != "const" {
+		t.Errorf("different array: violations %+v", vs)
+	}
+	s = &Schema{Enum: []any{map[string]any{"x": 1.0}, "y"}}
+	if vs := s.Validate(map[string]any{"x": 1.0}); len(vs) != 0 {
+		t.Errorf("object in enum: violations %+v", vs)
+	}
+}
+
+func TestValidateRefAndIfThen(t *testing.T) {
+	s := &Schema{
+		Type: "object",
+		AllOf: []*Schema{{
+			If:   &Schema{Properties: map[string]*Schema{"kind": {Const: "a"}}, Required: []string{"kind"}},
+			Then: &Schema{Properties: map[string]*Schema{"size": {Ref: "#/$defs/size"}}},
+		}},
+		Defs: map[string]*Schema{"size": Integer("", 1, 10)},
+	}
+	if v := s.Validate(map[string]any{"kind": "b", "size": 99.0}); len(v) != 0 {
+		t.Errorf("then applied although if failed: %+v", v)
+	}
+	v := s.Validate(map[string]any{"kind": "a", "size": 99.0})
+	if len(v) != 1 || v[0].Path != "/size" || v[0].Keyword != "maximum" {
+		t.Errorf("violations = %+v", v)
+	}
+}
+
+func TestViolationMessages(t *testing.T) {
+	s := Object("", map[string]*Schema{
+		"memory_size": Integer("Memory in MB.", 128, 10240),
+		"runtime":     Enum("", "python3.12", "nodejs20.x"),
+	})
+	v := s.Validate(map[string]any{"memory_size": "512", "runtime": "python2.7"})
+	want := []Violation{
+		{Path: "/memory_size", Keyword: "type", Message: "expected an integer, got string"},
+		{Path: "/runtime", Keyword: "enum", Message: `"python2.7" is not an allowed value`, Suggestion: "Use one of: python3.12, nodejs20.x"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("violations = %+v\nwant %+v", v, want)
+	}
+}
@@ -0,0 +1,189 @@
+// Package lint checks a diagram against best-practice and security rules. Unlike
+// validation, a finding does not mean the diagram is broken: each rule has an id, a default
+// severity and a suggestion, severities can be changed or rules turned off with a rules
+// file (Config), and a node can suppress rules for itself with its "suppress" list.
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// Severity is how a rule's findings are reported.
+type Severity string
+
+const (
+	// SeverityError findings are lint_errors and fail the parse.
+	SeverityError Severity = "error"
+	// SeverityWarning and SeverityInfo findings are lint_warnings.
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	// SeverityOff disables the rule.
+	SeverityOff Severity = "off"
+)
+
+func (s Severity) valid() bool {
+	switch s {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityOff:
+		return true
+	}
+	return false
+}
+
+// Rule is one built-in check.
+type Rule struct {
+	ID         string
+	Severity   Severity // default severity
+	Summary    string
+	Suggestion string
+	Types      []string // node types the rule looks at
+	check      func(d *diagram.Diagram, n *diagram.Node, props any) []finding
+}
+
+// finding is one problem found by a rule; path is relative to the node ("properties/ingress/0").
+type finding struct {
+	path    string
+	message string
+}
+
+// Rules returns the built-in rules ordered by id.
+func Rules() []Rule {
+	out := make([]Rule, len(rules))
+	for i, r := range rules {
+		out[i] = *r
+	}
+	return out
+}
+
+func ruleByID(id string) *Rule {
+	for _, r := range rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// Config adjusts the built-in rules. The zero Config runs every rule at its default severity.
+type Config struct {
+	// Rules maps a rule id to the severity it is reported with, or "off".
+	Rules map[string]Severity `json:"rules"`
+}
+
+// LoadConfig reads a rules file (see ParseConfig).
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig parses a rules file:
+//
+//	{"rules": {"s3-unencrypted": "off", "rds-plaintext-password": "error"}}
+//
+// Unknown rule ids and severities are rejected.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid rules file: %w", err)
+	}
+	ids := make([]string, 0, len(cfg.Rules))
+	for id := range cfg.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if ruleByID(id) == nil {
+			return Config{}, fmt.Errorf("unknown rule %q; rules are: %s", id, strings.Join(ruleIDs(), ", "))
+		}
+		if sev := cfg.Rules[id]; !sev.valid() {
+			return Config{}, fmt.Errorf("rule %s: unknown severity %q; use error, warning, info or off", id, sev)
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) severity(r *Rule) Severity {
+	if s, ok := c.Rules[r.ID]; ok {
+		return s
+	}
+	return r.Severity
+}
+
+func ruleIDs() []string {
+	ids := make([]string, len(rules))
+	for i, r := range rules {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// Run applies the rules to every node in diagram order. props maps node ids to the properties
+// their handler decoded (registry.ResourceHandler.Validate); the rules read those instead of
+// decoding again, and skip nodes without them. Findings of error severity are returned as
+// lint_errors, the rest as lint_warnings; both carry the rule id, and paths are
+// relative to the node. Rules listed in a node's Suppress are skipped for that node; unknown
+// ids there are reported as warnings so typos do not silently re-enable a rule.
+func Run(d *diagram.Diagram, props map[string]any, cfg Config) ([]result.Error, []result.Warning) {
+	var errs []result.Error
+	var warns []result.Warning
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		suppressed := make(map[string]bool, len(n.Suppress))
+		for j, id := range n.Suppress {
+			suppressed[id] = true
+			if ruleByID(id) == nil {
+				warns = append(warns, result.Warning{
+					Type: "lint_warning", Severity: string(SeverityWarning), NodeID: n.ID,
+					Path:       fmt.Sprintf("suppress/%d", j),
+					Message:    fmt.Sprintf("suppress lists unknown rule %q", id),
+					Suggestion: "Use one of: " + strings.Join(ruleIDs(), ", "),
+				})
+			}
+		}
+		for _, r := range rules {
+			sev := cfg.severity(r)
+			if sev == SeverityOff || suppressed[r.ID] || !appliesTo(r, n.Type) {
+				continue
+			}
+			for _, f := range r.check(d, n, props[n.ID]) {
+				if sev == SeverityError {
+					errs = append(errs, result.Error{
+						Type: "lint_error", Severity: string(sev), NodeID: n.ID, Rule: r.ID,
+						Path: f.path, Message: f.message, Suggestion: r.Suggestion,
+					})
+					continue
+				}
+				warns = append(warns, result.Warning{
+					Type: "lint_warning", Severity: string(sev), NodeID: n.ID, Rule: r.ID,
+					Path: f.path, Message: f.message, Suggestion: r.Suggestion,
+				})
+			}
+		}
+	}
+	return errs, warns
+}
+
+func appliesTo(r *Rule, nodeType string) bool {
+	for _, t := range r.Types {
+		if t == nodeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package lint
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+)
+
+// findings decodes one node with its handler, runs the rules on it and returns "severity rule path: message" for each finding.
+func findings(t *testing.T, env string, n diagram.Node, cfg Config) []string {
+	t.Helper()
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Environment: env}, Nodes: []diagram.Node{n}}
+	props := make(map[string]any)
+	if h, ok := registry.Default.Get(n.Type); ok {
+		props[n.ID], _, _ = h.Validate(&d.Nodes[0])
+	}
+	errs, warns := Run(d, props, cfg)
+	var out []string
+	for _, e := range errs {
+		out = append(out, "error "+e.Rule+" "+e.Path+": "+e.Message)
+	}
+	for _, w := range warns {
+		out = append(out, w.Severity+" "+w.Rule+" "+w.Path+": "+w.Message)
+	}
+	return out
+}
+
+func TestRules(t *testing.T) {
+	secureRDS := map[string]any{"engine": "postgres", "storage_encrypted": true, "backup_retention_period": 7.0, "multi_az": true}
+	tests := []struct {
+		name string
+		env  string
+		node diagram.Node
+		want []string
+	}{
+		{
+			name: "ssh open to the world",
+			node: diagram.Node{ID: "sg", Type: "security_group", Properties: map[string]any{"ingress": []any{
+				map[string]any{"from_port": 443.0, "to_port": 443.0, "protocol": "tcp", "cidr_blocks": []any{"0.0.0.0/0"}},
+				map[string]any{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []any{"10.0.0.0/8", "0.0.0.0/0"}},
+			}}},
+			want: []string{"warning sg-open-admin-port properties/ingress/1: ingress[1] opens port 22 (SSH) to 0.0.0.0/0"},
+		},
+		{
+			name: "all traffic covers both admin ports",
+			node: diagram.Node{ID: "sg", Type: "security_group", Properties: map[string]any{"ingress": []any{
+				map[string]any{"from_port": 0.0, "to_port": 0.0, "protocol": "-1", "cidr_blocks": []any{"0.0.0.0/0"}},
+			}}},
+			want: []string{"warning sg-open-admin-port properties/ingress/0: ingress[0] opens port 22 (SSH) and 3389 (RDP) to 0.0.0.0/0"},
+		},
+		{
+			name: "ssh from a private range",
+			node: diagram.Node{ID: "sg", Type: "security_group", Properties: map[string]any{"ingress": []any{
+				map[string]any{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []any{"10.0.0.0/16"}},
+			}}},
+		},
+		{
+			name: "egress is not checked",
+			node: diagram.Node{ID: "sg", Type: "security_group", Properties: map[string]any{"egress": []any{
+				map[string]any{"from_port": 0.0, "to_port": 0.0, "protocol": "-1", "cidr_blocks": []any{"0.0.0.0/0"}},
+			}}},
+		},
+		{
+			name: "bare RDS outside production",
+			env:  "dev",
+			node: diagram.Node{ID: "db", Type: "rds_instance", Properties: map[string]any{"engine": "postgres", "password": "hunter2"}},
+			want: []string{
+				"warning rds-plaintext-password properties/password: master password is stored in plain text in the diagram",
+				"warning rds-unencrypted : storage is not encrypted at rest",
+			},
+		},
+		{
+			name: "bare RDS in production",
+			env:  "Production",
+			node: diagram.Node{ID: "db", Type: "rds_instance", Properties: map[string]any{"engine": "postgres", "multi_az": false}},
+			want: []string{
+				"warning rds-no-backups : automated backups are disabled in a production environment",
+				"warning rds-single-az properties/multi_az: instance runs in a single availability zone in a production environment",
+				"warning rds-unencrypted : storage is not encrypted at rest",
+			},
+		},
+		{
+			name: "hardened RDS in production",
+			env:  "production",
+			node: diagram.Node{ID: "db", Type: "rds_instance", Properties: secureRDS},
+		},
+		{
+			name: "bare bucket",
+			node: diagram.Node{ID: "b", Type: "s3_bucket", Properties: map[string]any{"block_public_acls": true}},
+			want: []string{
+				"warning s3-public-access : bucket can be made public: block_public_policy not set",
+				"info s3-unencrypted : bucket has no default server-side encryption configured",
+			},
+		},
+		{
+			name: "hardened bucket",
+			node: diagram.Node{ID: "b", Type: "s3_bucket", Properties: map[string]any{
+				"block_public_acls": true, "block_public_policy": true, "sse_algorithm": "AES256",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := findings(t, tt.env, tt.node, Config{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunConfigAndSuppress(t *testing.T) {
+	bucket := diagram.Node{ID: "b", Type: "s3_bucket", Properties: map[string]any{"block_public_acls": true, "block_public_policy": true}}
+
+	cfg := Config{Rules: map[string]Severity{"s3-unencrypted": SeverityError}}
+	want := []string{"error s3-unencrypted : bucket has no default server-side encryption configured"}
+	if got := findings(t, "", bucket, cfg); !reflect.DeepEqual(got, want) {
+		t.Errorf("raised severity: got %q, want %q", got, want)
+	}
+
+	cfg = Config{Rules: map[string]Severity{"s3-unencrypted": SeverityOff}}
+	if got := findings(t, "", bucket, cfg); len(got) != 0 {
+		t.Errorf("rule off: got %q", got)
+	}
+
+	bucket.Suppress = []string{"s3-unencrypted", "s3-unencypted"}
+	want = []string{`warning  suppress/1: suppress lists unknown rule "s3-unencypted"`}
+	if got := findings(t, "", bucket, Config{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("suppressed: got %q, want %q", got, want)
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"rules": {"rds-single-az": "off", "sg-open-admin-port": "error"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Rules["sg-open-admin-port"] != SeverityError || cfg.Rules["rds-single-az"] != SeverityOff {
+		t.Errorf("rules = %v", cfg.Rules)
+	}
+
+	for input, want := range map[string]string{
+		`{"rules": {"sg-open-ssh": "error"}}`:      `unknown rule "sg-open-ssh"`,
+		`{"rules": {"rds-single-az": "critical"}}`: `unknown severity "critical"`,
+		`{"rule": {"rds-single-az": "off"}}`:       `unknown field "rule"`,
+		`{"rules": {"rds-single-az": "off"}`:       "invalid rules file",
+	} {
+		if _, err := ParseConfig([]byte(input)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseConfig(%s) error = %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestRulesSortedAndDocumented(t *testing.T) {
+	ids := ruleIDs()
+	if !sort.StringsAreSorted(ids) {
+		t.Errorf("rules not sorted by id: %v", ids)
+	}
+	for _, r := range Rules() {
+		if r.Summary == "" || r.Suggestion == "" || !r.Severity.valid() || r.Severity == SeverityOff || len(r.Types) == 0 {
+			t.Errorf("rule %s is incomplete: %+v", r.ID, r)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/handler"
+)
+
+// rules are the built-in rules, ordered by id.
+var rules = []*Rule{
+	{
+		ID: "rds-no-backups", Severity: SeverityWarning, Types: []string{"rds_instance"},
+		Summary:    "Production RDS instance without automated backups",
+		Suggestion: "Set backup_retention_period to at least 7 days",
+		check:      rdsNoBackups,
+	},
+	{
+		ID: "rds-plaintext-password", Severity: SeverityWarning, Types: []string{"rds_instance"},
+		Summary:    "RDS master password kept in plain text in the diagram",
+		Suggestion: "Remove password and set generate_password to keep a generated password in Secrets Manager",
+		check:      rdsPlaintextPassword,
+	},
+	{
+		ID: "rds-single-az", Severity: SeverityWarning, Types: []string{"rds_instance"},
+		Summary:    "Production RDS instance without a Multi-AZ standby",
+		Suggestion: "Set multi_az to true so the database fails over to another availability zone",
+		check:      rdsSingleAZ,
+	},
+	{
+		ID: "rds-unencrypted", Severity: SeverityWarning, Types: []string{"rds_instance"},
+		Summary:    "RDS storage not encrypted at rest",
+		Suggestion: "Set storage_encrypted to true; it cannot be changed after the instance is created",
+		check:      rdsUnencrypted,
+	},
+	{
+		ID: "s3-public-access", Severity: SeverityWarning, Types: []string{"s3_bucket"},
+		Summary:    "S3 bucket does not block public access",
+		Suggestion: "Set block_public_acls and block_public_policy to true unless the bucket must be public",
+		check:      s3PublicAccess,
+	},
+	{
+		// S3 has encrypted new objects with S3-managed keys by default since 2023, so this is informational.
+		ID: "s3-unencrypted", Severity: SeverityInfo, Types: []string{"s3_bucket"},
+		Summary:    "S3 bucket without explicit default encryption",
+		Suggestion: "Set sse_algorithm to AES256 or aws:kms",
+		check:      s3Unencrypted,
+	},
+	{
+		ID: "sg-open-admin-port", Severity: SeverityWarning, Types: []string{"security_group"},
+		Summary:    "Security group opens SSH or RDP to the internet",
+		Suggestion: "Restrict the rule to known CIDRs (e.g. a VPN or bastion range) or use SSM Session Manager instead",
+		check:      sgOpenAdminPort,
+	},
+}
+
+// propertyPath points at the property when the node sets it, and at the node otherwise.
+func propertyPath(n *diagram.Node, name string) string {
+	if _, ok := n.Properties[name]; ok {
+		return "properties/" + name
+	}
+	return ""
+}
+
+func rdsNoBackups(d *diagram.Diagram, n *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.RDSProps)
+	if !ok {
+		return nil
+	}
+	if !d.Metadata.IsProduction() || p.BackupRetentionPeriod > 0 {
+		return nil
+	}
+	return []finding{{propertyPath(n, "backup_retention_period"), "automated backups are disabled in a production environment"}}
+}
+
+func rdsPlaintextPassword(_ *diagram.Diagram, _ *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.RDSProps)
+	if !ok {
+		return nil
+	}
+	if p.Password == "" {
+		return nil
+	}
+	return []finding{{"properties/password", "master password is stored in plain text in the diagram"}}
+}
+
+func rdsSingleAZ(d *diagram.Diagram, n *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.RDSProps)
+	if !ok {
+		return nil
+	}
+	if !d.Metadata.IsProduction() || p.MultiAZ {
+		return nil
+	}
+	return []finding{{propertyPath(n, "multi_az"), "instance runs in a single availability zone in a production environment"}}
+}
+
+func rdsUnencrypted(_ *diagram.Diagram, n *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.RDSProps)
+	if !ok {
+		return nil
+	}
+	if p.StorageEncrypted {
+		return nil
+	}
+	return []finding{{propertyPath(n, "storage_encrypted"), "storage is not encrypted at rest"}}
+}
+
+func s3PublicAccess(_ *diagram.Diagram, _ *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.S3Props)
+	if !ok {
+		return nil
+	}
+	var open []string
+	if !p.BlockPublicACLs {
+		open = append(open, "block_public_acls")
+	}
+	if !p.BlockPublicPolicy {
+		open = append(open, "block_public_policy")
+	}
+	if len(open) == 0 {
+		return nil
+	}
+	return []finding{{"", "bucket can be made public: " + strings.Join(open, " and ") + " not set"}}
+}
+
+func s3Unencrypted(_ *diagram.Diagram, _ *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.S3Props)
+	if !ok {
+		return nil
+	}
+	if p.SSEAlgorithm != "" {
+		return nil
+	}
+	return []finding{{"", "bucket has no default server-side encryption configured"}}
+}
+
+// adminPorts are the remote administration ports that must not be reachable from anywhere.
+var adminPorts = []struct {
+	port int
+	name string
+}{{22, "SSH"}, {3389, "RDP"}}
+
+func sgOpenAdminPort(_ *diagram.Diagram, _ *diagram.Node, props any) []finding {
+	p, ok := props.(*handler.SecurityGroupProps)
+	if !ok {
+		return nil
+	}
+	var out []finding
+	for i, r := range p.Ingress {
+		cidr := anywhere(r.CIDRBlocks)
+		if cidr == "" {
+			continue
+		}
+		var open []string
+		for _, a := range adminPorts {
+			if covers(r, a.port) {
+				open = append(open, fmt.Sprintf("%d (%s)", a.port, a.name))
+			}
+		}
+		if len(open) > 0 {
+			out = append(out, finding{
+				path:    fmt.Sprintf("properties/ingress/%d", i),
+				message: fmt.Sprintf("ingress[%d] opens port %s to %s", i, strings.Join(open, " and "), cidr),
+			})
+		}
+	}
+	return out
+}
+
+// anywhere returns the first CIDR that matches every address, or "".
+func anywhere(cidrs []string) string {
+	for _, c := range cidrs {
+		if c == "0.0.0.0/0" || c == "::/0" {
+			return c
+		}
+	}
+	return ""
+}
+
+// covers reports whether an ingress rule admits TCP traffic to port.
+func covers(r handler.SGRule, port int) bool {
+	switch strings.ToLower(r.Protocol) {
+	case "-1", "all":
+		return true
+	case "tcp", "6":
+		return r.FromPort <= port && port <= r.ToPort
+	}
+	return false
+}
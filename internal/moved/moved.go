@@ -0,0 +1,204 @@
+// Package moved keeps Terraform resource addresses stable across diagram edits. A Snapshot
+// records which node produced which resource address; comparing the previous generation's
+// snapshot with the current one yields the moved blocks that tell Terraform a resource was
+// renamed rather than replaced.
+//
+// A node is matched with its previous version by id or, when its id changed, by type and
+// properties (a unique node with identical properties). Its resources are then paired by
+// resource type: when exactly one address of a type disappeared and one appeared, the
+// resource moved. This covers renamed nodes, a changed terraform_name, and handler changes
+// that rename a resource. Terraform cannot move a resource to a different type, so such
+// refactors are not covered.
+package moved
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+// Snapshot is what one generation produced.
+type Snapshot struct {
+	Nodes     []Node            `json:"nodes"`
+	Resources map[string]string `json:"resources"` // resource address -> node ID
+	// Moved are the moves still in effect: earlier ones are kept as long as their
+	// destination exists, so states that were not applied in between still migrate.
+	Moved []Move `json:"moved,omitempty"`
+}
+
+// Node identifies a node of the diagram independently of its id.
+type Node struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"` // hash of the properties, terraform_name excluded
+}
+
+// Move is one moved block.
+type Move struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	NodeID string `json:"node_id"`
+}
+
+// Take records the nodes of d and the owners (resource address -> node ID) of the
+// generated resources.
+func Take(d *diagram.Diagram, owners map[string]string) *Snapshot {
+	s := &Snapshot{Nodes: make([]Node, 0, len(d.Nodes)), Resources: make(map[string]string, len(owners))}
+	for _, n := range d.Nodes {
+		s.Nodes = append(s.Nodes, Node{ID: n.ID, Type: n.Type, Fingerprint: fingerprint(n.Properties)})
+	}
+	for addr, id := range owners {
+		s.Resources[addr] = id
+	}
+	return s
+}
+
+func fingerprint(props map[string]any) string {
+	p := make(map[string]any, len(props))
+	for k, v := range props {
+		if k != "terraform_name" {
+			p[k] = v
+		}
+	}
+	data, _ := json.Marshal(p) // map keys are sorted
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Resolve returns the moves from prev to cur: the still valid moves of prev followed by new
+// ones in the order of cur's nodes and, per node, of the destination address.
+func Resolve(prev, cur *Snapshot) []Move {
+	declared := func(addr string) bool { _, ok := cur.Resources[addr]; return ok }
+
+	identity := match(prev, cur)
+	byNode := func(s *Snapshot) map[string][]string {
+		out := make(map[string][]string)
+		for addr, id := range s.Resources {
+			out[id] = append(out[id], addr)
+		}
+		return out
+	}
+	prevByNode, curByNode := byNode(prev), byNode(cur)
+	var moves []Move
+	for _, n := range cur.Nodes {
+		oldID, ok := identity[n.ID]
+		if !ok {
+			continue
+		}
+		gone := make(map[string][]string) // resource type -> addresses no longer generated
+		for _, addr := range prevByNode[oldID] {
+			if !declared(addr) {
+				gone[resourceType(addr)] = append(gone[resourceType(addr)], addr)
+			}
+		}
+		added := make(map[string][]string)
+		for _, addr := range curByNode[n.ID] {
+			if _, existed := prev.Resources[addr]; !existed {
+				added[resourceType(addr)] = append(added[resourceType(addr)], addr)
+			}
+		}
+		var nodeMoves []Move
+		for typ, from := range gone {
+			if to := added[typ]; len(from) == 1 && len(to) == 1 {
+				nodeMoves = append(nodeMoves, Move{From: from[0], To: to[0], NodeID: n.ID})
+			}
+		}
+		sort.Slice(nodeMoves, func(i, j int) bool { return nodeMoves[i].To < nodeMoves[j].To })
+		moves = append(moves, nodeMoves...)
+	}
+
+	// Earlier moves stay while the chain they start, through the new moves, ends at a
+	// current resource; a state that was not applied in between then still migrates.
+	next := make(map[string]string)
+	for _, m := range prev.Moved {
+		next[m.From] = m.To
+	}
+	for _, m := range moves {
+		next[m.From] = m.To
+	}
+	var kept []Move
+	for _, m := range prev.Moved {
+		if declared(m.From) || next[m.From] != m.To {
+			continue
+		}
+		end := m.To
+		for i := 0; i < len(next) && !declared(end); i++ {
+			to, ok := next[end]
+			if !ok {
+				break
+			}
+			end = to
+		}
+		if declared(end) {
+			kept = append(kept, m)
+		}
+	}
+	return append(kept, moves...)
+}
+
+// match maps the ids of cur's nodes to the ids of the same nodes in prev.
+func match(prev, cur *Snapshot) map[string]string {
+	out := make(map[string]string)
+	prevIDs := make(map[string]bool, len(prev.Nodes))
+	for _, n := range prev.Nodes {
+		prevIDs[n.ID] = true
+	}
+	curIDs := make(map[string]bool, len(cur.Nodes))
+	for _, n := range cur.Nodes {
+		curIDs[n.ID] = true
+		if prevIDs[n.ID] {
+			out[n.ID] = n.ID
+		}
+	}
+
+	// Renamed nodes: pair removed and added nodes whose type and properties are unique among them.
+	key := func(n Node) string { return n.Type + "/" + n.Fingerprint }
+	removed := make(map[string][]string)
+	for _, n := range prev.Nodes {
+		if !curIDs[n.ID] {
+			removed[key(n)] = append(removed[key(n)], n.ID)
+		}
+	}
+	added := make(map[string][]string)
+	for _, n := range cur.Nodes {
+		if !prevIDs[n.ID] {
+			added[key(n)] = append(added[key(n)], n.ID)
+		}
+	}
+	for k, ids := range added {
+		if old := removed[k]; len(ids) == 1 && len(old) == 1 {
+			out[ids[0]] = old[0]
+		}
+	}
+	return out
+}
+
+func resourceType(addr string) string {
+	typ, _, _ := strings.Cut(addr, ".")
+	return typ
+}
+
+// HCL renders moves as moved blocks (moved.tf).
+func HCL(moves []Move) []byte {
+	f := hclwrite.NewEmptyFile()
+	for i, m := range moves {
+		if i > 0 {
+			f.Body().AppendNewline()
+		}
+		b := f.Body().AppendNewBlock("moved", nil).Body()
+		b.SetAttributeTraversal("from", traversal(m.From))
+		b.SetAttributeTraversal("to", traversal(m.To))
+	}
+	return f.Bytes()
+}
+
+func traversal(addr string) hcl.Traversal {
+	typ, name, _ := strings.Cut(addr, ".")
+	return hcl.Traversal{hcl.TraverseRoot{Name: typ}, hcl.TraverseAttr{Name: name}}
+}
@@ -0,0 +1,127 @@
+package moved
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func snapshot(nodes []diagram.Node, owners map[string]string) *Snapshot {
+	return Take(&diagram.Diagram{Nodes: nodes}, owners)
+}
+
+func TestResolve(t *testing.T) {
+	bucket := map[string]any{"bucket": "assets"}
+	prev := snapshot(
+		[]diagram.Node{
+			{ID: "s3-data", Type: "s3_bucket", Properties: bucket},
+			{ID: "vpc-main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		},
+		map[string]string{
+			"aws_s3_bucket.s3_data":                     "s3-data",
+			"aws_s3_bucket_public_access_block.s3_data": "s3-data",
+			"aws_vpc.vpc_main":                          "vpc-main",
+		},
+	)
+
+	tests := []struct {
+		name   string
+		nodes  []diagram.Node
+		owners map[string]string
+		want   []Move
+	}{
+		{
+			name:   "unchanged",
+			nodes:  []diagram.Node{{ID: "s3-data", Type: "s3_bucket", Properties: bucket}},
+			owners: map[string]string{"aws_s3_bucket.s3_data": "s3-data", "aws_s3_bucket_public_access_block.s3_data": "s3-data"},
+		},
+		{
+			name:  "renamed node",
+			nodes: []diagram.Node{{ID: "assets", Type: "s3_bucket", Properties: bucket}},
+			owners: map[string]string{
+				"aws_s3_bucket.assets":                     "assets",
+				"aws_s3_bucket_public_access_block.assets": "assets",
+			},
+			want: []Move{
+				{From: "aws_s3_bucket.s3_data", To: "aws_s3_bucket.assets", NodeID: "assets"},
+				{From: "aws_s3_bucket_public_access_block.s3_data", To: "aws_s3_bucket_public_access_block.assets", NodeID: "assets"},
+			},
+		},
+		{
+			name:   "terraform_name set",
+			nodes:  []diagram.Node{{ID: "vpc-main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16", "terraform_name": "main"}}},
+			owners: map[string]string{"aws_vpc.main": "vpc-main"},
+			want:   []Move{{From: "aws_vpc.vpc_main", To: "aws_vpc.main", NodeID: "vpc-main"}},
+		},
+		{
+			name:   "renamed and changed",
+			nodes:  []diagram.Node{{ID: "assets", Type: "s3_bucket", Properties: map[string]any{"bucket": "other"}}},
+			owners: map[string]string{"aws_s3_bucket.assets": "assets"},
+		},
+		{
+			name: "ambiguous rename",
+			nodes: []diagram.Node{
+				{ID: "a", Type: "s3_bucket", Properties: bucket},
+				{ID: "b", Type: "s3_bucket", Properties: bucket},
+			},
+			owners: map[string]string{"aws_s3_bucket.a": "a", "aws_s3_bucket.b": "b"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Resolve(prev, snapshot(tc.nodes, tc.owners))
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Resolve() =\n%+v\nwant\n%+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveKeepsEarlierMoves(t *testing.T) {
+	vpc := map[string]any{"cidr_block": "10.0.0.0/16"}
+	prev := snapshot([]diagram.Node{{ID: "network", Type: "vpc", Properties: vpc}}, map[string]string{"aws_vpc.network": "network"})
+	prev.Moved = []Move{{From: "aws_vpc.vpc_main", To: "aws_vpc.network", NodeID: "network"}}
+
+	// Renamed again: the old move chains into the new one
+	cur := snapshot([]diagram.Node{{ID: "core", Type: "vpc", Properties: vpc}}, map[string]string{"aws_vpc.core": "core"})
+	want := []Move{
+		{From: "aws_vpc.vpc_main", To: "aws_vpc.network", NodeID: "network"},
+		{From: "aws_vpc.network", To: "aws_vpc.core", NodeID: "core"},
+	}
+	if got := Resolve(prev, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %+v, want %+v", got, want)
+	}
+
+	// Back to the original name: the old move would point away from a declared resource
+	cur = snapshot([]diagram.Node{{ID: "network", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16", "terraform_name": "vpc_main"}}}, map[string]string{"aws_vpc.vpc_main": "network"})
+	want = []Move{{From: "aws_vpc.network", To: "aws_vpc.vpc_main", NodeID: "network"}}
+	if got := Resolve(prev, cur); !reflect.DeepEqual(got, want) {
+		t.Errorf("Resolve() = %+v, want %+v", got, want)
+	}
+
+	// Node removed: nothing is left to move to
+	if got := Resolve(prev, snapshot(nil, nil)); len(got) != 0 {
+		t.Errorf("Resolve() = %+v, want no moves", got)
+	}
+}
+
+func TestHCL(t *testing.T) {
+	got := string(HCL([]Move{
+		{From: "aws_vpc.vpc_main", To: "aws_vpc.main"},
+		{From: "aws_s3_bucket.a", To: "aws_s3_bucket.b"},
+	}))
+	want := `moved {
+  from = aws_vpc.vpc_main
+  to   = aws_vpc.main
+}
+
+moved {
+  from = aws_s3_bucket.a
+  to   = aws_s3_bucket.b
+}
+`
+	if got != want {
+		t.Errorf("HCL() =\n%s\nwant\n%s", got, want)
+	}
+}
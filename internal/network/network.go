@@ -0,0 +1,150 @@
+// Package network checks addressing across nodes, which no single handler can see:
+// VPC and subnet CIDR ranges and availability zones against the configured region.
+package network
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// AWS accepts VPC CIDR blocks from /16 (65,536 addresses) to /28 (16 addresses).
+const (
+	minVPCPrefix = 16
+	maxVPCPrefix = 28
+)
+
+// zoneShape is the availability zone format the subnet handler accepts.
+var zoneShape = regexp.MustCompile(`^[a-z]{2}(-gov)?-[a-z]+-[0-9][a-z]$`)
+
+// Validate checks the diagram's network layout. Problems are reported in diagram order with
+// paths relative to the offending node. Malformed or missing properties are left to the
+// handlers, whose property schemas reg holds; nodes with such properties are skipped here.
+func Validate(d *diagram.Diagram, reg *registry.Registry) ([]result.Error, []result.Warning) {
+	v := &validator{d: d, reg: reg, region: d.Metadata.AWSRegion()}
+	vpcs := make(map[string]netip.Prefix)
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		if n.Type != "vpc" {
+			continue
+		}
+		if p, ok := v.cidr(n); ok {
+			v.checkVPCSize(n, p)
+			vpcs[n.ID] = p
+		}
+	}
+
+	type subnet struct {
+		id     string
+		prefix netip.Prefix
+	}
+	siblings := make(map[string][]subnet) // VPC node ID -> subnets seen so far
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		if n.Type != "subnet" {
+			continue
+		}
+		v.checkZone(n)
+		p, ok := v.cidr(n)
+		vpcID := containingVPC(d, n.ID)
+		if !ok || vpcID == "" {
+			continue
+		}
+		if vp, ok := vpcs[vpcID]; ok && !(vp.Bits() <= p.Bits() && vp.Contains(p.Addr())) {
+			v.errorf(n, "properties/cidr_block", "Choose a range inside "+vp.String(),
+				"subnet cidr_block %s is not inside VPC %s (%s)", p, vpcID, vp)
+		}
+		for _, s := range siblings[vpcID] {
+			if s.prefix.Overlaps(p) {
+				v.errorf(n, "properties/cidr_block", "Give each subnet in a VPC its own, non-overlapping range",
+					"subnet cidr_block %s overlaps subnet %s (%s) in VPC %s", p, s.id, s.prefix, vpcID)
+			}
+		}
+		siblings[vpcID] = append(siblings[vpcID], subnet{id: n.ID, prefix: p})
+	}
+	return v.errs, v.warns
+}
+
+type validator struct {
+	d      *diagram.Diagram
+	reg    *registry.Registry
+	region string
+	errs   []result.Error
+	warns  []result.Warning
+}
+
+func (v *validator) errorf(n *diagram.Node, path, suggestion, format string, args ...any) {
+	v.errs = append(v.errs, result.Error{
+		Type: "validation_error", Severity: "error", NodeID: n.ID, Path: path,
+		Message: fmt.Sprintf(format, args...), Suggestion: suggestion,
+	})
+}
+
+// cidr parses the node's cidr_block. It reports values that pass the handler's schema but
+// are not usable IPv4 networks, and returns the network when there is one.
+func (v *validator) cidr(n *diagram.Node) (netip.Prefix, bool) {
+	s, _ := n.Properties["cidr_block"].(string)
+	if s == "" {
+		return netip.Prefix{}, false
+	}
+	if ps, ok := v.reg.PropertySchema(n.Type); ok {
+		if cs := ps.Properties["cidr_block"]; cs != nil && len(cs.Validate(s)) > 0 {
+			return netip.Prefix{}, false // the handler reports the format
+		}
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		v.errorf(n, "properties/cidr_block", "Use an IPv4 network such as 10.0.0.0/16",
+			"cidr_block %q is not a valid IPv4 CIDR", s)
+		return netip.Prefix{}, false
+	}
+	if p != p.Masked() {
+		v.errorf(n, "properties/cidr_block", "Use "+p.Masked().String(),
+			"cidr_block %s has host bits set", s)
+	}
+	return p.Masked(), true
+}
+
+func (v *validator) checkVPCSize(n *diagram.Node, p netip.Prefix) {
+	if p.Bits() >= minVPCPrefix && p.Bits() <= maxVPCPrefix {
+		return
+	}
+	size := "large"
+	if p.Bits() > maxVPCPrefix {
+		size = "small"
+	}
+	v.errorf(n, "properties/cidr_block", fmt.Sprintf("Use a prefix length between /%d and /%d", minVPCPrefix, maxVPCPrefix),
+		"VPC cidr_block %s is too %s; AWS allows /%d to /%d", p, size, minVPCPrefix, maxVPCPrefix)
+}
+
+// checkZone reports an availability zone that does not belong to the configured region.
+// Zone names are the region followed by one letter (us-east-1a).
+func (v *validator) checkZone(n *diagram.Node) {
+	az, _ := n.Properties["availability_zone"].(string)
+	if !zoneShape.MatchString(az) {
+		return // absent, or malformed and reported by the handler
+	}
+	if az[:len(az)-1] == v.region {
+		return
+	}
+	v.errorf(n, "properties/availability_zone",
+		fmt.Sprintf("Use a zone of %s (e.g. %sa), or set metadata.region to the zone's region", v.region, v.region),
+		"availability_zone %s is not in region %s", az, v.region)
+}
+
+// containingVPC returns the ID of the VPC with a contains edge to the node, or "".
+func containingVPC(d *diagram.Diagram, id string) string {
+	for _, e := range d.EdgesWithTarget(id) {
+		if e.Type != "contains" {
+			continue
+		}
+		if src := d.NodeByID(e.Source); src != nil && src.Type == "vpc" {
+			return src.ID
+		}
+	}
+	return ""
+}
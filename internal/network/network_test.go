@@ -0,0 +1,126 @@
+package network
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	_ "github.com/json-to-terraform/parser/internal/handler" // the vpc and subnet schemas
+	"github.com/json-to-terraform/parser/internal/registry"
+)
+
+func vpc(id, cidr string) diagram.Node {
+	return diagram.Node{ID: id, Type: "vpc", Properties: map[string]any{"cidr_block": cidr}}
+}
+
+func subnet(id, cidr, az string) diagram.Node {
+	return diagram.Node{ID: id, Type: "subnet", Properties: map[string]any{"cidr_block": cidr, "availability_zone": az}}
+}
+
+func contains(vpcID string, subnetIDs ...string) []diagram.Edge {
+	var out []diagram.Edge
+	for _, id := range subnetIDs {
+		out = append(out, diagram.Edge{ID: vpcID + "-" + id, Source: vpcID, Target: id, Type: "contains"})
+	}
+	return out
+}
+
+func messages(t *testing.T, d *diagram.Diagram) []string {
+	t.Helper()
+	errs, _ := Validate(diagram.Normalize(d), registry.Default)
+	var out []string
+	for _, e := range errs {
+		if e.Path != "properties/cidr_block" && e.Path != "properties/availability_zone" {
+			t.Errorf("%s: path = %q, want a property path", e.NodeID, e.Path)
+		}
+		out = append(out, e.NodeID+": "+e.Message)
+	}
+	return out
+}
+
+func TestValidateCIDRs(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []diagram.Node
+		edges []diagram.Edge
+		want  []string
+	}{
+		{
+			name:  "valid layout",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/16"), subnet("a", "10.0.1.0/24", "us-east-1a"), subnet("b", "10.0.2.0/24", "us-east-1b")},
+			edges: contains("v", "a", "b"),
+		},
+		{
+			name:  "subnet outside its VPC",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/16"), subnet("a", "10.1.0.0/24", "us-east-1a")},
+			edges: contains("v", "a"),
+			want:  []string{"a: subnet cidr_block 10.1.0.0/24 is not inside VPC v (10.0.0.0/16)"},
+		},
+		{
+			name:  "subnet larger than its VPC",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/16"), subnet("a", "10.0.0.0/8", "us-east-1a")},
+			edges: contains("v", "a"),
+			want:  []string{"a: subnet cidr_block 10.0.0.0/8 is not inside VPC v (10.0.0.0/16)"},
+		},
+		{
+			name:  "overlapping siblings",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/16"), subnet("a", "10.0.0.0/23", "us-east-1a"), subnet("b", "10.0.1.0/24", "us-east-1b")},
+			edges: contains("v", "a", "b"),
+			want:  []string{"b: subnet cidr_block 10.0.1.0/24 overlaps subnet a (10.0.0.0/23) in VPC v"},
+		},
+		{
+			name: "same range in different VPCs",
+			nodes: []diagram.Node{vpc("v1", "10.0.0.0/16"), vpc("v2", "10.0.0.0/16"),
+				subnet("a", "10.0.1.0/24", "us-east-1a"), subnet("b", "10.0.1.0/24", "us-east-1a")},
+			edges: append(contains("v1", "a"), contains("v2", "b")...),
+		},
+		{
+			name:  "VPC too large",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/8")},
+			want:  []string{"v: VPC cidr_block 10.0.0.0/8 is too large; AWS allows /16 to /28"},
+		},
+		{
+			name:  "VPC too small",
+			nodes: []diagram.Node{vpc("v", "10.0.0.0/29")},
+			want:  []string{"v: VPC cidr_block 10.0.0.0/29 is too small; AWS allows /16 to /28"},
+		},
+		{
+			name:  "host bits set",
+			nodes: []diagram.Node{vpc("v", "10.0.0.1/16")},
+			want:  []string{"v: cidr_block 10.0.0.1/16 has host bits set"},
+		},
+		{
+			name:  "out of range octet",
+			nodes: []diagram.Node{vpc("v", "10.0.300.0/16")},
+			want:  []string{`v: cidr_block "10.0.300.0/16" is not a valid IPv4 CIDR`},
+		},
+		{
+			name:  "malformed values are left to the handler",
+			nodes: []diagram.Node{vpc("v", "2001:db8::/56")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messages(t, &diagram.Diagram{Nodes: tt.nodes, Edges: tt.edges})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateZoneRegion(t *testing.T) {
+	nodes := []diagram.Node{subnet("a", "10.0.1.0/24", "eu-west-1a"), subnet("b", "10.0.2.0/24", "us-west-2b")}
+
+	got := messages(t, &diagram.Diagram{Nodes: nodes})
+	want := []string{"a: availability_zone eu-west-1a is not in region us-east-1", "b: availability_zone us-west-2b is not in region us-east-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default region: got %q, want %q", got, want)
+	}
+
+	got = messages(t, &diagram.Diagram{Metadata: diagram.Metadata{Region: "eu-west-1"}, Nodes: nodes})
+	want = []string{"b: availability_zone us-west-2b is not in region eu-west-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("metadata.region eu-west-1: got %q, want %q", got, want)
+	}
+}
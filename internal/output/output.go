@@ -0,0 +1,291 @@
+// Package output writes generated files into an output directory without losing hand edits.
+//
+// A manifest (.json2tf-manifest.json) records a hash of every file the generator wrote.
+// On the next run a file whose hash still matches is regenerated, one that was edited by
+// hand is a conflict, and a file that is no longer generated is removed. Files the
+// manifest does not list, such as Terraform's *_override.tf files, are never touched.
+//
+// Inside generated files, custom regions survive regeneration:
+//
+//	# json2tf:custom-begin monitoring
+//	resource "aws_cloudwatch_metric_alarm" "cpu" { ... }
+//	# json2tf:custom-end monitoring
+//
+// Regions are excluded from the hash and appended, in their original order, to the end of
+// the regenerated file, so they should contain whole top-level blocks.
+//
+// The manifest also stores the generation's moved.Snapshot, so the next run can declare
+// moved blocks for resources whose address changed.
+package output
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/moved"
+)
+
+// ManifestName is the manifest's file name in the output directory.
+const ManifestName = ".json2tf-manifest.json"
+
+// Custom region markers; the text after the marker names the region.
+const (
+	customBegin = "# json2tf:custom-begin"
+	customEnd   = "# json2tf:custom-end"
+)
+
+// Manifest lists the generated files and the hash of their generated content.
+type Manifest struct {
+	Version int               `json:"version"`
+	Files   map[string]string `json:"files"` // file name -> "sha256:<hex>"
+	// Snapshot maps the nodes of the last generation to their resource addresses.
+	Snapshot *moved.Snapshot `json:"snapshot,omitempty"`
+}
+
+// Actions on files.
+const (
+	Create    = "create"
+	Update    = "update"
+	Unchanged = "unchanged"
+	Delete    = "delete"
+	Conflict  = "conflict"
+)
+
+// FileChange is what happens to one file.
+type FileChange struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Changeset is the set of changes needed to bring a directory up to date.
+type Changeset struct {
+	Dir     string
+	Changes []FileChange // generated files in name order, then stale ones
+
+	content  map[string][]byte
+	manifest Manifest
+}
+
+// Prepare compares the generated files with the contents of dir and returns the changes
+// Apply would make. Without force, a generated file that was edited outside custom regions,
+// or that exists but was not written by the generator, is a Conflict and is left alone;
+// with force it is overwritten (custom regions are still kept) or, if stale, deleted.
+func Prepare(dir string, files map[string][]byte, force bool) (*Changeset, error) {
+	old, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	cs := &Changeset{
+		Dir:      dir,
+		content:  make(map[string][]byte),
+		manifest: Manifest{Version: 1, Files: make(map[string]string), Snapshot: old.Snapshot},
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		generated := files[name]
+		cs.manifest.Files[name] = hash(generated)
+		existing, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			cs.content[name] = generated
+			cs.Changes = append(cs.Changes, FileChange{Name: name, Action: Create})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		body, regions, err := splitRegions(existing)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Join(dir, name), err)
+		}
+		merged := generated
+		if len(regions) > 0 {
+			merged = append(append(bytes.TrimRight(append([]byte(nil), generated...), "\n"), "\n\n"...), regions...)
+		}
+		c := FileChange{Name: name, Action: Update}
+		switch recorded, ok := old.Files[name]; {
+		case !ok && hash(body) != hash(generated):
+			c.Action, c.Reason = Conflict, "exists but was not generated by json2tf"
+		case ok && hash(body) != recorded:
+			c.Action, c.Reason = Conflict, "edited outside custom regions since it was generated"
+		case bytes.Equal(existing, merged):
+			c.Action = Unchanged
+		}
+		if c.Action == Conflict && force {
+			c.Action, c.Reason = Update, "overwrites manual changes"
+		}
+		if c.Action == Conflict {
+			// Keep the recorded hash so the file is still recognized once the conflict is resolved.
+			if recorded, ok := old.Files[name]; ok {
+				cs.manifest.Files[name] = recorded
+			} else {
+				delete(cs.manifest.Files, name)
+			}
+		} else {
+			cs.content[name] = merged
+		}
+		cs.Changes = append(cs.Changes, c)
+	}
+
+	stale := make([]string, 0, len(old.Files))
+	for name := range old.Files {
+		if _, ok := files[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+	sort.Strings(stale)
+	for _, name := range stale {
+		existing, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		body, regions, err := splitRegions(existing)
+		c := FileChange{Name: name, Action: Delete, Reason: "no longer generated"}
+		switch {
+		case err != nil || hash(body) != old.Files[name]:
+			c.Action, c.Reason = Conflict, "no longer generated but edited by hand"
+		case len(regions) > 0:
+			c.Action, c.Reason = Conflict, "no longer generated but has custom regions"
+		}
+		if c.Action == Conflict {
+			if !force {
+				cs.manifest.Files[name] = old.Files[name]
+				cs.Changes = append(cs.Changes, c)
+				continue
+			}
+			c.Action, c.Reason = Delete, "no longer generated; discards manual changes"
+		}
+		cs.Changes = append(cs.Changes, c)
+	}
+	return cs, nil
+}
+
+// Conflicts returns the changes that need attention before the directory can be updated.
+func (cs *Changeset) Conflicts() []FileChange {
+	var out []FileChange
+	for _, c := range cs.Changes {
+		if c.Action == Conflict {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Record stores the snapshot of the generation in the manifest written by Apply.
+func (cs *Changeset) Record(s *moved.Snapshot) {
+	cs.manifest.Snapshot = s
+}
+
+// Apply writes created and updated files, deletes stale ones and rewrites the manifest.
+// Conflicting files are left as they are.
+func (cs *Changeset) Apply() error {
+	if err := os.MkdirAll(cs.Dir, 0755); err != nil {
+		return err
+	}
+	for _, c := range cs.Changes {
+		path := filepath.Join(cs.Dir, c.Name)
+		switch c.Action {
+		case Create, Update:
+			if err := os.WriteFile(path, cs.content[c.Name], 0644); err != nil {
+				return err
+			}
+		case Delete:
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	data, err := json.MarshalIndent(cs.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(cs.Dir, ManifestName), append(data, '\n'), 0644)
+}
+
+// ReadManifest reads the manifest of dir; a directory without one has an empty manifest.
+func ReadManifest(dir string) (Manifest, error) {
+	m := Manifest{Files: map[string]string{}}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", filepath.Join(dir, ManifestName), err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]string{}
+	}
+	return m, nil
+}
+
+// splitRegions separates the custom regions (marker lines included) from the rest of a file.
+func splitRegions(src []byte) (body, regions []byte, err error) {
+	var inRegion string
+	open := false
+	for i, line := range bytes.SplitAfter(src, []byte("\n")) {
+		trimmed := strings.TrimSpace(string(line))
+		switch {
+		case strings.HasPrefix(trimmed, customBegin):
+			if open {
+				return nil, nil, fmt.Errorf("line %d: custom region %q starts inside region %q", i+1, regionName(trimmed, customBegin), inRegion)
+			}
+			open, inRegion = true, regionName(trimmed, customBegin)
+			regions = append(regions, line...)
+		case strings.HasPrefix(trimmed, customEnd):
+			if !open || regionName(trimmed, customEnd) != inRegion {
+				return nil, nil, fmt.Errorf("line %d: custom region end %q does not match an open region", i+1, regionName(trimmed, customEnd))
+			}
+			open = false
+			regions = append(regions, line...)
+			if !bytes.HasSuffix(line, []byte("\n")) {
+				regions = append(regions, '\n')
+			}
+		case open:
+			regions = append(regions, line...)
+		default:
+			body = append(body, line...)
+		}
+	}
+	if open {
+		return nil, nil, fmt.Errorf("custom region %q is not closed", inRegion)
+	}
+	return body, regions, nil
+}
+
+func regionName(line, marker string) string {
+	return strings.TrimSpace(strings.TrimPrefix(line, marker))
+}
+
+// hash identifies generated content. Blank lines and trailing spaces are ignored, so the
+// space left around a custom region does not count as an edit.
+func hash(content []byte) string {
+	h := sha256.New()
+	for _, line := range bytes.Split(content, []byte("\n")) {
+		if line = bytes.TrimRight(line, " \t\r"); len(line) > 0 {
+			h.Write(line)
+			h.Write([]byte("\n"))
+		}
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
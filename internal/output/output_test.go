@@ -0,0 +1,159 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/moved"
+)
+
+func actions(cs *Changeset) []string {
+	var out []string
+	for _, c := range cs.Changes {
+		out = append(out, c.Action+" "+c.Name)
+	}
+	return out
+}
+
+func apply(t *testing.T, dir string, files map[string][]byte, force bool) *Changeset {
+	t.Helper()
+	cs, err := Prepare(dir, files, force)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	return cs
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRegenerate(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.tf")
+	v1 := map[string][]byte{
+		"main.tf":          []byte("resource \"aws_vpc\" \"a\" {}\n"),
+		"terraform.tfvars": []byte("aws_region = \"us-east-1\"\n"),
+	}
+	if got, want := actions(apply(t, dir, v1, false)), []string{"create main.tf", "create terraform.tfvars"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first run = %q, want %q", got, want)
+	}
+
+	// A custom region and an override file are user-owned.
+	region := "# json2tf:custom-begin alarms\nlocals {\n  x = 1\n}\n# json2tf:custom-end alarms\n"
+	if err := os.WriteFile(main, []byte(read(t, main)+"\n"+region), 0644); err != nil {
+		t.Fatal(err)
+	}
+	override := filepath.Join(dir, "main_override.tf")
+	if err := os.WriteFile(override, []byte("# mine\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	v2 := map[string][]byte{"main.tf": []byte("resource \"aws_vpc\" \"b\" {}\n")}
+	cs, err := Prepare(dir, v2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := actions(cs), []string{"update main.tf", "delete terraform.tfvars"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dry run = %q, want %q", got, want)
+	}
+	if !strings.Contains(read(t, main), `"a"`) {
+		t.Error("Prepare modified the directory")
+	}
+	if err := cs.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := read(t, main), "resource \"aws_vpc\" \"b\" {}\n\n"+region; got != want {
+		t.Errorf("main.tf =\n%s\nwant\n%s", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "terraform.tfvars")); !os.IsNotExist(err) {
+		t.Errorf("stale terraform.tfvars not removed: %v", err)
+	}
+	if read(t, override) != "# mine\n" {
+		t.Error("override file changed")
+	}
+	if got := actions(apply(t, dir, v2, false)); !reflect.DeepEqual(got, []string{"unchanged main.tf"}) {
+		t.Errorf("rerun = %q", got)
+	}
+}
+
+func TestRegenerateConflicts(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.tf")
+	files := map[string][]byte{"main.tf": []byte("resource \"aws_vpc\" \"a\" {}\n")}
+	apply(t, dir, files, false)
+	if err := os.WriteFile(main, []byte("resource \"aws_vpc\" \"edited\" {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	unmanaged := filepath.Join(dir, "versions.tf")
+	if err := os.WriteFile(unmanaged, []byte("terraform {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files["versions.tf"] = []byte("terraform {\n  required_version = \">= 1.0\"\n}\n")
+
+	cs := apply(t, dir, files, false)
+	if got := len(cs.Conflicts()); got != 2 {
+		t.Fatalf("conflicts = %+v, want main.tf and versions.tf", cs.Changes)
+	}
+	if !strings.Contains(read(t, main), "edited") || read(t, unmanaged) != "terraform {}\n" {
+		t.Error("conflicting files were overwritten")
+	}
+	// The edited file keeps its manifest entry, so it is still a conflict next time.
+	if cs, _ := Prepare(dir, files, false); len(cs.Conflicts()) != 2 {
+		t.Errorf("second run: %+v", cs.Changes)
+	}
+
+	apply(t, dir, files, true)
+	if read(t, main) != string(files["main.tf"]) || read(t, unmanaged) != string(files["versions.tf"]) {
+		t.Error("-force did not overwrite")
+	}
+}
+
+func TestManifestKeepsSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][]byte{"main.tf": []byte("resource \"aws_vpc\" \"main\" {}\n")}
+	snap := &moved.Snapshot{Resources: map[string]string{"aws_vpc.main": "vpc-main"}}
+
+	cs, err := Prepare(dir, files, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs.Record(snap)
+	if err := cs.Apply(); err != nil {
+		t.Fatal(err)
+	}
+	// A run that records nothing keeps the previous snapshot
+	apply(t, dir, files, false)
+
+	m, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Snapshot == nil || !reflect.DeepEqual(m.Snapshot.Resources, snap.Resources) {
+		t.Errorf("snapshot = %+v, want %+v", m.Snapshot, snap)
+	}
+}
+
+func TestSplitRegionsErrors(t *testing.T) {
+	for _, src := range []string{
+		"# json2tf:custom-begin a\n",
+		"# json2tf:custom-end a\n",
+		"# json2tf:custom-begin a\n# json2tf:custom-begin b\n",
+		"# json2tf:custom-begin a\n# json2tf:custom-end b\n",
+	} {
+		if _, _, err := splitRegions([]byte(src)); err == nil {
+			t.Errorf("splitRegions(%q) succeeded", src)
+		}
+	}
+}
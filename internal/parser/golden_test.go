@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files under testdata/golden")
+
+const testdataDir = "../../testdata"
+
+// TestGolden parses every testdata/*.json diagram and compares each generated file with
+// testdata/golden/<diagram>/<file>. Run with -update to regenerate the golden files.
+func TestGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join(testdataDir, "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no testdata diagrams found")
+	}
+	for _, in := range inputs {
+		name := strings.TrimSuffix(filepath.Base(in), ".json")
+		t.Run(name, func(t *testing.T) {
+			res := parseFile(t, in)
+			if !res.Success {
+				t.Fatalf("parse failed: %+v", res.Errors)
+			}
+			dir := filepath.Join(testdataDir, "golden", name)
+			if *update {
+				writeGolden(t, dir, res.TerraformFiles)
+				return
+			}
+			compareGolden(t, dir, res.TerraformFiles)
+		})
+	}
+}
+
+func parseFile(t *testing.T, path string) *result.ParseResult {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d diagram.Diagram
+	if err := json.Unmarshal(data, &d); err != nil {
+		t.Fatalf("unmarshal %s: %v", path, err)
+	}
+	opts := DefaultOptions()
+	opts.ValidateSchema = true
+	// complete_architecture is a production diagram with a plaintext RDS password, to cover
+	// the sensitive variable that replaces it
+	opts.AllowPlaintextSecrets = true
+	res, err := New(opts).Parse(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func writeGolden(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func compareGolden(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read golden dir (run with -update to create it): %v", err)
+	}
+	want := make(map[string][]byte)
+	for _, e := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want[e.Name()] = content
+	}
+	for name := range want {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s: expected file was not generated", name)
+		}
+	}
+	for name, got := range files {
+		exp, ok := want[name]
+		if !ok {
+			t.Errorf("%s: generated file has no golden counterpart", name)
+			continue
+		}
+		if !bytes.Equal(got, exp) {
+			t.Errorf("%s differs from golden file (run with -update to accept):\n--- want\n%s\n--- got\n%s", name, exp, got)
+		}
+	}
+}
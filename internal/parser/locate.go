@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// locate points every error and warning at the diagram: node-level problems without a
+// path get the node's pointer (/nodes/<index>), paths relative to the node (as handlers
+// report them, e.g. "properties/ami") are made absolute, and when the diagram was decoded with
+// diagram.Decode, line and column are filled from its recorded locations. Source map paths
+// are made absolute the same way.
+func locate(res *result.ParseResult, d *diagram.Diagram) {
+	if res == nil || d == nil {
+		return
+	}
+	g := d.Graph()
+	fill := func(nodeID string, path *string, line, col *int) {
+		if !strings.HasPrefix(*path, "/") && nodeID != "" {
+			if i, ok := g.Index(nodeID); ok {
+				node := fmt.Sprintf("/nodes/%d", i)
+				if *path != "" {
+					node += "/" + *path
+				}
+				*path = node
+			}
+		}
+		if *path == "" || d.Locations == nil {
+			return
+		}
+		if loc, ok := d.Locations.Locate(*path); ok {
+			*line, *col = loc.Line, loc.Column
+		}
+	}
+	for i := range res.Errors {
+		e := &res.Errors[i]
+		fill(e.NodeID, &e.Path, &e.Line, &e.Column)
+	}
+	for i := range res.Warnings {
+		w := &res.Warnings[i]
+		fill(w.NodeID, &w.Path, &w.Line, &w.Column)
+	}
+	for i := range res.SourceMap {
+		m := &res.SourceMap[i]
+		var line, col int
+		fill(m.NodeID, &m.Path, &line, &col)
+	}
+}
+
+// DecodeErrors converts an error from diagram.Decode into result errors of type
+// invalid_json, keeping each problem's path, line and column.
+func DecodeErrors(err error) []result.Error {
+	var decErrs diagram.DecodeErrors
+	if !errors.As(err, &decErrs) {
+		return []result.Error{{Type: "invalid_json", Severity: "error", Message: "invalid diagram JSON: " + err.Error()}}
+	}
+	out := make([]result.Error, len(decErrs))
+	for i, de := range decErrs {
+		out[i] = result.Error{
+			Type: "invalid_json", Severity: "error",
+			Message: de.Message, Path: de.Path, Line: de.Line, Column: de.Column,
+		}
+	}
+	return out
+}
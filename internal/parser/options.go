@@ -1,11 +1,50 @@
 package parser
 
+import (
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/moved"
+	"github.com/json-to-terraform/parser/internal/policy"
+	"github.com/json-to-terraform/parser/internal/tfcli"
+)
+
 // Options configures the parser behavior.
 type Options struct {
 	// EmitTfvars generates terraform.tfvars from diagram metadata when true.
 	EmitTfvars bool
-	// MaxParallel is the max number of nodes to process in parallel per tier (0 = default).
+	// MaxParallel is the max number of nodes to process in parallel per tier
+	// (0 = runtime.NumCPU, capped at 32).
 	MaxParallel int
+	// ValidateHCL re-parses generated files and checks that all references resolve.
+	ValidateHCL bool
+	// ValidateSchema also checks resource arguments against the bundled AWS provider schema.
+	ValidateSchema bool
+	// Lint adjusts the best-practice rules run after generation; the zero value runs all
+	// rules at their default severities.
+	Lint lint.Config
+	// Policies are CEL conditions evaluated against the plan model of the generated
+	// resources; nil skips the stage.
+	Policies *policy.Set
+	// AllowPlaintextSecrets accepts secret properties (such as an RDS password) in diagrams
+	// whose environment is production; they are rejected otherwise. Secret values are
+	// scrubbed from errors and warnings either way.
+	AllowPlaintextSecrets bool
+	// NameFromLabel derives resource names from node labels ("Web Server" -> web_server)
+	// instead of node ids; see terraform.LabelNames. A terraform_name property still wins.
+	NameFromLabel bool
+	// ImportNodes limits the import blocks in imports.tf to these node IDs; nil imports every
+	// node with an import_id. A listed node without an import_id is a validation_error.
+	ImportNodes []string
+	// NodeComments writes a "# node: <id> (<label>)" comment above every block generated
+	// from a node, so the files can be traced back to the diagram without the source map.
+	NodeComments bool
+	// Terraform runs the terraform (or tofu) CLI on the generated files as a last stage:
+	// fmt, init and validate, and plan when set. Its diagnostics become terraform_error
+	// errors and terraform_warning warnings on the node that produced the resource; nil
+	// skips the stage.
+	Terraform *tfcli.Runner
+	// Previous is the snapshot of the last generation (see moved). When set, resources whose
+	// address changed since then are declared in moved.tf instead of being replaced.
+	Previous *moved.Snapshot
 }
 
 // DefaultOptions returns default parser options.
@@ -13,5 +52,6 @@ func DefaultOptions() Options {
 	return Options{
 		EmitTfvars:  true,
 		MaxParallel: 0, // use runtime.NumCPU in parser
+		ValidateHCL: true,
 	}
 }
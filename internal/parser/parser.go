@@ -1,13 +1,22 @@
 package parser
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"runtime"
-	"sync"
+	"time"
 
+	"github.com/json-to-terraform/parser/internal/cost"
 	"github.com/json-to-terraform/parser/internal/dependency"
 	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/moved"
+	"github.com/json-to-terraform/parser/internal/network"
+	"github.com/json-to-terraform/parser/internal/plan"
 	"github.com/json-to-terraform/parser/internal/registry"
 	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/secrets"
 	"github.com/json-to-terraform/parser/internal/terraform"
 )
 
@@ -33,14 +42,42 @@ func New(opts Options) *InfrastructureParser {
 }
 
 // Parse validates the diagram, resolves dependencies, and generates Terraform files.
+// The diagram is not modified, so one diagram may be parsed concurrently.
 func (p *InfrastructureParser) Parse(d *diagram.Diagram) (*result.ParseResult, error) {
+	return p.ParseContext(context.Background(), d)
+}
+
+// ParseContext is like Parse but stops scheduling handlers once ctx is canceled or its
+// deadline passes; it then returns ctx.Err() and no result.
+func (p *InfrastructureParser) ParseContext(ctx context.Context, d *diagram.Diagram) (*result.ParseResult, error) {
+	res, err := p.parse(ctx, d)
+	locate(res, d)
+	secrets.Scrub(res, secrets.Find(d, p.reg))
+	return res, err
+}
+
+// nodeBlocks is the HCL a node generated.
+type nodeBlocks struct {
+	node *diagram.Node
+	hcl  []byte
+}
+
+// nodeResult is the outcome of running one handler.
+type nodeResult struct {
+	props any // decoded by the handler's Validate; the lint rules read it
+	hcl   []byte
+	errs  []result.Error
+	warns []result.Warning
+}
+
+func (p *InfrastructureParser) parse(ctx context.Context, d *diagram.Diagram) (*result.ParseResult, error) {
 	out := &result.ParseResult{Success: true}
 
 	// 1. Diagram-level validation
 	diagErrs := diagram.Validate(d)
 	for _, e := range diagErrs {
 		out.Errors = append(out.Errors, result.Error{
-			Type: e.Type, Severity: e.Severity, NodeID: e.NodeID,
+			Type: e.Type, Severity: e.Severity, NodeID: e.NodeID, Path: e.Path,
 			Message: e.Message, Suggestion: e.Suggestion,
 		})
 	}
@@ -48,11 +85,50 @@ func (p *InfrastructureParser) Parse(d *diagram.Diagram) (*result.ParseResult, e
 		out.Success = false
 		return out, nil
 	}
+	d = diagram.Normalize(d)
+	if errs := p.validateSchema(d); len(errs) > 0 {
+		out.Errors = append(out.Errors, errs...)
+		out.Success = false
+		return out, nil
+	}
+	if p.opts.NameFromLabel {
+		pinNames(d, terraform.LabelNames(d.Nodes))
+	}
+	importing, importErrs := selectImports(d, p.opts.ImportNodes)
+	out.Errors = append(out.Errors, importErrs...)
+	if len(importErrs) > 0 {
+		out.Success = false
+	}
+
+	// Cross-node checks (CIDR ranges, zones); problems are collected and handlers still run
+	netErrs, netWarns := network.Validate(d, p.reg)
+	out.Errors = append(out.Errors, netErrs...)
+	out.Warnings = append(out.Warnings, netWarns...)
+	if len(netErrs) > 0 {
+		out.Success = false
+	}
+
+	// Secrets in a production diagram would end up in version control with it
+	if errs := secrets.Check(d, secrets.Find(d, p.reg), p.opts.AllowPlaintextSecrets); len(errs) > 0 {
+		out.Errors = append(out.Errors, errs...)
+		out.Success = false
+	}
 
 	// 2. Resolve dependency order and tiers
 	ordered, tiers, err := dependency.Resolve(d)
 	if err != nil {
 		out.Success = false
+		var cycleErr *dependency.CycleError
+		if errors.As(err, &cycleErr) {
+			for _, c := range cycleErr.Cycles {
+				out.Errors = append(out.Errors, result.Error{
+					Type: "dependency_error", Severity: "error", NodeID: c[0].NodeID,
+					Message:    "dependency cycle: " + dependency.FormatCycle(c),
+					Suggestion: "Remove or reverse one of the edges in the cycle",
+				})
+			}
+			return out, nil
+		}
 		out.Errors = append(out.Errors, result.Error{
 			Type: "dependency_error", Severity: "error",
 			Message: err.Error(), Suggestion: "Remove circular edges or fix node references",
@@ -62,82 +138,85 @@ func (p *InfrastructureParser) Parse(d *diagram.Diagram) (*result.ParseResult, e
 
 	// 3. Build ref map and collect resource blocks in order
 	refs := make(registry.RefMap)
-	resourceBlocks := make([][]byte, 0, len(ordered))
-	nodeByID := make(map[string]*diagram.Node)
-	for i := range d.Nodes {
-		nodeByID[d.Nodes[i].ID] = &d.Nodes[i]
-	}
-
-	// Process tier by tier; within each tier run handlers in parallel
-	for _, tier := range tiers {
-		var wg sync.WaitGroup
-		var mu sync.Mutex
-		type nodeResult struct {
-			nodeID string
-			hcl    []byte
-			errs   []result.Error
-			warns  []result.Warning
-		}
-		results := make([]nodeResult, 0, len(tier))
+	resourceBlocks := make([]nodeBlocks, 0, len(ordered))
+	owners := make(map[string]string) // resource address -> node ID
+	nodeProps := make(map[string]any) // node ID -> properties decoded by its handler
+	var imports []terraform.Import
+
+	// Process tier by tier; within each tier run handlers on at most MaxParallel workers.
+	// Results are stored by position in the tier so errors, warnings and blocks
+	// are emitted in tier order regardless of which worker finishes first.
+	for tierIdx, tier := range tiers {
+		start := time.Now()
+		results := make([]nodeResult, len(tier))
+		// Handlers in this tier only reference earlier tiers; give them a private copy
+		// so appending this tier's addresses below never races with their reads.
+		snapshot := refs.Clone()
 
-		for _, nodeID := range tier {
-			node := nodeByID[nodeID]
+		type job struct {
+			i int
+			n *diagram.Node
+			h registry.ResourceHandler
+		}
+		var jobs []job
+		for i, nodeID := range tier {
+			node := d.NodeByID(nodeID)
 			if node == nil {
 				continue
 			}
 			h, ok := p.reg.Get(node.Type)
 			if !ok {
-				mu.Lock()
-				out.Errors = append(out.Errors, result.Error{
+				results[i].errs = []result.Error{{
 					Type: "validation_error", Severity: "error", NodeID: nodeID,
-					Message: "unsupported resource type: " + node.Type,
+					Message:    "unsupported resource type: " + node.Type,
 					Suggestion: "Use one of: vpc, subnet, security_group, ec2_instance, lambda_function, s3_bucket, rds_instance",
-				})
-				out.Success = false
-				mu.Unlock()
+				}}
 				continue
 			}
-
-			wg.Add(1)
-			go func(n *diagram.Node) {
-				defer wg.Done()
-				verrs, vwarns := h.Validate(n)
-				hcl, genErr := h.GenerateHCL(n, d, refs)
-				mu.Lock()
-				res := nodeResult{nodeID: n.ID, errs: verrs, warns: vwarns}
-				if genErr != nil {
-					res.errs = append(res.errs, result.Error{
-						Type: "generation_error", Severity: "error", NodeID: n.ID,
-						Message: genErr.Error(),
-					})
-				} else {
-					res.hcl = hcl
-				}
-				results = append(results, res)
-				mu.Unlock()
-			}(node)
+			jobs = append(jobs, job{i: i, n: node, h: h})
 		}
-		wg.Wait()
+		runPool(ctx, len(jobs), p.opts.MaxParallel, func(k int) {
+			j := jobs[k]
+			results[j.i] = runHandler(ctx, j.h, j.n, d, snapshot)
+		})
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		out.Tiers = append(out.Tiers, result.TierTiming{
+			Tier: tierIdx, Nodes: len(tier), Duration: time.Since(start),
+		})
 
-		resultsByID := make(map[string]nodeResult)
-		for _, res := range results {
+		// Append blocks in tier order so main.tf stays in dependency order
+		for i, nodeID := range tier {
+			res := results[i]
+			if res.props != nil {
+				nodeProps[nodeID] = res.props
+			}
 			out.Errors = append(out.Errors, res.errs...)
 			out.Warnings = append(out.Warnings, res.warns...)
 			if len(res.errs) > 0 {
 				out.Success = false
 			}
-			resultsByID[res.nodeID] = res
-		}
-		// Append blocks in tier order so main.tf stays in dependency order
-		for _, nodeID := range tier {
-			res := resultsByID[nodeID]
 			if len(res.hcl) > 0 {
-				resourceBlocks = append(resourceBlocks, res.hcl)
-				node := nodeByID[nodeID]
+				resourceBlocks = append(resourceBlocks, nodeBlocks{node: d.NodeByID(nodeID), hcl: res.hcl})
+				for _, addr := range terraform.ResourceAddresses(res.hcl) {
+					if other, taken := owners[addr]; taken && other != nodeID {
+						out.Errors = append(out.Errors, result.Error{
+							Type: "generation_error", Severity: "error", NodeID: nodeID,
+							Message:    fmt.Sprintf("resource address %s is also generated by node %q", addr, other),
+							Suggestion: "Node ids that differ only in punctuation map to the same name; rename one of the nodes or set terraform_name on it",
+						})
+						out.Success = false
+						continue
+					}
+					owners[addr] = nodeID
+				}
+				node := d.NodeByID(nodeID)
 				if node != nil {
-					tfType := terraformResourceType(node.Type)
-					name := terraform.SanitizeName(nodeID)
-					refs[nodeID] = tfType + "." + name
+					refs[nodeID] = terraformResourceType(node.Type) + "." + terraform.ResourceName(node)
+					if importing[nodeID] {
+						imports = append(imports, terraform.Import{To: refs[nodeID], ID: node.Properties["import_id"].(string)})
+					}
 				}
 			}
 		}
@@ -147,21 +226,212 @@ func (p *InfrastructureParser) Parse(d *diagram.Diagram) (*result.ParseResult, e
 		return out, nil
 	}
 
+	// Best-practice rules; only lint_errors (rules configured as "error") stop generation
+	lintErrs, lintWarns := lint.Run(d, nodeProps, p.opts.Lint)
+	out.Errors = append(out.Errors, lintErrs...)
+	out.Warnings = append(out.Warnings, lintWarns...)
+	if len(lintErrs) > 0 {
+		out.Success = false
+		return out, nil
+	}
+
 	// 4. Build Terraform files
+	addresses := make([]string, 0, len(owners))
+	for addr := range owners {
+		addresses = append(addresses, addr)
+	}
 	b := terraform.NewBuilder(p.opts.EmitTfvars)
-	b.SetVersions(terraform.VersionsTF())
 	b.SetVariables(terraform.VariablesTF())
 	b.SetOutputs(terraform.OutputsTF())
-	for _, block := range resourceBlocks {
-		b.AddResource(block)
+	b.SetNodeComments(p.opts.NodeComments)
+	for _, nb := range resourceBlocks {
+		// Handlers may declare variables (e.g. sensitive inputs); those belong in variables.tf
+		rest, vars := terraform.SplitVariables(nb.hcl)
+		b.AddResource(nb.node, rest)
+		b.AddVariables(nb.node, vars)
 	}
 	if p.opts.EmitTfvars {
 		b.SetTfvars(terraform.TfvarsFromMetadata(&d.Metadata))
 	}
-	out.TerraformFiles = b.Build()
+	// Resources whose address changed since the previous generation are moved, not replaced
+	snap := moved.Take(d, owners)
+	if p.opts.Previous != nil {
+		earlier := make(map[string]bool, len(p.opts.Previous.Moved))
+		for _, m := range p.opts.Previous.Moved {
+			earlier[m.From] = true
+		}
+		snap.Moved = moved.Resolve(p.opts.Previous, snap)
+		for _, m := range snap.Moved {
+			if earlier[m.From] {
+				continue
+			}
+			out.Warnings = append(out.Warnings, result.Warning{
+				Type: "generation_warning", Severity: "info", NodeID: m.NodeID,
+				Message:    fmt.Sprintf("%s is now %s; declared in moved.tf so Terraform keeps the existing resource", m.From, m.To),
+				Suggestion: "Set terraform_name on the node to pin its resource names",
+			})
+		}
+		if len(snap.Moved) > 0 {
+			b.SetMoved(moved.HCL(snap.Moved))
+		}
+	}
+	// Existing resources listed by import_id are adopted instead of created
+	if len(imports) > 0 {
+		b.SetImports(terraform.ImportsTF(imports))
+	}
+	required := terraform.RequiredVersion
+	switch {
+	case len(imports) > 0:
+		required = terraform.RequiredVersionImports
+	case len(snap.Moved) > 0:
+		required = terraform.RequiredVersionMoved
+	}
+	b.SetVersions(terraform.VersionsTF(required, terraform.ProvidersFor(addresses)...))
+	files, sm := b.Build()
+	out.SourceMap = sm
+
+	// 5. Re-parse the generated files so handler bugs surface as errors instead of broken output
+	if p.opts.ValidateHCL || p.opts.ValidateSchema {
+		errs, warns := p.validateGenerated(files, owners, sm)
+		out.Warnings = append(out.Warnings, warns...)
+		if len(errs) > 0 {
+			out.Errors = append(out.Errors, errs...)
+			out.Success = false
+			return out, nil
+		}
+	}
+
+	// 6. Normalize the resources into the plan model and evaluate policies against it
+	pl, err := plan.FromHCL(files["main.tf"], "main.tf", owners)
+	if err != nil {
+		out.Errors = append(out.Errors, result.Error{
+			Type: "generation_error", Severity: "error",
+			Message: "plan: " + err.Error(),
+		})
+		out.Success = false
+		return out, nil
+	}
+	errs := p.opts.Policies.Evaluate(ctx, pl)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		out.Errors = append(out.Errors, errs...)
+		out.Success = false
+		return out, nil
+	}
+	out.Plan = pl
+
+	// 7. Estimate the monthly cost from the bundled price table
+	prices, err := cost.BundledPrices()
+	if err != nil {
+		out.Errors = append(out.Errors, result.Error{Type: "generation_error", Severity: "error", Message: err.Error()})
+		out.Success = false
+		return out, nil
+	}
+	out.Cost = prices.Estimate(pl, d.Metadata.AWSRegion())
+
+	// 8. Let the Terraform CLI check what this parser cannot (provider-side validation, plan)
+	if p.opts.Terraform != nil {
+		errs, warns := p.runTerraform(ctx, files, owners, sm)
+		out.Warnings = append(out.Warnings, warns...)
+		if len(errs) > 0 {
+			out.Errors = append(out.Errors, errs...)
+			out.Success = false
+			return out, nil
+		}
+	}
+	out.TerraformFiles = files
+	out.Snapshot = snap
 	return out, nil
 }
 
+// selectImports returns the IDs of the nodes whose import_id becomes an import block: all
+// nodes with one, or the listed ones, which must have one.
+func selectImports(d *diagram.Diagram, only []string) (map[string]bool, []result.Error) {
+	withID := make(map[string]bool)
+	for _, n := range d.Nodes {
+		if id, ok := n.Properties["import_id"].(string); ok && id != "" {
+			withID[n.ID] = true
+		}
+	}
+	if only == nil {
+		return withID, nil
+	}
+	selected := make(map[string]bool, len(only))
+	var errs []result.Error
+	for _, id := range only {
+		switch {
+		case d.NodeByID(id) == nil:
+			errs = append(errs, result.Error{
+				Type: "validation_error", Severity: "error",
+				Message:    fmt.Sprintf("node %q selected for import does not exist", id),
+				Suggestion: "List the ids of nodes that have an import_id",
+			})
+		case !withID[id]:
+			errs = append(errs, result.Error{
+				Type: "validation_error", Severity: "error", NodeID: id, Path: "properties/import_id",
+				Message:    "node is selected for import but has no import_id",
+				Suggestion: "Set import_id to the id of the existing resource",
+			})
+		default:
+			selected[id] = true
+		}
+	}
+	return selected, errs
+}
+
+// pinNames sets terraform_name on the nodes of a normalized diagram that names maps
+// (node ID -> resource name). Properties are copied first: Normalize shares them with the
+// caller's diagram.
+func pinNames(d *diagram.Diagram, names map[string]string) {
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		name, ok := names[n.ID]
+		if !ok {
+			continue
+		}
+		props := make(map[string]any, len(n.Properties)+1)
+		for k, v := range n.Properties {
+			props[k] = v
+		}
+		props["terraform_name"] = name
+		n.Properties = props
+	}
+}
+
+// runHandler validates a node and, if it is valid, generates its HCL. A panicking handler is reported as a
+// generation_error for that node instead of crashing the process.
+func runHandler(ctx context.Context, h registry.ResourceHandler, n *diagram.Node, d *diagram.Diagram, refs registry.RefMap) (res nodeResult) {
+	if ctx.Err() != nil {
+		return res
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			res = nodeResult{errs: []result.Error{{
+				Type: "generation_error", Severity: "error", NodeID: n.ID,
+				Message:    fmt.Sprintf("%s handler panicked: %v", h.ResourceType(), r),
+				Suggestion: "This is a bug in the resource handler; please report it with the diagram",
+			}}}
+		}
+	}()
+	props, verrs, vwarns := h.Validate(n)
+	res = nodeResult{props: props, errs: verrs, warns: vwarns}
+	if len(verrs) > 0 {
+		return res
+	}
+	hcl, genErr := h.GenerateHCL(n, props, d, refs)
+	if genErr != nil {
+		res.errs = append(res.errs, result.Error{
+			Type: "generation_error", Severity: "error", NodeID: n.ID,
+			Message: genErr.Error(),
+		})
+	} else {
+		res.hcl = hcl
+	}
+	return res
+}
+
 func terraformResourceType(diagramType string) string {
 	m := map[string]string{
 		"vpc":             "aws_vpc",
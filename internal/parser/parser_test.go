@@ -0,0 +1,528 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/lint"
+	"github.com/json-to-terraform/parser/internal/policy"
+	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/tfcli"
+)
+
+// wideDiagram returns a VPC with n subnets, each containing an EC2 instance,
+// so most tiers hold many nodes that are processed in parallel.
+func wideDiagram(n int) *diagram.Diagram {
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}}
+	d.Nodes = append(d.Nodes, diagram.Node{ID: "vpc", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}})
+	for i := 0; i < n; i++ {
+		subnet := fmt.Sprintf("subnet-%d", i)
+		ec2 := fmt.Sprintf("ec2-%d", i)
+		d.Nodes = append(d.Nodes,
+			diagram.Node{ID: subnet, Type: "subnet", Properties: map[string]any{
+				"cidr_block": fmt.Sprintf("10.0.%d.0/24", i),
+				"tags":       map[string]any{"Zeta": "z", "Alpha": "a", "Index": fmt.Sprint(i)},
+			}},
+			diagram.Node{ID: ec2, Type: "ec2_instance", Properties: map[string]any{"ami": "ami-1", "instance_type": "t3.micro"}},
+		)
+		d.Edges = append(d.Edges,
+			diagram.Edge{ID: "v" + subnet, Source: "vpc", Target: subnet, Type: "contains"},
+			diagram.Edge{ID: "s" + ec2, Source: subnet, Target: ec2, Type: "contains"},
+		)
+	}
+	return d
+}
+
+func TestParseDeterministic(t *testing.T) {
+	const runs = 16
+	outputs := make([]*result.ParseResult, runs)
+	var wg sync.WaitGroup
+	for i := 0; i < runs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res, err := New(DefaultOptions()).Parse(wideDiagram(50))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			outputs[i] = res
+		}(i)
+	}
+	wg.Wait()
+
+	for i, res := range outputs {
+		if res == nil || !res.Success {
+			t.Fatalf("run %d failed: %+v", i, res)
+		}
+	}
+	want := outputs[0].TerraformFiles
+	for i, res := range outputs[1:] {
+		if len(res.TerraformFiles) != len(want) {
+			t.Fatalf("run %d produced %d files, want %d", i+1, len(res.TerraformFiles), len(want))
+		}
+		for name, content := range want {
+			if !bytes.Equal(res.TerraformFiles[name], content) {
+				t.Errorf("run %d: %s differs from run 0", i+1, name)
+			}
+		}
+	}
+}
+
+func TestParseErrorOrderDeterministic(t *testing.T) {
+	d := wideDiagram(20)
+	for i := range d.Nodes {
+		if d.Nodes[i].Type == "ec2_instance" {
+			delete(d.Nodes[i].Properties, "ami")
+		}
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success {
+		t.Fatal("expected validation errors")
+	}
+	for i, e := range res.Errors {
+		if want := fmt.Sprintf("ec2-%d", i); e.NodeID != want {
+			t.Fatalf("error %d is for %s, want %s (diagram order)", i, e.NodeID, want)
+		}
+	}
+}
+
+func TestParseReportsCyclePath(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "sg-a", Type: "security_group", Label: "a"},
+			{ID: "sg-b", Type: "security_group", Label: "b"},
+		},
+		Edges: []diagram.Edge{
+			{ID: "e1", Source: "sg-a", Target: "sg-b", Type: "depends_on"},
+			{ID: "e2", Source: "sg-b", Target: "sg-a", Type: "depends_on"},
+		},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 {
+		t.Fatalf("want one dependency error, got %+v", res.Errors)
+	}
+	e := res.Errors[0]
+	if e.Type != "dependency_error" || e.NodeID != "sg-a" || e.Message != "dependency cycle: sg-a --[e1]--> sg-b --[e2]--> sg-a" {
+		t.Errorf("unexpected error %+v", e)
+	}
+}
+
+func TestParseErrorsCarryLocation(t *testing.T) {
+	src := `{
+  "metadata": {"version": "1.0"},
+  "nodes": [
+    {"id": "sg", "type": "security_group", "properties": {}}
+  ]
+}`
+	d, err := diagram.Decode([]byte(src), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) == 0 {
+		t.Fatalf("want a handler validation error, got %+v", res)
+	}
+	e := res.Errors[0]
+	if e.Path != "/nodes/0" || e.Line != 4 || e.Column != 5 {
+		t.Errorf("error location = %s line %d column %d, want /nodes/0 line 4 column 5", e.Path, e.Line, e.Column)
+	}
+}
+
+func TestParseReportsPropertyErrors(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "fn", Type: "lambda_function", Properties: map[string]any{"runtime": "python3.12", "handler": "index.handler", "memory_size": "512"}},
+			{ID: "web", Type: "ec2_instance", Properties: map[string]any{"ami": "ami-123", "instance_type": "t3.huge"}},
+			{ID: "db", Type: "rds_instance", Properties: map[string]any{"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0, "engin_version": "16"}},
+		},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []result.Error{
+		{Type: "validation_error", NodeID: "fn", Path: "/nodes/0/properties/memory_size", Message: "memory_size: expected an integer, got string"},
+		{Type: "validation_error", NodeID: "web", Path: "/nodes/1/properties/instance_type"},
+		{Type: "validation_error", NodeID: "db", Path: "/nodes/2/properties/engin_version", Message: "unknown property engin_version"},
+	}
+	if res.Success || len(res.Errors) != len(want) {
+		t.Fatalf("errors = %+v", res.Errors)
+	}
+	for i, w := range want {
+		e := res.Errors[i]
+		if e.Type != w.Type || e.NodeID != w.NodeID || e.Path != w.Path || (w.Message != "" && e.Message != w.Message) {
+			t.Errorf("error %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestParseReportsDiagramSchemaErrors(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0", Region: "Europe"},
+		Nodes: []diagram.Node{
+			{ID: "vpc", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16", "tags": nil}},
+			{ID: "sub", Type: "subnet", Properties: map[string]any{"cidr_block": "10.0.1.0/24"}},
+		},
+		Edges: []diagram.Edge{{ID: "e1", Source: "vpc", Target: "sub", Type: "contanis"}},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []result.Error{
+		{Type: "schema_error", Path: "/edges/0/type", Message: `edges[0].type: "contanis" is not an allowed value`},
+		{Type: "schema_error", Path: "/metadata/region"},
+	}
+	if res.Success || len(res.Errors) != len(want) {
+		t.Fatalf("errors = %+v", res.Errors)
+	}
+	for i, w := range want {
+		e := res.Errors[i]
+		if e.Type != w.Type || e.Path != w.Path || (w.Message != "" && e.Message != w.Message) {
+			t.Errorf("error %d = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func TestParseReportsNetworkErrors(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "vpc", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+			{ID: "sub", Type: "subnet", Properties: map[string]any{"cidr_block": "10.1.0.0/24", "availability_zone": "us-east-1a"}},
+		},
+		Edges: []diagram.Edge{{ID: "e1", Source: "vpc", Target: "sub", Type: "contains"}},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 {
+		t.Fatalf("errors = %+v, want one", res.Errors)
+	}
+	if e := res.Errors[0]; e.NodeID != "sub" || e.Path != "/nodes/1/properties/cidr_block" {
+		t.Errorf("error = %+v, want subnet cidr_block", e)
+	}
+}
+
+func TestParseLintSeverities(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{{ID: "sg", Type: "security_group", Label: "web", Properties: map[string]any{"ingress": []any{
+			map[string]any{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []any{"0.0.0.0/0"}},
+		}}}},
+	}
+
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || len(res.Warnings) != 1 || res.Warnings[0].Rule != "sg-open-admin-port" || res.Warnings[0].Path != "/nodes/0/properties/ingress/0" {
+		t.Fatalf("default severity: success=%v warnings=%+v, want one located sg-open-admin-port warning", res.Success, res.Warnings)
+	}
+
+	opts := DefaultOptions()
+	opts.Lint = lint.Config{Rules: map[string]lint.Severity{"sg-open-admin-port": lint.SeverityError}}
+	res, err = New(opts).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 || res.Errors[0].Type != "lint_error" || res.TerraformFiles != nil {
+		t.Fatalf("error severity: success=%v errors=%+v, want the parse to fail with a lint_error", res.Success, res.Errors)
+	}
+}
+
+func TestParseEvaluatesPolicies(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "logs", Type: "s3_bucket", Label: "logs", Properties: map[string]any{"tags": map[string]any{"Owner": "ops"}}},
+			{ID: "assets", Type: "s3_bucket", Label: "assets"},
+		},
+	}
+	owner, err := policy.New("owner-tag", "has(resource.attributes.tags.Owner)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Plan == nil || len(res.Plan.Resources) != 2 || res.Plan.Resources[1].NodeID != "assets" {
+		t.Fatalf("without policies: success=%v plan=%+v", res.Success, res.Plan)
+	}
+
+	opts := DefaultOptions()
+	opts.Policies = &policy.Set{Policies: []*policy.Policy{owner}}
+	res, err = New(opts).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 {
+		t.Fatalf("errors = %+v, want one policy_violation", res.Errors)
+	}
+	if e := res.Errors[0]; e.Type != "policy_violation" || e.NodeID != "assets" || e.Rule != "owner-tag" || e.Path != "/nodes/1" {
+		t.Errorf("error = %+v", e)
+	}
+}
+
+func TestParseRefusesPlaintextSecretsInProduction(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0", Environment: "production"},
+		Nodes: []diagram.Node{{ID: "db", Type: "rds_instance", Properties: map[string]any{
+			"engine": "postgres", "instance_class": "db.t3.micro", "allocated_storage": 20.0,
+			"password": "s3cr3t-pa55",
+		}}},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 || res.Errors[0].Path != "/nodes/0/properties/password" {
+		t.Fatalf("errors = %+v, want the password refused", res.Errors)
+	}
+
+	opts := DefaultOptions()
+	opts.AllowPlaintextSecrets = true
+	res, err = New(opts).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatalf("allowed: errors = %+v", res.Errors)
+	}
+	out, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range res.TerraformFiles {
+		out = append(out, content...)
+		if bytes.Contains(content, []byte("s3cr3t-pa55")) {
+			t.Errorf("%s contains the password", name)
+		}
+	}
+	if bytes.Contains(out, []byte("s3cr3t-pa55")) {
+		t.Errorf("result contains the password:\n%s", out)
+	}
+}
+
+func TestParseEstimatesCost(t *testing.T) {
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes: []diagram.Node{
+			{ID: "web", Type: "ec2_instance", Properties: map[string]any{"ami": "ami-123", "instance_type": "t3.micro"}},
+			{ID: "assets", Type: "s3_bucket", Label: "assets"},
+		},
+	}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.Cost == nil {
+		t.Fatalf("success=%v cost=%+v errors=%+v", res.Success, res.Cost, res.Errors)
+	}
+	if len(res.Cost.Nodes) != 1 || res.Cost.Nodes[0].NodeID != "web" || res.Cost.MonthlyTotal != res.Cost.Nodes[0].Monthly {
+		t.Errorf("cost = %+v, want only the instance priced", res.Cost)
+	}
+	if len(res.Cost.Unpriced) != 1 || res.Cost.Unpriced[0].NodeID != "assets" {
+		t.Errorf("unpriced = %+v", res.Cost.Unpriced)
+	}
+}
+
+func TestParseDeclaresMovedResources(t *testing.T) {
+	vpc := map[string]any{"cidr_block": "10.0.0.0/16"}
+	before := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "vpc-main", Type: "vpc", Properties: vpc},
+	}}
+	prev, err := New(DefaultOptions()).Parse(before)
+	if err != nil || !prev.Success {
+		t.Fatalf("err=%v errors=%+v", err, prev.Errors)
+	}
+	if _, ok := prev.TerraformFiles["moved.tf"]; ok {
+		t.Error("moved.tf generated without a previous snapshot")
+	}
+
+	after := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "network", Type: "vpc", Properties: vpc},
+	}}
+	opts := DefaultOptions()
+	opts.Previous = prev.Snapshot
+	res, err := New(opts).Parse(after)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	want := "moved {\n  from = aws_vpc.vpc_main\n  to   = aws_vpc.network\n}\n"
+	if got := string(res.TerraformFiles["moved.tf"]); got != want {
+		t.Errorf("moved.tf =\n%s\nwant\n%s", got, want)
+	}
+	if len(res.Warnings) != 1 || res.Warnings[0].NodeID != "network" || res.Warnings[0].Severity != "info" {
+		t.Errorf("warnings = %+v", res.Warnings)
+	}
+
+	// Pinning the old name keeps the address, so nothing moves
+	after.Nodes[0].Properties = map[string]any{"cidr_block": "10.0.0.0/16", "terraform_name": "vpc_main"}
+	res, err = New(opts).Parse(after)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	if _, ok := res.TerraformFiles["moved.tf"]; ok || !strings.Contains(string(res.TerraformFiles["main.tf"]), `"aws_vpc" "vpc_main"`) {
+		t.Errorf("files = %s", res.TerraformFiles)
+	}
+}
+
+func TestParseReportsNameCollisions(t *testing.T) {
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "vpc-main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{ID: "vpc_main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.1.0.0/16"}},
+	}}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 {
+		t.Fatalf("success=%v errors=%+v", res.Success, res.Errors)
+	}
+	e := res.Errors[0]
+	if e.Type != "generation_error" || e.NodeID != "vpc_main" || !strings.Contains(e.Message, `aws_vpc.vpc_main is also generated by node "vpc-main"`) {
+		t.Errorf("error = %+v", e)
+	}
+
+	// Pinning a name resolves the collision
+	d.Nodes[1].Properties = map[string]any{"cidr_block": "10.1.0.0/16", "terraform_name": "secondary"}
+	if res, err := New(DefaultOptions()).Parse(d); err != nil || !res.Success {
+		t.Errorf("err=%v errors=%+v", err, res.Errors)
+	}
+}
+
+func TestParseNamesFromLabels(t *testing.T) {
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "vpc-1", Type: "vpc", Label: "Core Network", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+		{ID: "subnet-1", Type: "subnet", Label: "Public A", Properties: map[string]any{"cidr_block": "10.0.1.0/24"}},
+		{ID: "subnet-2", Type: "subnet", Label: "public a", Properties: map[string]any{"cidr_block": "10.0.2.0/24"}},
+	}, Edges: []diagram.Edge{
+		{ID: "e1", Source: "vpc-1", Target: "subnet-1", Type: "contains"},
+		{ID: "e2", Source: "vpc-1", Target: "subnet-2", Type: "contains"},
+	}}
+	opts := DefaultOptions()
+	opts.NameFromLabel = true
+	res, err := New(opts).Parse(d)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	main := string(res.TerraformFiles["main.tf"])
+	for _, want := range []string{`"aws_vpc" "core_network"`, `"aws_subnet" "public_a"`, `"aws_subnet" "public_a_2"`, "vpc_id                  = aws_vpc.core_network.id"} {
+		if !strings.Contains(main, want) {
+			t.Errorf("main.tf does not contain %s:\n%s", want, main)
+		}
+	}
+	if _, ok := d.Nodes[0].Properties["terraform_name"]; ok {
+		t.Error("Parse modified the caller's diagram")
+	}
+}
+
+func TestParseGeneratesImports(t *testing.T) {
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "vpc-main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16", "import_id": "vpc-0abc"}},
+		{ID: "assets", Type: "s3_bucket", Properties: map[string]any{"bucket": "assets", "import_id": "assets"}},
+		{ID: "logs", Type: "s3_bucket", Properties: map[string]any{"bucket": "logs"}},
+	}}
+	res, err := New(DefaultOptions()).Parse(d)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	want := "import {\n  to = aws_vpc.vpc_main\n  id = \"vpc-0abc\"\n}\n\nimport {\n  to = aws_s3_bucket.assets\n  id = \"assets\"\n}\n"
+	if got := string(res.TerraformFiles["imports.tf"]); got != want {
+		t.Errorf("imports.tf =\n%s\nwant\n%s", got, want)
+	}
+	if !bytes.Contains(res.TerraformFiles["versions.tf"], []byte(`required_version = ">= 1.5"`)) {
+		t.Errorf("versions.tf does not require Terraform 1.5:\n%s", res.TerraformFiles["versions.tf"])
+	}
+
+	opts := DefaultOptions()
+	opts.ImportNodes = []string{"assets"}
+	res, err = New(opts).Parse(d)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	if got := string(res.TerraformFiles["imports.tf"]); got != "import {\n  to = aws_s3_bucket.assets\n  id = \"assets\"\n}\n" {
+		t.Errorf("imports.tf with ImportNodes =\n%s", got)
+	}
+
+	opts.ImportNodes = []string{"logs", "missing"}
+	res, err = New(opts).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range res.Errors {
+		got = append(got, e.Message)
+	}
+	want = `["node is selected for import but has no import_id" "node \"missing\" selected for import does not exist"]`
+	if res.Success || fmt.Sprintf("%q", got) != want {
+		t.Errorf("success=%v errors=%q", res.Success, got)
+	}
+}
+
+func TestParseRunsTerraform(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform is a shell script")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "terraform")
+	script := "#!/bin/sh\ncase \"$1\" in\nvalidate) cat \"$(dirname \"$0\")/validate.json\" ;;\nplan) cat \"$(dirname \"$0\")/plan.jsonl\" ;;\nesac\n"
+	os.WriteFile(bin, []byte(script), 0755)
+	os.WriteFile(filepath.Join(dir, "plan.jsonl"), []byte(`{"type":"change_summary","@message":"Plan: 1 to add, 0 to change, 0 to destroy."}`+"\n"), 0644)
+	setValidate := func(diags string) {
+		os.WriteFile(filepath.Join(dir, "validate.json"), []byte(`{"diagnostics": [`+diags+`]}`), 0644)
+	}
+
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}, Nodes: []diagram.Node{
+		{ID: "vpc-main", Type: "vpc", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}},
+	}}
+	opts := DefaultOptions()
+	opts.Terraform = &tfcli.Runner{Binary: bin, Plan: true}
+
+	setValidate(`{"severity": "error", "summary": "Unsupported argument", "range": {"filename": "main.tf", "start": {"line": 2}}}`)
+	res, err := New(opts).Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 1 {
+		t.Fatalf("success=%v errors=%+v", res.Success, res.Errors)
+	}
+	e := res.Errors[0]
+	if e.Type != "terraform_error" || e.NodeID != "vpc-main" || e.Path != "/nodes/0/properties/cidr_block" || e.Message != "main.tf:2: Unsupported argument" {
+		t.Errorf("error = %+v", e)
+	}
+
+	setValidate("")
+	res, err = New(opts).Parse(d)
+	if err != nil || !res.Success {
+		t.Fatalf("err=%v errors=%+v", err, res.Errors)
+	}
+	last := res.Warnings[len(res.Warnings)-1]
+	if last.Type != "terraform_plan" || last.Message != "Plan: 1 to add, 0 to change, 0 to destroy." {
+		t.Errorf("warnings = %+v", res.Warnings)
+	}
+}
@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"context"
+	"sync"
+)
+
+// runPool calls fn(i) for every i in [0, n) on at most workers goroutines.
+// Once ctx is done no further indexes are handed out; in-flight calls finish normally.
+func runPool(ctx context.Context, n, workers int, fn func(i int)) {
+	if n == 0 {
+		return
+	}
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+}
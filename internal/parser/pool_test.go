@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+func TestRunPoolBounded(t *testing.T) {
+	for _, workers := range []int{1, 3, 8} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			var active, peak, calls int32
+			runPool(context.Background(), 50, workers, func(int) {
+				n := atomic.AddInt32(&active, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&active, -1)
+				atomic.AddInt32(&calls, 1)
+			})
+			if calls != 50 {
+				t.Errorf("fn called %d times, want 50", calls)
+			}
+			if peak > int32(workers) {
+				t.Errorf("peak concurrency %d exceeds %d workers", peak, workers)
+			}
+		})
+	}
+}
+
+// slowHandler sleeps in GenerateHCL so deadlines expire mid-parse.
+type slowHandler struct{ delay time.Duration }
+
+func (slowHandler) ResourceType() string { return "slow" }
+
+func (slowHandler) Validate(*diagram.Node) (any, []result.Error, []result.Warning) {
+	return nil, nil, nil
+}
+
+func (h slowHandler) GenerateHCL(n *diagram.Node, _ any, _ *diagram.Diagram, _ registry.RefMap) ([]byte, error) {
+	time.Sleep(h.delay)
+	return []byte(fmt.Sprintf("resource \"null_resource\" %q {}\n", n.ID)), nil
+}
+
+func TestParseContextDeadline(t *testing.T) {
+	reg := registry.New()
+	reg.Register("slow", slowHandler{delay: 20 * time.Millisecond})
+	p := New(Options{MaxParallel: 1})
+	p.reg = reg
+
+	d := &diagram.Diagram{Metadata: diagram.Metadata{Version: "1.0"}}
+	for i := 0; i < 100; i++ {
+		d.Nodes = append(d.Nodes, diagram.Node{ID: fmt.Sprintf("n%d", i), Type: "slow"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.ParseContext(ctx, d)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ParseContext() error = %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ParseContext took %v after deadline; workers did not stop", elapsed)
+	}
+}
+
+func TestParseReportsTierTimings(t *testing.T) {
+	res, err := New(DefaultOptions()).Parse(wideDiagram(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 5, 5}
+	if len(res.Tiers) != len(want) {
+		t.Fatalf("got %d tier timings, want %d", len(res.Tiers), len(want))
+	}
+	for i, tt := range res.Tiers {
+		if tt.Tier != i || tt.Nodes != want[i] || tt.Duration <= 0 {
+			t.Errorf("tier %d timing = %+v, want %d nodes and a positive duration", i, tt, want[i])
+		}
+	}
+	// Timings differ between runs, so the JSON result leaves them out.
+	data, err := json.Marshal(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(`"tiers"`)) {
+		t.Errorf("JSON result includes timings: %s", data)
+	}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// These tests are most useful under `go test -race`.
+
+func TestParseSharedDiagramConcurrently(t *testing.T) {
+	d := wideDiagram(500)
+	d.Nodes[0].Properties = nil
+	for i := range d.Edges {
+		d.Edges[i].Type = ""
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := New(Options{MaxParallel: 4})
+			if _, err := p.Parse(d); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if d.Nodes[0].Properties != nil {
+		t.Error("Parse modified node properties of the input diagram")
+	}
+	for _, e := range d.Edges {
+		if e.Type != "" {
+			t.Fatalf("Parse modified edge %s type to %q", e.ID, e.Type)
+		}
+	}
+}
+
+type panicHandler struct{}
+
+func (panicHandler) ResourceType() string { return "boom" }
+
+func (panicHandler) Validate(*diagram.Node) (any, []result.Error, []result.Warning) {
+	return nil, nil, nil
+}
+
+func (panicHandler) GenerateHCL(*diagram.Node, any, *diagram.Diagram, registry.RefMap) ([]byte, error) {
+	panic("unexpected property shape")
+}
+
+func TestParseRecoversHandlerPanic(t *testing.T) {
+	reg := registry.New()
+	reg.Register("boom", panicHandler{})
+	p := New(DefaultOptions())
+	p.reg = reg
+
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes:    []diagram.Node{{ID: "a", Type: "boom"}, {ID: "b", Type: "boom"}},
+	}
+	res, err := p.Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) != 2 {
+		t.Fatalf("want 2 errors, got %+v", res.Errors)
+	}
+	for _, e := range res.Errors {
+		if e.Type != "generation_error" || !strings.Contains(e.Message, "boom handler panicked: unexpected property shape") {
+			t.Errorf("unexpected error %+v", e)
+		}
+	}
+}
+
+// brokenHandler generates HCL that does not parse.
+type brokenHandler struct{}
+
+func (brokenHandler) ResourceType() string { return "broken" }
+
+func (brokenHandler) Validate(*diagram.Node) (any, []result.Error, []result.Warning) {
+	return nil, nil, nil
+}
+
+func (brokenHandler) GenerateHCL(n *diagram.Node, _ any, _ *diagram.Diagram, _ registry.RefMap) ([]byte, error) {
+	return []byte("resource \"aws_vpc\" \"" + n.ID + "\" {\n  cidr_block =\n}\n"), nil
+}
+
+func TestParseReportsSyntaxErrorsOnTheirNode(t *testing.T) {
+	reg := registry.New()
+	reg.Register("broken", brokenHandler{})
+	p := New(DefaultOptions())
+	p.reg = reg
+
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes:    []diagram.Node{{ID: "a", Type: "broken"}},
+	}
+	res, err := p.Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || len(res.Errors) == 0 {
+		t.Fatalf("want syntax errors, got %+v", res)
+	}
+	for _, e := range res.Errors {
+		if e.Type != "generation_error" || e.NodeID != "a" {
+			t.Errorf("error = %+v, want a generation_error on node a", e)
+		}
+	}
+}
+
+// unlistedHandler generates an argument the bundled provider schema does not list.
+type unlistedHandler struct{}
+
+func (unlistedHandler) ResourceType() string { return "unlisted" }
+
+func (unlistedHandler) Validate(*diagram.Node) (any, []result.Error, []result.Warning) {
+	return nil, nil, nil
+}
+
+func (unlistedHandler) GenerateHCL(n *diagram.Node, _ any, _ *diagram.Diagram, _ registry.RefMap) ([]byte, error) {
+	return []byte("resource \"aws_vpc\" \"" + n.ID + "\" {\n  cidr_block = \"10.0.0.0/16\"\n  not_in_schema = true\n}\n"), nil
+}
+
+func TestParseWarnsAboutArgumentsMissingFromTheSchema(t *testing.T) {
+	reg := registry.New()
+	reg.Register("unlisted", unlistedHandler{})
+	opts := DefaultOptions()
+	opts.ValidateSchema = true
+	p := New(opts)
+	p.reg = reg
+
+	d := &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0"},
+		Nodes:    []diagram.Node{{ID: "a", Type: "unlisted"}},
+	}
+	res, err := p.Parse(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success {
+		t.Fatalf("errors = %+v, want none", res.Errors)
+	}
+	var found bool
+	for _, w := range res.Warnings {
+		found = found || (w.Type == "generation_warning" && w.NodeID == "a" && strings.Contains(w.Message, `unsupported argument "not_in_schema"`))
+	}
+	if !found {
+		t.Errorf("warnings = %+v, want one about not_in_schema on node a", res.Warnings)
+	}
+}
+
+func TestParseCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err := New(DefaultOptions()).parse(ctx, wideDiagram(10))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("parse() = %v, %v; want context.Canceled", res, err)
+	}
+}
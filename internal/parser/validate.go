@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/terraform"
+)
+
+// validateSchema checks the structure of the diagram against the JSON Schema the registry
+// publishes (node types, edge types, metadata) and reports violations as schema_errors
+// pointing at the offending value. Node properties are left to the handlers, which decode
+// them and report their problems as validation_errors.
+func (p *InfrastructureParser) validateSchema(d *diagram.Diagram) []result.Error {
+	var errs []result.Error
+	for _, v := range p.reg.DiagramSchema().Validate(schemaDocument(d)) {
+		e := result.Error{Type: "schema_error", Severity: "error", Path: v.Path, Suggestion: v.Suggestion}
+		name := v.Path
+		if rest, ok := strings.CutPrefix(v.Path, "/nodes/"); ok {
+			idx, below, _ := strings.Cut(rest, "/")
+			if i, err := strconv.Atoi(idx); err == nil && i < len(d.Nodes) {
+				e.NodeID, name = d.Nodes[i].ID, below
+			}
+		}
+		e.Message = v.Message
+		// An unknown key is named by the message itself
+		if name = pointerName(name); name != "" && v.Keyword != "additionalProperties" {
+			e.Message = fmt.Sprintf("%s: %s", name, v.Message)
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// schemaDocument renders the structure of d as the JSON document the schema describes,
+// without node properties.
+func schemaDocument(d *diagram.Diagram) map[string]any {
+	meta := map[string]any{"version": d.Metadata.Version}
+	for k, v := range map[string]string{
+		"name": d.Metadata.Name, "description": d.Metadata.Description,
+		"environment": d.Metadata.Environment, "region": d.Metadata.Region,
+	} {
+		if v != "" {
+			meta[k] = v
+		}
+	}
+	nodes := make([]any, len(d.Nodes))
+	for i, n := range d.Nodes {
+		node := map[string]any{"id": n.ID, "type": n.Type, "label": n.Label}
+		if n.Suppress != nil {
+			suppress := make([]any, len(n.Suppress))
+			for j, r := range n.Suppress {
+				suppress[j] = r
+			}
+			node["suppress"] = suppress
+		}
+		nodes[i] = node
+	}
+	edges := make([]any, len(d.Edges))
+	for i, e := range d.Edges {
+		edge := map[string]any{"id": e.ID, "source": e.Source, "target": e.Target, "type": e.Type}
+		if e.Properties != nil {
+			edge["properties"] = e.Properties
+		}
+		edges[i] = edge
+	}
+	return map[string]any{"metadata": meta, "nodes": nodes, "edges": edges}
+}
+
+// pointerName renders a JSON pointer as "properties.ingress[0].from_port".
+func pointerName(ptr string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if strings.Trim(part, "0123456789") == "" {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(strings.NewReplacer("~1", "/", "~0", "~").Replace(part))
+	}
+	return b.String()
+}
+
+// validateGenerated re-parses the generated files and reports problems as generation_errors,
+// attributed to the node that produced the offending resource block. Arguments missing from
+// the bundled provider schema are only generation_warnings: the schema is trimmed, so they
+// may well be valid.
+// owners maps resource addresses (e.g. "aws_vpc.vpc_main") to node IDs.
+func (p *InfrastructureParser) validateGenerated(files map[string][]byte, owners map[string]string, sm terraform.SourceMap) ([]result.Error, []result.Warning) {
+	var schema *terraform.ProviderSchemas
+	if p.opts.ValidateSchema {
+		s, err := terraform.BundledSchema()
+		if err != nil {
+			return []result.Error{{
+				Type: "generation_error", Severity: "error",
+				Message: err.Error(),
+			}}, nil
+		}
+		schema = s
+	}
+
+	var errs []result.Error
+	var warns []result.Warning
+	for _, diag := range terraform.ValidateFiles(files, schema) {
+		nodeID, path := origin(diag, owners, sm)
+		if diag.Unlisted {
+			warns = append(warns, result.Warning{
+				Type: "generation_warning", Severity: "warning", NodeID: nodeID, Path: path,
+				Message:    diag.String() + " (the bundled provider schema lists only some arguments)",
+				Suggestion: "Check the argument against the AWS provider documentation",
+			})
+			continue
+		}
+		errs = append(errs, result.Error{
+			Type: "generation_error", Severity: "error", NodeID: nodeID, Path: path,
+			Message:    diag.String(),
+			Suggestion: "This is a bug in the resource handler; please report it with the diagram",
+		})
+	}
+	return errs, warns
+}
+
+// runTerraform runs the Terraform CLI on the generated files and reports its diagnostics on
+// the node, and where possible the property, that produced the lines they point at.
+func (p *InfrastructureParser) runTerraform(ctx context.Context, files map[string][]byte, owners map[string]string, sm terraform.SourceMap) ([]result.Error, []result.Warning) {
+	res, err := p.opts.Terraform.Check(ctx, files)
+	if err != nil {
+		return []result.Error{{
+			Type: "terraform_error", Severity: "error",
+			Message:    "terraform " + err.Error(),
+			Suggestion: "Check that the terraform binary works and can download the providers (terraform init)",
+		}}, nil
+	}
+	var errs []result.Error
+	var warns []result.Warning
+	for _, d := range res.Diagnostics {
+		nodeID, path := origin(d.Diagnostic, owners, sm)
+		if d.Severity == "error" {
+			errs = append(errs, result.Error{
+				Type: "terraform_error", Severity: "error", NodeID: nodeID, Path: path,
+				Message: d.String(),
+			})
+			continue
+		}
+		warns = append(warns, result.Warning{
+			Type: "terraform_warning", Severity: "warning", NodeID: nodeID, Path: path,
+			Message: d.String(),
+		})
+	}
+	if res.Summary != "" {
+		warns = append(warns, result.Warning{Type: "terraform_plan", Severity: "info", Message: res.Summary})
+	}
+	return errs, warns
+}
+
+// origin returns the node a diagnostic concerns and the property path (relative to the node)
+// it points at: by the source map when the diagnostic has a line, otherwise by the address.
+func origin(d terraform.Diagnostic, owners map[string]string, sm terraform.SourceMap) (nodeID, path string) {
+	if d.Line > 0 {
+		if m, ok := sm.Lookup(d.File, d.Line); ok {
+			return m.NodeID, m.Path
+		}
+	}
+	return owners[d.Address], ""
+}
@@ -0,0 +1,195 @@
+// Package plan turns generated Terraform into a normalized JSON model that policies can be
+// written against without parsing HCL: one entry per resource with its address, the node
+// it came from, its attributes as plain JSON values and the references it makes.
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// FormatVersion is the version of the JSON layout below.
+const FormatVersion = "1.0"
+
+// Plan is the normalized model of the generated resources.
+type Plan struct {
+	FormatVersion string     `json:"format_version"`
+	Resources     []Resource `json:"resources"`
+}
+
+// Resource is one generated resource block.
+//
+// Attributes hold literal values as JSON (strings, numbers, booleans, lists and objects);
+// nested blocks become lists of objects under the block type, as in Terraform's own JSON
+// plans. Values that depend on other resources are kept as expressions in ${...} form
+// ("${aws_vpc.vpc_main.id}"), and every reference is listed in References.
+type Resource struct {
+	Address    string         `json:"address"` // aws_vpc.vpc_main
+	Type       string         `json:"type"`
+	Name       string         `json:"name"`
+	NodeID     string         `json:"node_id,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+	References []string       `json:"references,omitempty"` // sorted, e.g. aws_vpc.vpc_main.id
+	// IgnoreChanges are the attributes of lifecycle's ignore_changes, which Terraform does
+	// not update once the resource exists.
+	IgnoreChanges []string `json:"ignore_changes,omitempty"`
+}
+
+// FromHCL builds the plan from generated Terraform source (main.tf). owners maps resource
+// addresses to the node IDs that produced them. Resources keep their order in src.
+func FromHCL(src []byte, filename string, owners map[string]string) (*Plan, error) {
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("plan: %s is not native HCL syntax", filename)
+	}
+	p := &Plan{FormatVersion: FormatVersion, Resources: []Resource{}}
+	for _, b := range body.Blocks {
+		if b.Type != "resource" || len(b.Labels) != 2 {
+			continue
+		}
+		c := converter{src: src, refs: make(map[string]bool)}
+		r := Resource{
+			Address:       b.Labels[0] + "." + b.Labels[1],
+			Type:          b.Labels[0],
+			Name:          b.Labels[1],
+			Attributes:    c.body(b.Body),
+			IgnoreChanges: ignoreChanges(b.Body),
+		}
+		r.NodeID = owners[r.Address]
+		for ref := range c.refs {
+			r.References = append(r.References, ref)
+		}
+		sort.Strings(r.References)
+		p.Resources = append(p.Resources, r)
+	}
+	return p, nil
+}
+
+// Map returns the plan as the generic JSON value (map[string]any) it marshals to.
+func (p *Plan) Map() (map[string]any, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// ignoreChanges returns the attribute names listed in the ignore_changes of a resource's
+// lifecycle block.
+func ignoreChanges(b *hclsyntax.Body) []string {
+	var out []string
+	for _, nested := range b.Blocks {
+		if nested.Type != "lifecycle" {
+			continue
+		}
+		a, ok := nested.Body.Attributes["ignore_changes"]
+		if !ok {
+			continue
+		}
+		for _, t := range a.Expr.Variables() {
+			out = append(out, t.RootName())
+		}
+	}
+	return out
+}
+
+type converter struct {
+	src  []byte
+	refs map[string]bool
+}
+
+func (c *converter) body(b *hclsyntax.Body) map[string]any {
+	out := make(map[string]any, len(b.Attributes)+len(b.Blocks))
+	for name, a := range b.Attributes {
+		out[name] = c.expr(a.Expr)
+	}
+	for _, nested := range b.Blocks {
+		if nested.Type == "lifecycle" {
+			continue // a meta-argument, not part of the resource
+		}
+		list, _ := out[nested.Type].([]any)
+		out[nested.Type] = append(list, c.body(nested.Body))
+	}
+	return out
+}
+
+// expr returns the JSON value of a constant expression. Lists and objects are converted
+// element by element so a single reference does not hide the literals around it; any other
+// expression with references is kept as "${source}".
+func (c *converter) expr(e hclsyntax.Expression) any {
+	for _, t := range e.Variables() {
+		c.refs[traversalString(t)] = true
+	}
+	switch x := e.(type) {
+	case *hclsyntax.TupleConsExpr:
+		out := make([]any, len(x.Exprs))
+		for i, item := range x.Exprs {
+			out[i] = c.expr(item)
+		}
+		return out
+	case *hclsyntax.ObjectConsExpr:
+		out := make(map[string]any, len(x.Items))
+		for _, item := range x.Items {
+			key, diags := item.KeyExpr.Value(nil)
+			if diags.HasErrors() || key.Type() != cty.String || !key.IsKnown() || key.IsNull() {
+				continue
+			}
+			out[key.AsString()] = c.expr(item.ValueExpr)
+		}
+		return out
+	}
+	if v, diags := e.Value(nil); !diags.HasErrors() {
+		return jsonValue(v)
+	}
+	rng := e.Range()
+	return "${" + strings.TrimSpace(string(rng.SliceBytes(c.src))) + "}"
+}
+
+// jsonValue converts a known cty value to the value encoding/json would produce for it.
+func jsonValue(v cty.Value) any {
+	if !v.IsWhollyKnown() {
+		return nil
+	}
+	data, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return nil
+	}
+	var out any
+	if json.Unmarshal(data, &out) != nil {
+		return nil
+	}
+	return out
+}
+
+// traversalString renders a traversal as written in HCL: aws_vpc.main.id, aws_instance.web[0].id.
+func traversalString(t hcl.Traversal) string {
+	var b strings.Builder
+	for _, step := range t {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			b.WriteString(s.Name)
+		case hcl.TraverseAttr:
+			b.WriteString("." + s.Name)
+		case hcl.TraverseIndex:
+			data, err := ctyjson.Marshal(s.Key, s.Key.Type())
+			if err != nil {
+				data = []byte("?")
+			}
+			b.WriteString("[" + string(data) + "]")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package plan
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const mainTF = `resource "aws_vpc" "vpc_main" {
+  cidr_block           = "10.0.0.0/16"
+  enable_dns_hostnames = true
+  tags = {
+    Name = "main"
+  }
+}
+
+resource "aws_security_group" "sg_web" {
+  vpc_id = aws_vpc.vpc_main.id
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+
+resource "aws_instance" "web" {
+  ami                    = "ami-1"
+  vpc_security_group_ids = [aws_security_group.sg_web.id, "sg-123"]
+}
+`
+
+func TestFromHCL(t *testing.T) {
+	owners := map[string]string{"aws_vpc.vpc_main": "vpc-main", "aws_security_group.sg_web": "sg-web", "aws_instance.web": "web"}
+	p, err := FromHCL([]byte(mainTF), "main.tf", owners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	rule := func(port float64) map[string]any {
+		return map[string]any{"from_port": port, "to_port": port, "protocol": "tcp", "cidr_blocks": []any{"0.0.0.0/0"}}
+	}
+	want := map[string]any{
+		"format_version": "1.0",
+		"resources": []any{
+			map[string]any{
+				"address": "aws_vpc.vpc_main", "type": "aws_vpc", "name": "vpc_main", "node_id": "vpc-main",
+				"attributes": map[string]any{
+					"cidr_block": "10.0.0.0/16", "enable_dns_hostnames": true, "tags": map[string]any{"Name": "main"},
+				},
+			},
+			map[string]any{
+				"address": "aws_security_group.sg_web", "type": "aws_security_group", "name": "sg_web", "node_id": "sg-web",
+				"attributes": map[string]any{
+					"vpc_id":  "${aws_vpc.vpc_main.id}",
+					"ingress": []any{rule(22), rule(443)},
+				},
+				"references": []any{"aws_vpc.vpc_main.id"},
+			},
+			map[string]any{
+				"address": "aws_instance.web", "type": "aws_instance", "name": "web", "node_id": "web",
+				"attributes": map[string]any{
+					"ami":                    "ami-1",
+					"vpc_security_group_ids": []any{"${aws_security_group.sg_web.id}", "sg-123"},
+				},
+				"references": []any{"aws_security_group.sg_web.id"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("plan =\n%s", data)
+	}
+}
+
+func TestFromHCLLifecycle(t *testing.T) {
+	src := `resource "aws_secretsmanager_secret" "db_password" {
+  name_prefix = "db-master-password-"
+  lifecycle {
+    ignore_changes = [name_prefix]
+  }
+}
+`
+	p, err := FromHCL([]byte(src), "main.tf", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := p.Resources[0]
+	if want := map[string]any{"name_prefix": "db-master-password-"}; !reflect.DeepEqual(r.Attributes, want) {
+		t.Errorf("attributes = %v, want %v", r.Attributes, want)
+	}
+	if !reflect.DeepEqual(r.IgnoreChanges, []string{"name_prefix"}) || len(r.References) != 0 {
+		t.Errorf("ignore_changes = %v, references = %v", r.IgnoreChanges, r.References)
+	}
+}
+
+func TestFromHCLSyntaxError(t *testing.T) {
+	if _, err := FromHCL([]byte(`resource "aws_vpc" "x" {`), "main.tf", nil); err == nil {
+		t.Error("expected an error for malformed HCL")
+	}
+}
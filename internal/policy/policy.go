@@ -0,0 +1,208 @@
+// Package policy evaluates user-supplied CEL expressions against the plan model
+// (package plan). Each policy is a condition every matching resource must satisfy; a
+// resource for which it evaluates to false is a policy_violation tied to the node that
+// produced the resource.
+//
+// Expressions see two variables: resource, the plan.Resource being checked as a JSON
+// object (address, type, name, node_id, attributes, references), and plan, the whole plan.
+// For example:
+//
+//	resource.type != "aws_db_instance" || resource.attributes.storage_encrypted == true
+//
+// Policies may come from untrusted callers (the Lambda's policies field), so every
+// evaluation is bounded: by a cost limit, and by the context, which is checked inside
+// comprehensions such as all() and exists().
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/google/cel-go/cel"
+	"github.com/json-to-terraform/parser/internal/plan"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// Policy is one rule over the plan.
+type Policy struct {
+	// Name identifies the policy in reports (e.g. "rds-encrypted").
+	Name string `json:"name"`
+	// ResourceTypes limits the policy to these Terraform types; empty means every resource.
+	ResourceTypes []string `json:"resource_types,omitempty"`
+	// Condition is a CEL expression that must evaluate to true for compliant resources.
+	Condition string `json:"condition"`
+	// Message describes a violation; defaults to the condition.
+	Message string `json:"message,omitempty"`
+	// Suggestion tells the author how to comply.
+	Suggestion string `json:"suggestion,omitempty"`
+
+	prg cel.Program
+}
+
+// Set is a list of compiled policies, evaluated in order.
+type Set struct {
+	Policies []*Policy
+}
+
+const (
+	// costLimit bounds the work of one evaluation, in CEL cost units (about one per
+	// operation); conditions over a plan's resources stay far below it.
+	costLimit = 1_000_000
+	// interruptCheckFrequency is how many comprehension iterations run between checks of
+	// the context.
+	interruptCheckFrequency = 100
+)
+
+// env is shared by all policies; both variables are dynamic JSON values.
+var env = func() *cel.Env {
+	e, err := cel.NewEnv(
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("plan", cel.MapType(cel.StringType, cel.DynType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+	if err != nil {
+		panic("policy: " + err.Error())
+	}
+	return e
+}()
+
+// Load reads a policy file (see Parse).
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Parse reads and compiles a policy file:
+//
+//	{"policies": [{"name": "rds-encrypted", "resource_types": ["aws_db_instance"],
+//	  "condition": "resource.attributes.storage_encrypted == true",
+//	  "message": "RDS storage must be encrypted"}]}
+//
+// Every condition must compile to a boolean expression.
+func Parse(data []byte) (*Set, error) {
+	var file struct {
+		Policies []*Policy `json:"policies"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, p := range file.Policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("policy %d: name is required", i)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("policy %s: duplicate name", p.Name)
+		}
+		seen[p.Name] = true
+		if err := p.compile(); err != nil {
+			return nil, err
+		}
+	}
+	return &Set{Policies: file.Policies}, nil
+}
+
+// New compiles a policy built in code.
+func New(name, condition string) (*Policy, error) {
+	p := &Policy{Name: name, Condition: condition}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Policy) compile() error {
+	ast, iss := env.Compile(p.Condition)
+	if iss.Err() != nil {
+		return fmt.Errorf("policy %s: %w", p.Name, iss.Err())
+	}
+	if !ast.OutputType().IsExactType(cel.BoolType) && !ast.OutputType().IsExactType(cel.DynType) {
+		return fmt.Errorf("policy %s: condition must be a boolean, got %s", p.Name, ast.OutputType())
+	}
+	prg, err := env.Program(ast, cel.CostLimit(costLimit), cel.InterruptCheckFrequency(interruptCheckFrequency))
+	if err != nil {
+		return fmt.Errorf("policy %s: %w", p.Name, err)
+	}
+	p.prg = prg
+	return nil
+}
+
+func (p *Policy) applies(resourceType string) bool {
+	if len(p.ResourceTypes) == 0 {
+		return true
+	}
+	for _, t := range p.ResourceTypes {
+		if t == resourceType {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate checks every resource of pl against every policy, in resource then policy order.
+// A false condition is a policy_violation; a condition that fails to evaluate (for example
+// by reading an attribute the resource does not set; guard with has()) or yields a non-boolean
+// is a policy_error, as is one that exceeds the cost limit. Both name the policy in Rule and
+// carry the resource's NodeID. Evaluation stops when ctx is done; callers check ctx.Err().
+func (s *Set) Evaluate(ctx context.Context, pl *plan.Plan) []result.Error {
+	if s == nil || len(s.Policies) == 0 {
+		return nil
+	}
+	m, err := pl.Map()
+	if err != nil {
+		return []result.Error{{Type: "policy_error", Severity: "error", Message: "plan: " + err.Error()}}
+	}
+	resources, _ := m["resources"].([]any)
+
+	var errs []result.Error
+	for i, r := range pl.Resources {
+		for _, p := range s.Policies {
+			if ctx.Err() != nil {
+				return errs
+			}
+			if !p.applies(r.Type) {
+				continue
+			}
+			out, _, err := p.prg.ContextEval(ctx, map[string]any{"resource": resources[i], "plan": m})
+			if err == nil {
+				if ok, isBool := out.Value().(bool); isBool {
+					if !ok {
+						errs = append(errs, p.violation(r))
+					}
+					continue
+				}
+				err = fmt.Errorf("condition returned %v, not a boolean", out.Value())
+			}
+			errs = append(errs, result.Error{
+				Type: "policy_error", Severity: "error", NodeID: r.NodeID, Rule: p.Name,
+				Message:    fmt.Sprintf("%s: condition could not be evaluated: %v", r.Address, err),
+				Suggestion: "Guard optional attributes with has(), e.g. has(resource.attributes.tags) && ...",
+			})
+		}
+	}
+	return errs
+}
+
+func (p *Policy) violation(r plan.Resource) result.Error {
+	msg := p.Message
+	if msg == "" {
+		msg = "condition not met: " + p.Condition
+	}
+	return result.Error{
+		Type: "policy_violation", Severity: "error", NodeID: r.NodeID, Rule: p.Name,
+		Message:    r.Address + ": " + msg,
+		Suggestion: p.Suggestion,
+	}
+}
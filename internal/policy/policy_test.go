@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/plan"
+)
+
+func testPlan() *plan.Plan {
+	return &plan.Plan{FormatVersion: plan.FormatVersion, Resources: []plan.Resource{
+		{Address: "aws_db_instance.db", Type: "aws_db_instance", Name: "db", NodeID: "db",
+			Attributes: map[string]any{"engine": "postgres", "allocated_storage": 20.0, "storage_encrypted": true}},
+		{Address: "aws_db_instance.legacy", Type: "aws_db_instance", Name: "legacy", NodeID: "legacy",
+			Attributes: map[string]any{"engine": "mysql", "allocated_storage": 5000.0}},
+		{Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs", NodeID: "logs",
+			Attributes: map[string]any{"bucket": "logs", "tags": map[string]any{"Owner": "ops"}}},
+	}}
+}
+
+func TestEvaluate(t *testing.T) {
+	set, err := Parse([]byte(`{"policies": [
+		{"name": "rds-encrypted", "resource_types": ["aws_db_instance"],
+		 "condition": "has(resource.attributes.storage_encrypted) && resource.attributes.storage_encrypted",
+		 "message": "RDS storage must be encrypted", "suggestion": "Set storage_encrypted"},
+		{"name": "rds-storage-cap", "resource_types": ["aws_db_instance"],
+		 "condition": "resource.attributes.allocated_storage <= 1000"},
+		{"name": "owner-tag", "condition": "resource.type != 'aws_s3_bucket' || 'Owner' in resource.attributes.tags"},
+		{"name": "at-most-two-databases",
+		 "condition": "plan.resources.filter(r, r.type == 'aws_db_instance').size() <= 2"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := set.Evaluate(context.Background(), testPlan())
+	want := []string{
+		"policy_violation legacy rds-encrypted: aws_db_instance.legacy: RDS storage must be encrypted",
+		"policy_violation legacy rds-storage-cap: aws_db_instance.legacy: condition not met: resource.attributes.allocated_storage <= 1000",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %+v", len(errs), len(want), errs)
+	}
+	for i, e := range errs {
+		if got := e.Type + " " + e.NodeID + " " + e.Rule + ": " + e.Message; got != want[i] {
+			t.Errorf("error %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if errs[0].Suggestion != "Set storage_encrypted" {
+		t.Errorf("suggestion = %q", errs[0].Suggestion)
+	}
+}
+
+func TestEvaluateReportsRuntimeErrors(t *testing.T) {
+	p, err := New("needs-tags", "resource.attributes.tags.Owner == 'ops'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := (&Set{Policies: []*Policy{p}}).Evaluate(context.Background(), testPlan())
+	if len(errs) != 2 {
+		t.Fatalf("got %+v, want errors for the two untagged databases", errs)
+	}
+	for _, e := range errs {
+		if e.Type != "policy_error" || e.Rule != "needs-tags" || !strings.Contains(e.Message, "could not be evaluated") {
+			t.Errorf("error = %+v", e)
+		}
+	}
+}
+
+func TestEvaluateIsBounded(t *testing.T) {
+	// A million iterations of nested comprehensions; the cost limit stops it.
+	digits := "[0, 1, 2, 3, 4, 5, 6, 7, 8, 9]"
+	cond := "true"
+	for _, v := range []string{"a", "b", "c", "d", "e", "f"} {
+		cond = digits + ".all(" + v + ", " + cond + ")"
+	}
+	p, err := New("expensive", cond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &Set{Policies: []*Policy{p}}
+	pl := &plan.Plan{FormatVersion: plan.FormatVersion, Resources: testPlan().Resources[:1]}
+	errs := set.Evaluate(context.Background(), pl)
+	if len(errs) != 1 || errs[0].Type != "policy_error" || !strings.Contains(errs[0].Message, "cost limit") {
+		t.Errorf("got %+v, want a policy_error for the cost limit", errs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if errs := set.Evaluate(ctx, pl); len(errs) != 0 {
+		t.Errorf("cancelled context: got %+v, want evaluation to stop", errs)
+	}
+}
+
+func TestParseRejectsBadPolicies(t *testing.T) {
+	for input, want := range map[string]string{
+		`{"policies": [{"name": "x", "condition": "resource.type =="}]}`:                         "policy x",
+		`{"policies": [{"name": "x", "condition": "resource.type"}]}`:                            "",
+		`{"policies": [{"name": "x", "condition": "1 + 1"}]}`:                                    "must be a boolean",
+		`{"policies": [{"condition": "true"}]}`:                                                  "name is required",
+		`{"policies": [{"name": "x", "condition": "true"}, {"name": "x", "condition": "true"}]}`: "duplicate name",
+		`{"policy": []}`: "unknown field",
+	} {
+		_, err := Parse([]byte(input))
+		if want == "" {
+			// resource.type is dynamic, so it compiles; a non-boolean result is a runtime policy_error.
+			if err != nil {
+				t.Errorf("Parse(%s) = %v, want success", input, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%s) error = %v, want %q", input, err, want)
+		}
+	}
+}
+
+func TestNilSetEvaluatesNothing(t *testing.T) {
+	var s *Set
+	if errs := s.Evaluate(context.Background(), testPlan()); errs != nil {
+		t.Errorf("nil set: %+v", errs)
+	}
+}
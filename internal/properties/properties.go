@@ -0,0 +1,377 @@
+// Package properties decodes node.properties into typed handler structs and derives the
+// handler's JSON Schema from the same struct, so the published schema and the checks the
+// parser runs cannot drift apart.
+//
+// Fields are described with struct tags:
+//
+//	json:"memory_size"                    property name (required; fields without it are ignored)
+//	desc:"Memory in MB."                  description, also used in suggestions
+//	schema:"required,min=128,max=10240"   constraints: required, nonempty, min=N, max=N,
+//	                                      default=V and enum=a|b|c; secret marks the value
+//	                                      writeOnly (see registry.SecretProperties)
+//	pattern:"^ami-[0-9a-f]+$"             regular expression for strings
+//
+// Supported field types are string, bool, int, float64, map[string]string, slices, nested
+// structs (which become closed objects themselves) and pointers to any of these, which stay
+// nil when the property is absent. The fields of embedded structs are promoted, as in
+// encoding/json, so properties shared by several handlers can be declared once. Unknown
+// keys are rejected.
+package properties
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+var schemas sync.Map // reflect.Type -> *jsonschema.Schema
+
+// Schema returns the JSON Schema of the struct v (a value or pointer), described by description.
+func Schema(v any, description string) *jsonschema.Schema {
+	s := *schemaOf(structType(v))
+	s.Description = description
+	return &s
+}
+
+// Decode validates node.Properties against the schema of dst, which must point to a struct,
+// and fills dst with every property that has the right type. Defaults apply to top-level
+// properties that are absent. Problems are returned as validation_errors whose Path is
+// relative to the node ("properties/memory_size"); fields with problems are left zero.
+func Decode(node *diagram.Node, dst any) []result.Error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("properties: Decode needs a pointer to a struct, got %T", dst))
+	}
+	s := schemaOf(rv.Elem().Type())
+	props := Present(node.Properties)
+
+	var errs []result.Error
+	for _, v := range s.Validate(props) {
+		errs = append(errs, result.Error{
+			Type: "validation_error", Severity: "error", NodeID: node.ID,
+			Path:       "properties" + v.Path,
+			Message:    message(v),
+			Suggestion: v.Suggestion,
+		})
+	}
+	fill(rv.Elem(), props, true)
+	return errs
+}
+
+// Present returns props without its null values: a property set to null is treated as
+// absent, as it was before properties were typed.
+func Present(props map[string]any) map[string]any {
+	out := make(map[string]any, len(props))
+	for k, v := range props {
+		if v != nil {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// message prefixes a violation with the property it concerns ("ingress[0].to_port").
+func message(v jsonschema.Violation) string {
+	name := dotted(v.Path)
+	switch v.Keyword {
+	case "required":
+		return name + " is required"
+	case "additionalProperties":
+		return "unknown property " + name
+	}
+	return name + ": " + v.Message
+}
+
+// dotted renders a JSON pointer as "ingress[0].from_port".
+func dotted(ptr string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.TrimPrefix(ptr, "/"), "/") {
+		if part != "" && strings.Trim(part, "0123456789") == "" {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(strings.NewReplacer("~1", "/", "~0", "~").Replace(part))
+	}
+	return b.String()
+}
+
+// fill sets the fields of v from props, skipping values of the wrong type (Decode reports
+// them). With defaults, absent fields get their default.
+func fill(v reflect.Value, props map[string]any, defaults bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if embedded(f) {
+			fill(v.Field(i), props, defaults)
+			continue
+		}
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+		raw, ok := props[name]
+		if !ok {
+			if !defaults {
+				continue
+			}
+			if def, ok := parseTag(f).def(f.Type); ok {
+				v.Field(i).Set(reflect.ValueOf(def).Convert(f.Type))
+			}
+			continue
+		}
+		set(v.Field(i), raw)
+	}
+}
+
+// set stores the JSON value raw in v and reports whether raw has v's type. Lists and maps
+// with a mistyped element are not stored at all.
+func set(v reflect.Value, raw any) bool {
+	switch v.Kind() {
+	case reflect.String:
+		s, ok := raw.(string)
+		if !ok {
+			return false
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return false
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, ok := number(raw)
+		if !ok || n != math.Trunc(n) {
+			return false
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		n, ok := number(raw)
+		if !ok {
+			return false
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		items, ok := raw.([]any)
+		if !ok {
+			return false
+		}
+		out := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if !set(out.Index(i), item) {
+				return false
+			}
+		}
+		v.Set(out)
+	case reflect.Map:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return false
+		}
+		out := reflect.MakeMapWithSize(v.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if !set(elem, item) {
+				return false
+			}
+			out.SetMapIndex(reflect.ValueOf(k), elem)
+		}
+		v.Set(out)
+	case reflect.Struct:
+		m, ok := raw.(map[string]any)
+		if !ok {
+			return false
+		}
+		fill(v, m, false)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if !set(elem.Elem(), raw) {
+			return false
+		}
+		v.Set(elem)
+	default:
+		return false
+	}
+	return true
+}
+
+// number returns a JSON number (float64, or an int from properties built in code).
+func number(raw any) (float64, bool) {
+	switch n := raw.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func structType(v any) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("properties: %T is not a struct", v))
+	}
+	return t
+}
+
+func schemaOf(t reflect.Type) *jsonschema.Schema {
+	if s, ok := schemas.Load(t); ok {
+		return s.(*jsonschema.Schema)
+	}
+	s := objectSchema(t)
+	schemas.Store(t, s)
+	return s
+}
+
+func objectSchema(t reflect.Type) *jsonschema.Schema {
+	s := jsonschema.Object("", map[string]*jsonschema.Schema{}).Closed()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if embedded(f) {
+			inner := objectSchema(f.Type)
+			for name, fs := range inner.Properties {
+				s.Properties[name] = fs
+			}
+			s.Required = append(s.Required, inner.Required...)
+			continue
+		}
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+		tag := parseTag(f)
+		fs := typeSchema(f.Type)
+		fs.Description = f.Tag.Get("desc")
+		fs.Pattern = f.Tag.Get("pattern")
+		if tag.nonempty {
+			one := 1
+			fs.MinLength = &one
+		}
+		fs.Minimum, fs.Maximum = tag.min, tag.max
+		fs.WriteOnly = tag.secret
+		for _, e := range tag.enum {
+			fs.Enum = append(fs.Enum, e)
+		}
+		if def, ok := tag.def(f.Type); ok {
+			fs.Default = def
+		}
+		s.Properties[name] = fs
+		if tag.required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+func typeSchema(t reflect.Type) *jsonschema.Schema {
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonschema.Schema{Type: "string"}
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64:
+		return &jsonschema.Schema{Type: "integer"}
+	case reflect.Float64:
+		return &jsonschema.Schema{Type: "number"}
+	case reflect.Slice:
+		return &jsonschema.Schema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return &jsonschema.Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+		}
+	case reflect.Struct:
+		return objectSchema(t)
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	}
+	panic("properties: unsupported field type " + t.String())
+}
+
+// embedded reports whether f is an embedded struct without a json name, whose fields are promoted.
+func embedded(f reflect.StructField) bool {
+	return f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == ""
+}
+
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" || !f.IsExported() {
+		return ""
+	}
+	return name
+}
+
+type tag struct {
+	required, nonempty bool
+	secret             bool
+	min, max           *float64
+	enum               []string
+	defaultValue       string
+}
+
+func parseTag(f reflect.StructField) tag {
+	var t tag
+	for _, opt := range strings.Split(f.Tag.Get("schema"), ",") {
+		key, val, _ := strings.Cut(opt, "=")
+		switch key {
+		case "":
+		case "required":
+			t.required = true
+		case "nonempty":
+			t.nonempty = true
+		case "secret":
+			t.secret = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				panic(fmt.Sprintf("properties: field %s: bad %s %q", f.Name, key, val))
+			}
+			if key == "min" {
+				t.min = &n
+			} else {
+				t.max = &n
+			}
+		case "enum":
+			t.enum = strings.Split(val, "|")
+		case "default":
+			t.defaultValue = val
+		default:
+			panic(fmt.Sprintf("properties: field %s: unknown schema option %q", f.Name, key))
+		}
+	}
+	return t
+}
+
+// def returns the default value converted to the field's kind.
+func (t tag) def(typ reflect.Type) (any, bool) {
+	if t.defaultValue == "" {
+		return nil, false
+	}
+	switch typ.Kind() {
+	case reflect.String:
+		return t.defaultValue, true
+	case reflect.Bool:
+		return t.defaultValue == "true", true
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.Atoi(t.defaultValue)
+		return n, err == nil
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(t.defaultValue, 64)
+		return n, err == nil
+	}
+	return nil, false
+}
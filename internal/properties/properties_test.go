@@ -0,0 +1,146 @@
+package properties
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+type rule struct {
+	Port  int      `json:"port" schema:"required,min=0,max=65535"`
+	CIDRs []string `json:"cidrs"`
+}
+
+type testProps struct {
+	Name    string            `json:"name" schema:"required,nonempty" desc:"Resource name"`
+	Size    int               `json:"size" schema:"min=1,max=10,default=3"`
+	Kind    string            `json:"kind" schema:"enum=a|b"`
+	Enabled bool              `json:"enabled"`
+	Rules   []rule            `json:"rules"`
+	Extra   *rule             `json:"extra"`
+	Tags    map[string]string `json:"tags"`
+	ignored string
+}
+
+func decode(props map[string]any) (testProps, []string, []string) {
+	var p testProps
+	var msgs, paths []string
+	for _, e := range Decode(&diagram.Node{ID: "n", Properties: props}, &p) {
+		msgs = append(msgs, e.Message)
+		paths = append(paths, e.Path)
+	}
+	return p, msgs, paths
+}
+
+func TestDecodeFillsFieldsAndDefaults(t *testing.T) {
+	p, msgs, _ := decode(map[string]any{
+		"name": "web", "enabled": true,
+		"rules": []any{map[string]any{"port": 22.0, "cidrs": []any{"10.0.0.0/8"}}},
+		"tags":  map[string]any{"Env": "dev"},
+	})
+	if len(msgs) != 0 {
+		t.Fatalf("unexpected errors %q", msgs)
+	}
+	want := testProps{
+		Name: "web", Size: 3, Enabled: true,
+		Rules: []rule{{Port: 22, CIDRs: []string{"10.0.0.0/8"}}},
+		Tags:  map[string]string{"Env": "dev"},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("decoded %+v, want %+v", p, want)
+	}
+}
+
+func TestDecodeTreatsNullAsAbsent(t *testing.T) {
+	p, msgs, _ := decode(map[string]any{"name": "web", "size": nil, "extra": nil, "tags": nil})
+	if len(msgs) != 0 {
+		t.Fatalf("unexpected errors %q", msgs)
+	}
+	if want := (testProps{Name: "web", Size: 3}); !reflect.DeepEqual(p, want) {
+		t.Errorf("decoded %+v, want %+v", p, want)
+	}
+}
+
+func TestDecodeReportsProblems(t *testing.T) {
+	p, msgs, paths := decode(map[string]any{
+		"name":  "web",
+		"size":  "5",
+		"kind":  "c",
+		"rules": []any{map[string]any{"port": 70000.0}, map[string]any{}},
+		"extra": map[string]any{"port": 1.0, "cidr": []any{}},
+		"nmae":  "typo",
+	})
+	wantMsgs := []string{
+		"unknown property extra.cidr",
+		`kind: "c" is not an allowed value`,
+		"unknown property nmae",
+		"rules[0].port: must be at most 65535, got 70000",
+		"rules[1].port is required",
+		"size: expected an integer, got string",
+	}
+	if !reflect.DeepEqual(msgs, wantMsgs) {
+		t.Errorf("messages = %q\nwant %q", msgs, wantMsgs)
+	}
+	if paths[2] != "properties/nmae" || paths[4] != "properties/rules/1/port" {
+		t.Errorf("paths = %q", paths)
+	}
+	if p.Size != 0 || p.Name != "web" {
+		t.Errorf("mistyped fields must stay zero and valid ones must be filled: %+v", p)
+	}
+}
+
+func TestDecodeSuggestsClosestProperty(t *testing.T) {
+	var p testProps
+	errs := Decode(&diagram.Node{ID: "n", Properties: map[string]any{"name": "a", "enabeld": true}}, &p)
+	if len(errs) != 1 || errs[0].Suggestion != `Did you mean "enabled"?` {
+		t.Errorf("errors = %+v", errs)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	s := Schema(testProps{}, "Test properties.")
+	if s.Description != "Test properties." || s.AdditionalProperties == nil {
+		t.Fatalf("schema = %+v", s)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"name"}) {
+		t.Errorf("required = %v", s.Required)
+	}
+	size := s.Properties["size"]
+	if size.Type != "integer" || *size.Minimum != 1 || *size.Maximum != 10 || size.Default != 3 {
+		t.Errorf("size schema = %+v", size)
+	}
+	if rules := s.Properties["rules"]; rules.Items == nil || rules.Items.Properties["port"] == nil {
+		t.Errorf("rules schema = %+v", rules)
+	}
+	if _, ok := s.Properties["ignored"]; ok {
+		t.Error("unexported fields must not be in the schema")
+	}
+	if Schema(testProps{}, "other").Description != "other" || s.Description != "Test properties." {
+		t.Error("Schema must not share the cached root between callers")
+	}
+}
+
+type shared struct {
+	Alias string `json:"alias" pattern:"^[a-z]+$"`
+}
+
+type embeddingProps struct {
+	shared
+	Name string `json:"name" schema:"required"`
+}
+
+func TestDecodePromotesEmbeddedFields(t *testing.T) {
+	var p embeddingProps
+	errs := Decode(&diagram.Node{ID: "n", Properties: map[string]any{"name": "web", "alias": "www"}}, &p)
+	if len(errs) != 0 || p.Alias != "www" || p.Name != "web" {
+		t.Errorf("decoded %+v, errors %+v", p, errs)
+	}
+	errs = Decode(&diagram.Node{ID: "n", Properties: map[string]any{"name": "web", "alias": "WWW"}}, &p)
+	if len(errs) != 1 || errs[0].Path != "properties/alias" {
+		t.Errorf("errors = %+v, want the alias pattern violation", errs)
+	}
+	if s := Schema(embeddingProps{}, ""); s.Properties["alias"] == nil || len(s.Properties) != 2 {
+		t.Errorf("schema properties = %v", s.Properties)
+	}
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/json-to-terraform/parser/internal/diagram"
@@ -8,13 +9,26 @@ import (
 )
 
 // RefMap maps node IDs to Terraform resource addresses (e.g. "node-3" -> "aws_vpc.node_3").
+// The parser hands each tier its own snapshot covering all earlier tiers; handlers run
+// concurrently and must treat it as read-only.
 type RefMap map[string]string
 
-// ResourceHandler is the interface each resource type handler must implement.
+// Clone returns a copy of the map that can be read while the original keeps growing.
+func (m RefMap) Clone() RefMap {
+	out := make(RefMap, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// ResourceHandler is the interface each resource type handler must implement. Validate
+// decodes the node's properties once; GenerateHCL receives what it returned, and is only
+// called when Validate reported no errors.
 type ResourceHandler interface {
 	ResourceType() string
-	Validate(node *diagram.Node) ([]result.Error, []result.Warning)
-	GenerateHCL(node *diagram.Node, d *diagram.Diagram, refs RefMap) ([]byte, error)
+	Validate(node *diagram.Node) (props any, errs []result.Error, warns []result.Warning)
+	GenerateHCL(node *diagram.Node, props any, d *diagram.Diagram, refs RefMap) ([]byte, error)
 }
 
 // Default is the global handler registry.
@@ -46,7 +60,7 @@ func (r *Registry) Get(resourceType string) (ResourceHandler, bool) {
 	return h, ok
 }
 
-// ListSupportedTypes returns all registered resource types.
+// ListSupportedTypes returns all registered resource types, sorted by name.
 func (r *Registry) ListSupportedTypes() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -54,5 +68,6 @@ func (r *Registry) ListSupportedTypes() []string {
 	for t := range r.handlers {
 		types = append(types, t)
 	}
+	sort.Strings(types)
 	return types
 }
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"sort"
+
+	"github.com/json-to-terraform/parser/internal/jsonschema"
+)
+
+// SchemaID is the $id of the generated diagram schema: a relative reference, resolved
+// against wherever the schema is published (json2tf schema -o diagram.schema.json).
+const SchemaID = "diagram.schema.json"
+
+// PropertySchemer is implemented by handlers that declare the shape of node.properties
+// for their resource type. DiagramSchema publishes it under $defs/<type>; handlers enforce
+// it in Validate. The parser checks only the diagram's structure against DiagramSchema.
+type PropertySchemer interface {
+	PropertySchema() *jsonschema.Schema
+}
+
+// PropertySchema returns the property schema declared by the handler for resourceType.
+func (r *Registry) PropertySchema(resourceType string) (*jsonschema.Schema, bool) {
+	h, ok := r.Get(resourceType)
+	if !ok {
+		return nil, false
+	}
+	ps, ok := h.(PropertySchemer)
+	if !ok {
+		return nil, false
+	}
+	return ps.PropertySchema(), true
+}
+
+// SecretProperties returns the names of the top-level properties of resourceType whose
+// schema is writeOnly (passwords and the like), in sorted order.
+func (r *Registry) SecretProperties(resourceType string) []string {
+	ps, ok := r.PropertySchema(resourceType)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for name, s := range ps.Properties {
+		if s.WriteOnly {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DiagramSchema returns a JSON Schema (draft 2020-12) for the whole diagram document.
+// node.type is restricted to the registered types, and node.properties is checked
+// against the handler's property schema selected by node.type.
+func (r *Registry) DiagramSchema() *jsonschema.Schema {
+	types := r.ListSupportedTypes()
+
+	node := jsonschema.Object("A resource in the diagram.", map[string]*jsonschema.Schema{
+		"id":         jsonschema.NonEmptyString("Unique node id, referenced by edges."),
+		"type":       jsonschema.Enum("Resource type.", types...),
+		"label":      jsonschema.String("Display name; used for the Name tag and some resource names."),
+		"position":   {Ref: "#/$defs/position"},
+		"properties": {Type: "object", Description: "Resource settings; the allowed keys depend on type."},
+		"suppress":   jsonschema.Array("Lint rule ids not to report for this node.", jsonschema.NonEmptyString("")),
+	}, "id", "type")
+
+	defs := map[string]*jsonschema.Schema{
+		"metadata": jsonschema.Object("Diagram-level information.", map[string]*jsonschema.Schema{
+			"version":     jsonschema.NonEmptyString("Diagram format version, e.g. \"1.0\"."),
+			"name":        jsonschema.String("Project name."),
+			"description": jsonschema.String("Free-form description."),
+			"environment": jsonschema.String("Deployment environment, e.g. production."),
+			"region":      jsonschema.String("AWS region, e.g. eu-west-1; defaults to us-east-1.").WithPattern(`^[a-z]{2}(-gov)?-[a-z]+-[0-9]$`),
+		}, "version"),
+		"position": jsonschema.Object("Canvas position; ignored by the parser.", map[string]*jsonschema.Schema{
+			"x": {Type: "number"},
+			"y": {Type: "number"},
+		}),
+		"edge": jsonschema.Object("A directed relationship between two nodes.", map[string]*jsonschema.Schema{
+			"id":         jsonschema.String("Edge id."),
+			"source":     jsonschema.NonEmptyString("Id of the source node."),
+			"target":     jsonschema.NonEmptyString("Id of the target node."),
+			"type":       jsonschema.Enum("Relationship; defaults to depends_on.", "contains", "connects_to", "depends_on"),
+			"properties": {Type: "object"},
+		}, "source", "target"),
+		"node": node,
+	}
+	for _, t := range types {
+		ps, ok := r.PropertySchema(t)
+		if !ok {
+			continue
+		}
+		defs[t] = ps
+		node.AllOf = append(node.AllOf, &jsonschema.Schema{
+			If: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{"type": {Const: t}},
+				Required:   []string{"type"},
+			},
+			Then: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{"properties": {Ref: "#/$defs/" + t}},
+			},
+		})
+	}
+
+	return &jsonschema.Schema{
+		SchemaURI: jsonschema.Draft,
+		ID:        SchemaID,
+		Title:     "Architecture diagram",
+		Type:      "object",
+		Properties: map[string]*jsonschema.Schema{
+			"metadata": {Ref: "#/$defs/metadata"},
+			"nodes":    jsonschema.Array("Resources.", &jsonschema.Schema{Ref: "#/$defs/node"}),
+			"edges":    jsonschema.Array("Relationships between resources.", &jsonschema.Schema{Ref: "#/$defs/edge"}),
+		},
+		Required: []string{"metadata"},
+		Defs:     defs,
+	}
+}
@@ -0,0 +1,69 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/registry"
+)
+
+func TestEveryHandlerDeclaresPropertySchema(t *testing.T) {
+	for _, typ := range registry.Default.ListSupportedTypes() {
+		if _, ok := registry.Default.PropertySchema(typ); !ok {
+			t.Errorf("%s handler does not implement PropertySchemer", typ)
+		}
+	}
+}
+
+func TestDiagramSchemaAcceptsTestdata(t *testing.T) {
+	s := registry.Default.DiagramSchema()
+	inputs, _ := filepath.Glob("../../testdata/*.json")
+	if len(inputs) == 0 {
+		t.Fatal("no testdata diagrams found")
+	}
+	for _, in := range inputs {
+		data, err := os.ReadFile(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			t.Fatal(err)
+		}
+		if violations := s.Validate(v); len(violations) > 0 {
+			t.Errorf("%s: %+v", in, violations)
+		}
+	}
+}
+
+func TestDiagramSchemaSelectsPropertiesByType(t *testing.T) {
+	doc := `{
+		"metadata": {"version": "1.0"},
+		"nodes": [
+			{"id": "fn", "type": "lambda_function", "properties": {"runtime": "python3.12", "handler": "h", "memory_size": "512"}},
+			{"id": "x", "type": "queue"}
+		],
+		"edges": [{"source": "fn", "target": "x", "type": "links"}]
+	}`
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, vi := range registry.Default.DiagramSchema().Validate(v) {
+		got = append(got, vi.Path+" "+vi.Keyword)
+	}
+	// Members are visited in key order, so edges come before nodes.
+	want := []string{"/edges/0/type enum", "/nodes/0/properties/memory_size type", "/nodes/1/type enum"}
+	if len(got) != len(want) {
+		t.Fatalf("violations = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("violation %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
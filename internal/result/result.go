@@ -1,5 +1,14 @@
 package result
 
+import (
+	"time"
+
+	"github.com/json-to-terraform/parser/internal/cost"
+	"github.com/json-to-terraform/parser/internal/moved"
+	"github.com/json-to-terraform/parser/internal/plan"
+	"github.com/json-to-terraform/parser/internal/terraform"
+)
+
 // Error represents a validation or generation error (AGENTS.md format).
 type Error struct {
 	Type       string `json:"type"`
@@ -7,6 +16,15 @@ type Error struct {
 	NodeID     string `json:"node_id,omitempty"`
 	Message    string `json:"message"`
 	Suggestion string `json:"suggestion,omitempty"`
+	// Path is a JSON pointer into the diagram (e.g. /nodes/2/properties/ami);
+	// Line and Column locate it in the source when the diagram was decoded from JSON.
+	// Handlers may set a path relative to their node ("properties/ami"); the parser
+	// makes it absolute.
+	Path   string `json:"path,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+	// Rule is the id of the lint rule or the name of the policy that reported the error.
+	Rule string `json:"rule,omitempty"`
 }
 
 // Warning represents a best-practice or non-fatal warning.
@@ -16,12 +34,28 @@ type Warning struct {
 	NodeID     string `json:"node_id,omitempty"`
 	Message    string `json:"message"`
 	Suggestion string `json:"suggestion,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Line       int    `json:"line,omitempty"`
+	Column     int    `json:"column,omitempty"`
+	Rule       string `json:"rule,omitempty"` // lint rule id for lint_warnings
+}
+
+// TierTiming reports how long handlers took for one dependency tier.
+type TierTiming struct {
+	Tier     int
+	Nodes    int
+	Duration time.Duration
 }
 
 // ParseResult is the result of parsing a diagram.
 type ParseResult struct {
-	Success       bool              `json:"success"`
-	TerraformFiles map[string][]byte `json:"-"` // filename -> content
-	Errors        []Error           `json:"errors,omitempty"`
-	Warnings      []Warning         `json:"warnings,omitempty"`
+	Success        bool                `json:"success"`
+	TerraformFiles map[string][]byte   `json:"-"`                    // filename -> content
+	Plan           *plan.Plan          `json:"-"`                    // normalized model of the generated resources
+	Snapshot       *moved.Snapshot     `json:"-"`                    // node -> address mapping to pass as Options.Previous next time
+	SourceMap      terraform.SourceMap `json:"source_map,omitempty"` // generated lines -> diagram nodes and properties
+	Cost           *cost.Estimate      `json:"cost,omitempty"`
+	Errors         []Error             `json:"errors,omitempty"`
+	Warnings       []Warning           `json:"warnings,omitempty"`
+	Tiers          []TierTiming        `json:"-"` // per-tier timings (parser -timings); left out so results are reproducible
 }
@@ -0,0 +1,93 @@
+// Package secrets finds secret property values in a diagram (properties whose schema is
+// writeOnly, such as the RDS master password), refuses them in production diagrams unless
+// explicitly allowed, and scrubs them from parse results so they never reach logs, JSON
+// output or API responses.
+package secrets
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+// Redacted replaces secret values in scrubbed text.
+const Redacted = "(sensitive value)"
+
+// minScrubLength is the shortest value Scrub replaces; shorter strings occur in ordinary
+// messages too often to be removed without mangling them.
+const minScrubLength = 4
+
+// Secret is a secret value set in the diagram.
+type Secret struct {
+	NodeID   string
+	Property string
+	Value    string
+}
+
+// Find returns the non-empty string values of secret properties, in node order.
+func Find(d *diagram.Diagram, reg *registry.Registry) []Secret {
+	if d == nil {
+		return nil
+	}
+	var out []Secret
+	for _, n := range d.Nodes {
+		for _, name := range reg.SecretProperties(n.Type) {
+			if v, ok := n.Properties[name].(string); ok && v != "" {
+				out = append(out, Secret{NodeID: n.ID, Property: name, Value: v})
+			}
+		}
+	}
+	return out
+}
+
+// Check reports a validation_error for every secret when the diagram targets a production
+// environment, unless allow is set.
+func Check(d *diagram.Diagram, found []Secret, allow bool) []result.Error {
+	if allow || !d.Metadata.IsProduction() {
+		return nil
+	}
+	var errs []result.Error
+	for _, s := range found {
+		errs = append(errs, result.Error{
+			Type: "validation_error", Severity: "error", NodeID: s.NodeID, Path: "properties/" + s.Property,
+			Message:    s.Property + " is a secret and must not be kept in plain text in a production diagram",
+			Suggestion: "Remove the value and supply it through the generated sensitive variable, or allow plaintext secrets explicitly",
+		})
+	}
+	return errs
+}
+
+// Scrub replaces every secret value of at least four characters in the messages and
+// suggestions of res with Redacted. Longer values are replaced first so a secret that
+// contains another is not left half-visible.
+func Scrub(res *result.ParseResult, found []Secret) {
+	if res == nil || len(found) == 0 {
+		return
+	}
+	var values []string
+	for _, s := range found {
+		if len(s.Value) >= minScrubLength {
+			values = append(values, s.Value)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	pairs := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		pairs = append(pairs, v, Redacted)
+	}
+	r := strings.NewReplacer(pairs...)
+	for i := range res.Errors {
+		res.Errors[i].Message = r.Replace(res.Errors[i].Message)
+		res.Errors[i].Suggestion = r.Replace(res.Errors[i].Suggestion)
+	}
+	for i := range res.Warnings {
+		res.Warnings[i].Message = r.Replace(res.Warnings[i].Message)
+		res.Warnings[i].Suggestion = r.Replace(res.Warnings[i].Suggestion)
+	}
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	_ "github.com/json-to-terraform/parser/internal/handler"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+)
+
+func testDiagram(env string) *diagram.Diagram {
+	return &diagram.Diagram{
+		Metadata: diagram.Metadata{Version: "1.0", Environment: env},
+		Nodes: []diagram.Node{
+			{ID: "db", Type: "rds_instance", Properties: map[string]any{"engine": "postgres", "password": "hunter22"}},
+			{ID: "gen", Type: "rds_instance", Properties: map[string]any{"engine": "postgres", "generate_password": true}},
+			{ID: "logs", Type: "s3_bucket", Properties: map[string]any{"bucket": "hunter22-logs"}},
+		},
+	}
+}
+
+func TestFind(t *testing.T) {
+	got := Find(testDiagram("dev"), registry.Default)
+	want := []Secret{{NodeID: "db", Property: "password", Value: "hunter22"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	found := []Secret{{NodeID: "db", Property: "password", Value: "hunter22"}}
+	for _, tc := range []struct {
+		env   string
+		allow bool
+		want  int
+	}{
+		{"dev", false, 0},
+		{"Production", false, 1},
+		{"prod", true, 0},
+	} {
+		errs := Check(testDiagram(tc.env), found, tc.allow)
+		if len(errs) != tc.want {
+			t.Errorf("env %q allow %v: errors = %+v, want %d", tc.env, tc.allow, errs, tc.want)
+			continue
+		}
+		for _, e := range errs {
+			if e.NodeID != "db" || e.Path != "properties/password" || e.Type != "validation_error" {
+				t.Errorf("error = %+v", e)
+			}
+		}
+	}
+}
+
+func TestScrub(t *testing.T) {
+	res := &result.ParseResult{
+		Errors:   []result.Error{{Message: `password: "hunter22" is too short`, Suggestion: "Do not reuse hunter22"}},
+		Warnings: []result.Warning{{Message: "bucket hunter22-logs is public"}, {Message: "id abc"}},
+	}
+	Scrub(res, []Secret{{Value: "hunter22"}, {Value: "abc"}})
+	if got := res.Errors[0].Message; got != `password: "(sensitive value)" is too short` {
+		t.Errorf("error message = %q", got)
+	}
+	if got := res.Errors[0].Suggestion; got != "Do not reuse (sensitive value)" {
+		t.Errorf("suggestion = %q", got)
+	}
+	if got := res.Warnings[0].Message; got != "bucket (sensitive value)-logs is public" {
+		t.Errorf("warning = %q", got)
+	}
+	if got := res.Warnings[1].Message; got != "id abc" {
+		t.Errorf("short value scrubbed: %q", got)
+	}
+}
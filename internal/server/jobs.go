@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/jobs"
+)
+
+// jobStatus is the JSON body of the job endpoints.
+type jobStatus struct {
+	*jobs.Job
+	StatusURL string `json:"status_url"`
+	ResultURL string `json:"result_url,omitempty"`
+}
+
+func newJobStatus(job *jobs.Job) jobStatus {
+	st := jobStatus{Job: job, StatusURL: "/v1/jobs/" + job.ID}
+	if job.Done() {
+		st.ResultURL = st.StatusURL + "/result"
+	}
+	return st
+}
+
+// submitJob is POST /v1/jobs: the request of POST /v1/generate, answered at once with 202
+// and the job's status. Options, Accept and the JSON syntax are checked up front, so a
+// malformed request fails now rather than in the job.
+func (s *server) submitJob(w http.ResponseWriter, r *http.Request) {
+	if _, ok := negotiate(r.Header.Get("Accept")); !ok {
+		writeError(w, http.StatusNotAcceptable, "invalid_input", "Accept must allow application/json, application/zip or application/gzip")
+		return
+	}
+	if _, _, err := s.options(r); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_input", err.Error())
+		return
+	}
+	body, status, err := s.readBody(w, r)
+	if err != nil {
+		writeError(w, status, "invalid_input", err.Error())
+		return
+	}
+	if !json.Valid(body) {
+		writeError(w, http.StatusBadRequest, "invalid_json", "invalid diagram JSON")
+		return
+	}
+	job, err := s.cfg.Jobs.Submit(r.Context(), jobs.Request{Query: r.URL.RawQuery, Accept: r.Header.Get("Accept"), Body: body})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "submit job: "+err.Error())
+		return
+	}
+	st := newJobStatus(job)
+	w.Header().Set("Location", st.StatusURL)
+	writeJSON(w, http.StatusAccepted, st)
+}
+
+// job serves GET /v1/jobs/<id> (the job's status) and GET /v1/jobs/<id>/result (the
+// generate response of a finished job, with its status code and content type).
+func (s *server) job(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v1/jobs/"), "/")
+	if rest != "" && rest != "result" {
+		writeError(w, http.StatusNotFound, "invalid_input", "no such endpoint: "+r.URL.Path)
+		return
+	}
+	var job *jobs.Job
+	var body []byte
+	var err error
+	if rest == "" {
+		// A status poll must not read the result body, which can be a large archive
+		job, err = s.cfg.Jobs.Status(r.Context(), id)
+	} else {
+		job, body, err = s.cfg.Jobs.Result(r.Context(), id)
+	}
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		writeError(w, http.StatusNotFound, "invalid_input", fmt.Sprintf("no job %q", id))
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "internal_error", "job: "+err.Error())
+		return
+	}
+	if rest == "" {
+		writeJSON(w, http.StatusOK, newJobStatus(job))
+		return
+	}
+	if !job.Done() {
+		writeError(w, http.StatusConflict, "invalid_input", fmt.Sprintf("job %s is %s; poll /v1/jobs/%s until it is done", id, job.State, id))
+		return
+	}
+	w.Header().Set("Content-Type", job.ContentType)
+	if f, ok := archives[job.ContentType]; ok {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "terraform"+f.Ext))
+	}
+	w.WriteHeader(job.StatusCode)
+	w.Write(body)
+}
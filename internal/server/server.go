@@ -0,0 +1,408 @@
+// Package server exposes the parser over HTTP:
+//
+//	POST /v1/generate  diagram JSON in, generated files out (JSON, zip or tar.gz by Accept)
+//	POST /v1/validate  diagram JSON in, errors and warnings out
+//	GET  /v1/types     supported node types
+//	GET  /v1/schema    diagram JSON Schema
+//	GET  /healthz      liveness
+//
+// With Config.Jobs, generation can also run asynchronously (see package jobs):
+//
+//	POST /v1/jobs               the request of /v1/generate, answered at once with a job id
+//	GET  /v1/jobs/<id>          the job's state
+//	GET  /v1/jobs/<id>/result   the /v1/generate response, once the job is done
+//
+// The request body of generate and validate is the diagram itself; options are query
+// parameters (strict, tfvars, name_from_label, import, validate_schema, node_comments).
+// Lint rules, policies and whether production diagrams may hold plaintext secrets are
+// server configuration, so every diagram is held to the same rules. Request bodies may be
+// gzip-compressed, and JSON responses are gzipped for clients that send
+// Accept-Encoding: gzip.
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/json-to-terraform/parser/internal/archive"
+	"github.com/json-to-terraform/parser/internal/cost"
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"github.com/json-to-terraform/parser/internal/jobs"
+	"github.com/json-to-terraform/parser/internal/parser"
+	"github.com/json-to-terraform/parser/internal/registry"
+	"github.com/json-to-terraform/parser/internal/result"
+	"github.com/json-to-terraform/parser/internal/terraform"
+)
+
+// Config configures the API.
+type Config struct {
+	// Options are the parser options every request starts from; query parameters adjust them.
+	Options parser.Options
+	// MaxBodyBytes limits request bodies; larger ones are rejected with 413 (0 = 5 MiB).
+	MaxBodyBytes int64
+	// Timeout limits the generation of one diagram; slower ones get 503 (0 = no limit).
+	Timeout time.Duration
+	// Logger receives one access log record per request (nil = slog.Default()).
+	Logger *slog.Logger
+	// Jobs enables the asynchronous /v1/jobs endpoints; its API must be the handler New
+	// returns.
+	Jobs *jobs.Service
+}
+
+// DefaultMaxBodyBytes is the request body limit when Config.MaxBodyBytes is 0.
+const DefaultMaxBodyBytes = 5 << 20
+
+// Response is the JSON body of generate and validate. Files maps file names to their
+// content and SourceMap their lines to diagram nodes; both are only set by a successful
+// generate.
+type Response struct {
+	Success   bool                `json:"success"`
+	Errors    []result.Error      `json:"errors,omitempty"`
+	Warnings  []result.Warning    `json:"warnings,omitempty"`
+	Files     map[string]string   `json:"files,omitempty"`
+	SourceMap terraform.SourceMap `json:"source_map,omitempty"`
+	Cost      *cost.Estimate      `json:"cost,omitempty"`
+}
+
+// archives are the media types generate can answer with besides JSON.
+var archives = map[string]archive.Format{
+	"application/zip":    archive.ZipFormat,
+	"application/gzip":   archive.TarGzFormat,
+	"application/x-gzip": archive.TarGzFormat,
+}
+
+type server struct {
+	cfg Config
+	log *slog.Logger
+}
+
+// New returns the API handler.
+func New(cfg Config) http.Handler {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	s := &server{cfg: cfg, log: cfg.Logger}
+	if s.log == nil {
+		s.log = slog.Default()
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/v1/generate", only(http.MethodPost, s.generate))
+	mux.Handle("/v1/validate", only(http.MethodPost, s.validate))
+	mux.Handle("/v1/types", only(http.MethodGet, s.types))
+	mux.Handle("/v1/schema", only(http.MethodGet, s.schema))
+	if cfg.Jobs != nil {
+		mux.Handle("/v1/jobs", only(http.MethodPost, s.submitJob))
+		mux.Handle("/v1/jobs/", only(http.MethodGet, s.job))
+	}
+	mux.Handle("/healthz", only(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	}))
+	return s.accessLog(compression(mux))
+}
+
+// only restricts h to one method (and HEAD for GET); others get 405. The module still
+// targets Go 1.21, whose ServeMux does not match on methods.
+func only(method string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+			w.Header().Set("Allow", method)
+			writeError(w, http.StatusMethodNotAllowed, "invalid_input", fmt.Sprintf("%s requires %s", r.URL.Path, method))
+			return
+		}
+		h(w, r)
+	})
+}
+
+func (s *server) generate(w http.ResponseWriter, r *http.Request) {
+	media, ok := negotiate(r.Header.Get("Accept"))
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, "invalid_input", "Accept must allow application/json, application/zip or application/gzip")
+		return
+	}
+	res, status, resp := s.parse(w, r)
+	if res == nil || !res.Success {
+		writeJSON(w, status, resp)
+		return
+	}
+	if f, ok := archives[media]; ok {
+		var buf bytes.Buffer
+		if err := f.Write(&buf, res.TerraformFiles); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", media)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "terraform"+f.Ext))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+		return
+	}
+	resp.Files = make(map[string]string, len(res.TerraformFiles))
+	for name, content := range res.TerraformFiles {
+		resp.Files[name] = string(content)
+	}
+	resp.SourceMap = res.SourceMap
+	resp.Cost = res.Cost
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// validate runs the whole pipeline, so generation errors and policy violations are found
+// too, but returns only the errors and warnings. A diagram with errors is still a 200.
+func (s *server) validate(w http.ResponseWriter, r *http.Request) {
+	res, status, resp := s.parse(w, r)
+	if res != nil {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, resp)
+}
+
+// parse decodes and parses the request's diagram. res is nil when the request itself was
+// rejected; status and resp then describe the rejection, or otherwise the parse result.
+func (s *server) parse(w http.ResponseWriter, r *http.Request) (res *result.ParseResult, status int, resp Response) {
+	opts, strict, err := s.options(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, errorResponse("invalid_input", err.Error())
+	}
+	body, status, err := s.readBody(w, r)
+	if err != nil {
+		return nil, status, errorResponse("invalid_input", err.Error())
+	}
+	d, err := diagram.Decode(body, strict)
+	if err != nil {
+		return nil, http.StatusBadRequest, Response{Errors: parser.DecodeErrors(err)}
+	}
+
+	ctx := r.Context()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+	res, err = parser.New(opts).ParseContext(ctx, d)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, http.StatusServiceUnavailable, errorResponse("timeout", fmt.Sprintf("generation did not finish within %s", s.cfg.Timeout))
+		}
+		return nil, http.StatusInternalServerError, errorResponse("parse_error", err.Error())
+	}
+	resp = Response{Success: res.Success, Errors: res.Errors, Warnings: res.Warnings}
+	if !res.Success {
+		return res, http.StatusUnprocessableEntity, resp
+	}
+	return res, http.StatusOK, resp
+}
+
+// readBody reads the request body up to the size limit; on error, status is 413 or 400.
+func (s *server) readBody(w http.ResponseWriter, r *http.Request) (body []byte, status int, err error) {
+	body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, s.cfg.MaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit)
+		}
+		return nil, http.StatusBadRequest, fmt.Errorf("read body: %w", err)
+	}
+	return body, http.StatusOK, nil
+}
+
+// options applies the request's query parameters to the configured options; strict
+// reports whether unknown diagram fields are rejected.
+func (s *server) options(r *http.Request) (opts parser.Options, strict bool, err error) {
+	opts = s.cfg.Options
+	q := r.URL.Query()
+	for name, dst := range map[string]*bool{
+		"strict":          &strict,
+		"tfvars":          &opts.EmitTfvars,
+		"name_from_label": &opts.NameFromLabel,
+		"validate_schema": &opts.ValidateSchema,
+		"node_comments":   &opts.NodeComments,
+	} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.ParseBool(v); err != nil {
+				return opts, false, fmt.Errorf("query parameter %s: %q is not a boolean", name, v)
+			}
+		}
+	}
+	if v := q.Get("import"); v != "" {
+		opts.ImportNodes = strings.Split(v, ",")
+	}
+	return opts, strict, nil
+}
+
+func (s *server) types(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string][]string{"types": registry.Default.ListSupportedTypes()})
+}
+
+func (s *server) schema(w http.ResponseWriter, r *http.Request) {
+	data, err := json.MarshalIndent(registry.Default.DiagramSchema(), "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/schema+json")
+	w.Write(append(data, '\n'))
+}
+
+// negotiate picks the response media type from an Accept header: the first listed type
+// that generate can produce. Quality values are not weighed.
+func negotiate(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return "application/json", true
+	}
+	for _, part := range strings.Split(accept, ",") {
+		media, _, _ := strings.Cut(part, ";")
+		switch media = strings.ToLower(strings.TrimSpace(media)); media {
+		case "application/json", "application/*", "*/*":
+			return "application/json", true
+		default:
+			if _, ok := archives[media]; ok {
+				return media, true
+			}
+		}
+	}
+	return "", false
+}
+
+func errorResponse(typ, msg string) Response {
+	return Response{Errors: []result.Error{{Type: typ, Severity: "error", Message: msg}}}
+}
+
+func writeError(w http.ResponseWriter, status int, typ, msg string) {
+	writeJSON(w, status, errorResponse(typ, msg))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// statusRecorder remembers the status and size of a response for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+func (s *server) accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		s.log.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("bytes", rec.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote", r.RemoteAddr),
+		)
+	})
+}
+
+// compression decompresses gzip request bodies (Content-Encoding: gzip) and gzips JSON and
+// text responses for clients that accept it. The body limit applies to the decompressed
+// body, so a small compressed request cannot expand without bound.
+func compression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch enc := strings.ToLower(r.Header.Get("Content-Encoding")); enc {
+		case "", "identity":
+		case "gzip":
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_input", "gzip request body: "+err.Error())
+				return
+			}
+			defer zr.Close()
+			r.Body = zr
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+		default:
+			writeError(w, http.StatusUnsupportedMediaType, "invalid_input", fmt.Sprintf("unsupported Content-Encoding %q: use gzip or none", enc))
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			gw := &gzipWriter{ResponseWriter: w}
+			defer gw.Close()
+			w = gw
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		switch strings.ToLower(strings.TrimSpace(coding)) {
+		case "gzip", "*":
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// gzipWriter compresses the response when its content type is JSON or text; archives are
+// compressed already and pass through.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (g *gzipWriter) WriteHeader(status int) {
+	if g.wroteHeader {
+		return
+	}
+	g.wroteHeader = true
+	h := g.Header()
+	ct := h.Get("Content-Type")
+	if h.Get("Content-Encoding") == "" && (strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "application/schema+json") || strings.HasPrefix(ct, "text/")) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// Close flushes the compressed stream.
+func (g *gzipWriter) Close() error {
+	if g.gz != nil {
+		return g.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/json-to-terraform/parser/internal/handler" // register handlers
+	"github.com/json-to-terraform/parser/internal/jobs"
+	"github.com/json-to-terraform/parser/internal/parser"
+)
+
+func newServer(t *testing.T, logs io.Writer) *httptest.Server {
+	t.Helper()
+	if logs == nil {
+		logs = io.Discard
+	}
+	srv := httptest.NewServer(New(Config{
+		Options:      parser.DefaultOptions(),
+		MaxBodyBytes: 64 << 10,
+		Logger:       slog.New(slog.NewJSONHandler(logs, nil)),
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func diagramJSON(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/single_ec2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func post(t *testing.T, url, accept string, body []byte) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response) Response {
+	t.Helper()
+	var r Response
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestGenerateJSON(t *testing.T) {
+	srv := newServer(t, nil)
+	resp := post(t, srv.URL+"/v1/generate", "", diagramJSON(t))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	r := decode(t, resp)
+	if !r.Success || !strings.Contains(r.Files["main.tf"], `resource "aws_instance"`) || r.Files["terraform.tfvars"] == "" {
+		t.Errorf("response = %+v", r)
+	}
+
+	// query parameters adjust the options
+	r = decode(t, post(t, srv.URL+"/v1/generate?tfvars=false", "", diagramJSON(t)))
+	if _, ok := r.Files["terraform.tfvars"]; ok {
+		t.Error("tfvars=false still generated terraform.tfvars")
+	}
+	if len(r.SourceMap) == 0 || r.SourceMap[0].NodeID != "node-1" || r.SourceMap[0].Path != "/nodes/0" {
+		t.Errorf("source map = %+v", r.SourceMap)
+	}
+
+	r = decode(t, post(t, srv.URL+"/v1/generate?node_comments=true", "", diagramJSON(t)))
+	if !strings.HasPrefix(r.Files["main.tf"], "# node: node-1 (Web Server)\n") {
+		t.Errorf("main.tf with node comments:\n%s", r.Files["main.tf"])
+	}
+}
+
+func TestGenerateArchives(t *testing.T) {
+	srv := newServer(t, nil)
+
+	resp := post(t, srv.URL+"/v1/generate", "application/zip", diagramJSON(t))
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/zip" {
+		t.Fatalf("zip: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) == 0 || zr.File[0].Name != "main.tf" {
+		t.Errorf("zip entries = %v", zr.File)
+	}
+
+	resp = post(t, srv.URL+"/v1/generate", "text/html, application/gzip;q=0.9", diagramJSON(t))
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Disposition"), "terraform.tar.gz") {
+		t.Fatalf("tar.gz: status %d, disposition %q", resp.StatusCode, resp.Header.Get("Content-Disposition"))
+	}
+	if _, err := gzip.NewReader(resp.Body); err != nil {
+		t.Error(err)
+	}
+
+	resp = post(t, srv.URL+"/v1/generate", "text/html", diagramJSON(t))
+	if resp.StatusCode != http.StatusNotAcceptable {
+		t.Errorf("text/html: status = %d", resp.StatusCode)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	srv := newServer(t, nil)
+	tests := []struct {
+		name, query string
+		body        []byte
+		status      int
+		errType     string
+	}{
+		{"malformed", "", []byte(`{"nodes": [`), http.StatusBadRequest, "invalid_json"},
+		{"bad parameter", "?strict=maybe", diagramJSON(t), http.StatusBadRequest, "invalid_input"},
+		{"too large", "", bytes.Repeat([]byte(" "), 65<<10), http.StatusRequestEntityTooLarge, "invalid_input"},
+		{"invalid diagram", "", []byte(`{"version":"1.0","nodes":[{"id":"x","type":"nope"}],"edges":[]}`), http.StatusUnprocessableEntity, ""},
+		// Only the server's configuration may allow plaintext secrets, not the client.
+		{"plaintext secret", "?allow_plaintext_secrets=true", []byte(`{"metadata":{"version":"1.0","environment":"production"},"nodes":[{"id":"db","type":"rds_instance","properties":{"engine":"postgres","instance_class":"db.t3.micro","allocated_storage":20,"password":"hunter2hunter2"}}],"edges":[]}`), http.StatusUnprocessableEntity, "validation_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := post(t, srv.URL+"/v1/generate"+tt.query, "", tt.body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			r := decode(t, resp)
+			if r.Success || len(r.Errors) == 0 || r.Files != nil {
+				t.Fatalf("response = %+v", r)
+			}
+			if tt.errType != "" && r.Errors[0].Type != tt.errType {
+				t.Errorf("error type = %q, want %q", r.Errors[0].Type, tt.errType)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	srv := newServer(t, nil)
+	resp := post(t, srv.URL+"/v1/validate", "", []byte(`{"version":"1.0","nodes":[{"id":"x","type":"nope"}],"edges":[]}`))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if r := decode(t, resp); r.Success || len(r.Errors) == 0 || r.Files != nil {
+		t.Errorf("response = %+v", r)
+	}
+	if r := decode(t, post(t, srv.URL+"/v1/validate", "", diagramJSON(t))); !r.Success || r.Files != nil {
+		t.Errorf("response = %+v", r)
+	}
+}
+
+func TestTypesAndSchema(t *testing.T) {
+	srv := newServer(t, nil)
+	resp, err := http.Get(srv.URL + "/v1/types")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var types struct{ Types []string }
+	json.NewDecoder(resp.Body).Decode(&types)
+	if len(types.Types) == 0 {
+		t.Error("no types listed")
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var schema map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&schema); err != nil || schema["$schema"] == nil {
+		t.Errorf("schema = %v, %v", schema, err)
+	}
+
+	resp, err = http.Get(srv.URL + "/v1/generate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /v1/generate: status = %d", resp.StatusCode)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	srv := newServer(t, &logs)
+	post(t, srv.URL+"/v1/validate", "", diagramJSON(t))
+	var rec map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("access log %q: %v", logs.String(), err)
+	}
+	if rec["method"] != "POST" || rec["path"] != "/v1/validate" || rec["status"] != float64(200) || rec["bytes"].(float64) == 0 {
+		t.Errorf("access log = %v", rec)
+	}
+}
+
+func TestGzip(t *testing.T) {
+	srv := newServer(t, nil)
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	zw.Write(diagramJSON(t))
+	zw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/generate", &body)
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("status %d, Content-Encoding %q", resp.StatusCode, resp.Header.Get("Content-Encoding"))
+	}
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Response
+	if err := json.NewDecoder(zr).Decode(&r); err != nil || !r.Success || r.Files["main.tf"] == "" {
+		t.Errorf("response = %+v, %v", r, err)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL+"/v1/generate", bytes.NewReader(diagramJSON(t)))
+	req.Header.Set("Content-Encoding", "br")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Content-Encoding br: status = %d", resp.StatusCode)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	svc := &jobs.Service{Store: jobs.DirStore{Dir: t.TempDir()}}
+	api := New(Config{Options: parser.DefaultOptions(), Logger: slog.New(slog.NewJSONHandler(io.Discard, nil)), Jobs: svc})
+	svc.API = api
+	queued := make(chan string, 1)
+	svc.Dispatch = func(id string) { queued <- id }
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	resp := post(t, srv.URL+"/v1/jobs?tfvars=false", "application/zip", diagramJSON(t))
+	var st struct{ ID, State string }
+	json.NewDecoder(resp.Body).Decode(&st)
+	if resp.StatusCode != http.StatusAccepted || st.State != "queued" || resp.Header.Get("Location") != "/v1/jobs/"+st.ID {
+		t.Fatalf("submit: status %d, %+v", resp.StatusCode, st)
+	}
+	get := func(path string) *http.Response {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+	if resp := get("/v1/jobs/" + st.ID + "/result"); resp.StatusCode != http.StatusConflict {
+		t.Errorf("result of a queued job: status %d", resp.StatusCode)
+	}
+
+	if err := svc.Run(context.Background(), <-queued); err != nil {
+		t.Fatal(err)
+	}
+	resp = get("/v1/jobs/" + st.ID)
+	var done struct {
+		State      string `json:"state"`
+		StatusCode int    `json:"status_code"`
+		ResultURL  string `json:"result_url"`
+	}
+	json.NewDecoder(resp.Body).Decode(&done)
+	if done.State != "succeeded" || done.StatusCode != 200 || done.ResultURL != "/v1/jobs/"+st.ID+"/result" {
+		t.Fatalf("status = %+v", done)
+	}
+	resp = get(done.ResultURL)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.Header.Get("Content-Type") != "application/zip" || !strings.Contains(resp.Header.Get("Content-Disposition"), "terraform.zip") {
+		t.Errorf("result headers = %v", resp.Header)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name == "terraform.tfvars" {
+			t.Error("tfvars=false was not applied to the job")
+		}
+	}
+
+	if resp := get("/v1/jobs/" + strings.Repeat("0", 32)); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown job: status %d", resp.StatusCode)
+	}
+	if resp := post(t, srv.URL+"/v1/jobs", "", []byte(`{"nodes": [`)); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("malformed diagram: status %d", resp.StatusCode)
+	}
+}
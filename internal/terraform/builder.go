@@ -1,17 +1,40 @@
 package terraform
 
 import (
-	"bytes"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/json-to-terraform/parser/internal/diagram"
 )
 
 // TerraformBuilder collects resource blocks and template content for the final Terraform config.
 type TerraformBuilder struct {
-	resources   [][]byte
-	variables   []byte
-	outputs     []byte
-	versions    []byte
-	tfvars      []byte
-	emitTfvars  bool
+	resources      []fragment
+	variables      []byte
+	extraVariables []fragment
+	outputs        []byte
+	versions       []byte
+	tfvars         []byte
+	moved          fragment
+	imports        fragment
+	emitTfvars     bool
+	nodeComments   bool
+	owners         map[string]*diagram.Node // resource address -> node, for moved and import blocks
+}
+
+// fragment is HCL generated from a node (nil for blocks not generated from one), parsed
+// once for the owners of its resources and the source map.
+type fragment struct {
+	node *diagram.Node
+	src  []byte
+	body *hclsyntax.Body // nil if src does not parse
+}
+
+func newFragment(node *diagram.Node, src []byte) fragment {
+	f := fragment{node: node, src: src}
+	if file, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos); !diags.HasErrors() {
+		f.body, _ = file.Body.(*hclsyntax.Body)
+	}
+	return f
 }
 
 // NewBuilder returns a new TerraformBuilder.
@@ -19,15 +42,31 @@ func NewBuilder(emitTfvars bool) *TerraformBuilder {
 	return &TerraformBuilder{
 		resources:  nil,
 		emitTfvars: emitTfvars,
+		owners:     make(map[string]*diagram.Node),
 	}
 }
 
-// AddResource appends a resource block (raw bytes from handler).
-func (b *TerraformBuilder) AddResource(block []byte) {
+// SetNodeComments writes a "# node: <id> (<label>)" comment above every block generated
+// from a node.
+func (b *TerraformBuilder) SetNodeComments(on bool) {
+	b.nodeComments = on
+}
+
+// AddResource appends the resource blocks node generated (raw bytes from handler).
+func (b *TerraformBuilder) AddResource(node *diagram.Node, block []byte) {
 	if len(block) == 0 {
 		return
 	}
-	b.resources = append(b.resources, block)
+	f := newFragment(node, block)
+	b.resources = append(b.resources, f)
+	if node == nil || f.body == nil {
+		return
+	}
+	for _, blk := range f.body.Blocks {
+		if blk.Type == "resource" && len(blk.Labels) == 2 {
+			b.owners[blk.Labels[0]+"."+blk.Labels[1]] = node
+		}
+	}
 }
 
 // SetVariables sets the variables.tf content.
@@ -35,6 +74,14 @@ func (b *TerraformBuilder) SetVariables(content []byte) {
 	b.variables = content
 }
 
+// AddVariables appends variable blocks declared by node's handler to variables.tf.
+func (b *TerraformBuilder) AddVariables(node *diagram.Node, content []byte) {
+	if len(content) == 0 {
+		return
+	}
+	b.extraVariables = append(b.extraVariables, newFragment(node, content))
+}
+
 // SetOutputs sets the outputs.tf content.
 func (b *TerraformBuilder) SetOutputs(content []byte) {
 	b.outputs = content
@@ -50,30 +97,72 @@ func (b *TerraformBuilder) SetTfvars(content []byte) {
 	b.tfvars = content
 }
 
-// Build returns a map of filename -> content for all Terraform files.
-func (b *TerraformBuilder) Build() map[string][]byte {
+// SetMoved sets the moved.tf content (moved blocks for renamed resources; optional).
+func (b *TerraformBuilder) SetMoved(content []byte) {
+	b.moved = newFragment(nil, content)
+}
+
+// SetImports sets the imports.tf content (import blocks for adopted resources; optional).
+func (b *TerraformBuilder) SetImports(content []byte) {
+	b.imports = newFragment(nil, content)
+}
+
+// Build returns a map of filename -> content for all Terraform files, and the source map
+// of the blocks generated from nodes: those of main.tf and variables.tf, and the moved and
+// import blocks targeting their resources.
+func (b *TerraformBuilder) Build() (map[string][]byte, SourceMap) {
 	out := make(map[string][]byte)
+	var sm SourceMap
 	if len(b.versions) > 0 {
 		out["versions.tf"] = b.versions
 	}
-	if len(b.variables) > 0 {
-		out["variables.tf"] = b.variables
+	vars := &output{name: "variables.tf"}
+	vars.write(b.variables)
+	for i, f := range b.extraVariables {
+		if i == 0 && vars.buf.Len() > 0 {
+			vars.write([]byte("\n"))
+		}
+		render(vars, &sm, f, b.nodeComments, f.nodeOf)
+	}
+	if vars.buf.Len() > 0 {
+		out["variables.tf"] = vars.buf.Bytes()
 	}
-	var mainBuf bytes.Buffer
+	mainTF := &output{name: "main.tf"}
 	for i, r := range b.resources {
 		if i > 0 {
-			mainBuf.WriteString("\n\n")
+			mainTF.write([]byte("\n\n"))
 		}
-		mainBuf.Write(r)
+		render(mainTF, &sm, r, b.nodeComments, r.nodeOf)
 	}
-	if mainBuf.Len() > 0 {
-		out["main.tf"] = mainBuf.Bytes()
+	if mainTF.buf.Len() > 0 {
+		out["main.tf"] = mainTF.buf.Bytes()
 	}
 	if len(b.outputs) > 0 {
 		out["outputs.tf"] = b.outputs
 	}
+	for _, f := range []struct {
+		name    string
+		content fragment
+	}{{"moved.tf", b.moved}, {"imports.tf", b.imports}} {
+		if len(f.content.src) == 0 {
+			continue
+		}
+		o := &output{name: f.name}
+		render(o, &sm, f.content, b.nodeComments, b.targetOf)
+		out[f.name] = o.buf.Bytes()
+	}
 	if b.emitTfvars && len(b.tfvars) > 0 {
 		out["terraform.tfvars"] = b.tfvars
 	}
-	return out
+	return out, sm
+}
+
+// nodeOf attributes every block of the fragment to its node.
+func (f fragment) nodeOf(*hclsyntax.Block) *diagram.Node {
+	return f.node
+}
+
+// targetOf attributes a moved or import block to the node of the resource it targets.
+func (b *TerraformBuilder) targetOf(block *hclsyntax.Block) *diagram.Node {
+	return b.owners[blockAddress(block)]
 }
@@ -1,17 +1,13 @@
 package terraform
 
 import (
-	"strings"
+	"bytes"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/zclconf/go-cty/cty"
 )
 
-// SanitizeName converts a node id to a Terraform-safe resource name (e.g. node-1 -> node_1).
-func SanitizeName(id string) string {
-	return strings.ReplaceAll(id, "-", "_")
-}
-
 // ResourceBlock creates a resource "type" "name" { } block; body can be filled by the caller.
 func ResourceBlock(resourceType, name string) *hclwrite.Block {
 	return hclwrite.NewBlock("resource", []string{resourceType, name})
@@ -46,6 +42,45 @@ func SetAttributeMap(body *hclwrite.Body, name string, m map[string]string) {
 	body.SetAttributeValue(name, cty.MapVal(ctyMap))
 }
 
+// VariableBlock creates a variable "name" block of type string. A sensitive variable has no
+// default, so its value must be supplied at plan time (TF_VAR_name or a tfvars file) and
+// Terraform redacts it in output.
+func VariableBlock(name, description string, sensitive bool) *hclwrite.Block {
+	block := hclwrite.NewBlock("variable", []string{name})
+	body := block.Body()
+	body.SetAttributeValue("description", cty.StringVal(description))
+	body.SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
+	if sensitive {
+		body.SetAttributeValue("sensitive", cty.True)
+	}
+	return block
+}
+
+// SplitVariables separates the variable blocks of a generated fragment from everything
+// else, so handlers can declare the inputs they need next to their resources and the
+// builder can still write them to variables.tf. Either result may be empty.
+func SplitVariables(src []byte) (rest, variables []byte) {
+	f, diags := hclwrite.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return src, nil
+	}
+	vars := hclwrite.NewEmptyFile()
+	for _, b := range f.Body().Blocks() {
+		if b.Type() != "variable" {
+			continue
+		}
+		if len(vars.Body().Blocks()) > 0 {
+			vars.Body().AppendNewline()
+		}
+		vars.Body().AppendBlock(b)
+		f.Body().RemoveBlock(b)
+	}
+	if len(vars.Body().Blocks()) == 0 {
+		return src, nil
+	}
+	return bytes.TrimLeft(hclwrite.Format(f.Bytes()), "\n"), vars.Bytes()
+}
+
 // BlockToBytes formats a block and returns its bytes (with newline).
 func BlockToBytes(block *hclwrite.Block) []byte {
 	f := hclwrite.NewEmptyFile()
@@ -0,0 +1,125 @@
+package terraform
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+	"golang.org/x/text/unicode/norm"
+)
+
+// reserved are names that Terraform gives a meaning of their own (meta-arguments and the
+// roots of references). They are valid resource names, but derived names avoid them so an
+// address never reads like a reference (aws_vpc.count, var.self_password).
+var reserved = map[string]bool{
+	"count": true, "for_each": true, "depends_on": true, "lifecycle": true, "provider": true,
+	"providers": true, "locals": true, "source": true, "version": true, "each": true,
+	"self": true, "var": true, "local": true, "module": true, "data": true, "path": true,
+	"terraform": true, "null": true, "true": true, "false": true,
+}
+
+// SanitizeName converts a node id (or label) to a Terraform identifier: `-` becomes `_`
+// (node-1 -> node_1), accents are dropped (café -> cafe), every other run of characters
+// that is not an ASCII letter or digit becomes one `_` (web.server -> web_server), a leading
+// digit gets a `_` prefix (1-web -> _1_web) and reserved words a `_` suffix (count ->
+// count_). A name without any letter or digit left is replaced by node_ and a hash of s.
+// Names that are already valid identifiers keep their case and underscores unchanged.
+func SanitizeName(s string) string {
+	if name, ok := sanitize(s); ok {
+		return name
+	}
+	sum := sha256.Sum256([]byte(s))
+	return "node_" + hex.EncodeToString(sum[:4])
+}
+
+// sanitize implements SanitizeName; ok is false when s has no letter or digit to keep.
+func sanitize(s string) (name string, ok bool) {
+	var b strings.Builder
+	pending := false // a run of invalid characters, written as one _ before the next valid one
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining mark of a decomposed letter
+		case r == '-' || r == '_':
+			b.WriteByte('_')
+			pending = false
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if pending && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pending = false
+			ok = true
+			b.WriteRune(r)
+		default:
+			if written := b.String(); written == "" || written[len(written)-1] != '_' {
+				pending = true
+			}
+		}
+	}
+	name = b.String()
+	switch {
+	case !ok:
+		return "", false
+	case name[0] >= '0' && name[0] <= '9':
+		name = "_" + name
+	case reserved[name]:
+		name += "_"
+	}
+	return name, true
+}
+
+// ResourceName returns the name of a node's resources: the node's terraform_name property
+// when set, so addresses survive a change of node id, or else the sanitized node id.
+func ResourceName(node *diagram.Node) string {
+	if name, ok := pinnedName(node); ok {
+		return name
+	}
+	return SanitizeName(node.ID)
+}
+
+func pinnedName(node *diagram.Node) (string, bool) {
+	name, ok := node.Properties["terraform_name"].(string)
+	return name, ok && name != ""
+}
+
+// LabelNames derives resource names from node labels, lowercased ("Web Server" ->
+// web_server), for nodes without a terraform_name; nodes without a usable label keep
+// their id-based name. Nodes of the same type whose names collide are disambiguated in
+// diagram order: the first keeps the name, the next ones get _2, _3 and so on, skipping
+// names already in use. The result maps node IDs to names for the nodes whose name differs
+// from ResourceName.
+func LabelNames(nodes []diagram.Node) map[string]string {
+	taken := make(map[string]bool) // type + "." + name
+	labels := make([]string, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		if _, pinned := pinnedName(n); !pinned {
+			if name, ok := sanitize(strings.ToLower(n.Label)); ok {
+				labels[i] = name
+				continue
+			}
+		}
+		// pinned and id-based names are kept, so they claim theirs first
+		taken[n.Type+"."+ResourceName(n)] = true
+	}
+
+	out := make(map[string]string)
+	for i := range nodes {
+		n := &nodes[i]
+		if labels[i] == "" {
+			continue
+		}
+		name := labels[i]
+		for k := 2; taken[n.Type+"."+name]; k++ {
+			name = fmt.Sprintf("%s_%d", labels[i], k)
+		}
+		taken[n.Type+"."+name] = true
+		if name != ResourceName(n) {
+			out[n.ID] = name
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := map[string]string{
+		"vpc-main":      "vpc_main",
+		"Web_Server-01": "Web_Server_01",
+		"node--1":       "node__1",
+		"1-web":         "_1_web",
+		"42":            "_42",
+		"web.server":    "web_server",
+		"node 1":        "node_1",
+		"api (v2)":      "api_v2",
+		"web - server":  "web_server",
+		"café":          "cafe",
+		"Ünïcödé-node":  "Unicode_node",
+		"データベース-1":      "_1",
+		"count":         "count_",
+		"self":          "self_",
+		"counter":       "counter",
+		"_private":      "_private",
+		"データベース":        "node_24be0ba6",
+		"":              "node_e3b0c442",
+	}
+	for in, want := range tests {
+		if got := SanitizeName(in); got != want {
+			t.Errorf("SanitizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	n := &diagram.Node{ID: "vpc-main", Properties: map[string]any{}}
+	if got := ResourceName(n); got != "vpc_main" {
+		t.Errorf("ResourceName() = %q", got)
+	}
+	n.Properties["terraform_name"] = "main"
+	if got := ResourceName(n); got != "main" {
+		t.Errorf("ResourceName() with terraform_name = %q", got)
+	}
+}
+
+func TestLabelNames(t *testing.T) {
+	nodes := []diagram.Node{
+		{ID: "ec2-1", Type: "ec2_instance", Label: "Web Server"},
+		{ID: "ec2-2", Type: "ec2_instance", Label: "web server"},
+		{ID: "web_server_2", Type: "ec2_instance"}, // id-based name claims web_server_2
+		{ID: "ec2-4", Type: "ec2_instance", Label: "Web-Server"},
+		{ID: "ec2-5", Type: "ec2_instance", Label: "Pinned", Properties: map[string]any{"terraform_name": "pinned"}},
+		{ID: "s3-1", Type: "s3_bucket", Label: "Web Server"}, // other type, no collision
+		{ID: "db", Type: "rds_instance", Label: "db"},        // same as the id-based name
+		{ID: "lambda-1", Type: "lambda_function", Label: "⚙️"},
+	}
+	want := map[string]string{
+		"ec2-1": "web_server",
+		"ec2-2": "web_server_3",
+		"ec2-4": "web_server_4",
+		"s3-1":  "web_server",
+	}
+	if got := LabelNames(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("LabelNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,124 @@
+package terraform
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// awsProviderSchemaJSON is hand-trimmed, not captured from `terraform providers schema -json`
+// (whose format it follows): it lists only the resource types the handlers generate for
+// hashicorp/aws ~> 5.0 and hashicorp/random ~> 3.0, and only some of their arguments. An
+// argument the provider accepts may be missing, so such arguments are Unlisted diagnostics
+// (warnings), not errors.
+//
+//go:embed aws_provider_schema.json
+var awsProviderSchemaJSON []byte
+
+// ProviderSchemas mirrors the top-level shape of `terraform providers schema -json`.
+type ProviderSchemas struct {
+	FormatVersion string                    `json:"format_version"`
+	Providers     map[string]ProviderSchema `json:"provider_schemas"`
+}
+
+// ProviderSchema holds the resource schemas of a single provider.
+type ProviderSchema struct {
+	Resources map[string]ResourceSchema `json:"resource_schemas"`
+}
+
+// ResourceSchema is the schema of one resource type.
+type ResourceSchema struct {
+	Version int         `json:"version"`
+	Block   SchemaBlock `json:"block"`
+}
+
+// SchemaBlock describes the attributes and nested blocks allowed in a block body.
+type SchemaBlock struct {
+	Attributes map[string]SchemaAttribute `json:"attributes"`
+	BlockTypes map[string]SchemaBlockType `json:"block_types"`
+}
+
+// SchemaAttribute describes a single attribute. Type is the cty JSON type (e.g. "string" or ["list","string"]).
+type SchemaAttribute struct {
+	Type      json.RawMessage `json:"type"`
+	Required  bool            `json:"required"`
+	Optional  bool            `json:"optional"`
+	Computed  bool            `json:"computed"`
+	Sensitive bool            `json:"sensitive"`
+}
+
+// SchemaBlockType describes a nested block.
+type SchemaBlockType struct {
+	NestingMode string      `json:"nesting_mode"`
+	Block       SchemaBlock `json:"block"`
+	MinItems    int         `json:"min_items"`
+	MaxItems    int         `json:"max_items"`
+}
+
+// ComputedOnly reports whether the attribute is set by the provider and cannot be configured.
+func (a SchemaAttribute) ComputedOnly() bool {
+	return a.Computed && !a.Optional && !a.Required
+}
+
+// objectAttributes returns the attribute names of a list/set(object) type, which Terraform
+// also accepts in nested block syntax (e.g. aws_security_group ingress). ok is false for other types.
+func (a SchemaAttribute) objectAttributes() (names map[string]bool, ok bool) {
+	var t []json.RawMessage
+	if err := json.Unmarshal(a.Type, &t); err != nil || len(t) != 2 {
+		return nil, false
+	}
+	var kind string
+	if err := json.Unmarshal(t[0], &kind); err != nil || (kind != "list" && kind != "set") {
+		return nil, false
+	}
+	var elem []json.RawMessage
+	if err := json.Unmarshal(t[1], &elem); err != nil || len(elem) != 2 {
+		return nil, false
+	}
+	if err := json.Unmarshal(elem[0], &kind); err != nil || kind != "object" {
+		return nil, false
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(elem[1], &attrs); err != nil {
+		return nil, false
+	}
+	names = make(map[string]bool, len(attrs))
+	for k := range attrs {
+		names[k] = true
+	}
+	return names, true
+}
+
+// Resource returns the schema for a resource type across all providers.
+func (s *ProviderSchemas) Resource(resourceType string) (ResourceSchema, bool) {
+	for _, p := range s.Providers {
+		if rs, ok := p.Resources[resourceType]; ok {
+			return rs, true
+		}
+	}
+	return ResourceSchema{}, false
+}
+
+var (
+	bundledOnce   sync.Once
+	bundledSchema *ProviderSchemas
+	bundledErr    error
+)
+
+// BundledSchema returns the embedded provider schema snapshot (parsed once).
+func BundledSchema() (*ProviderSchemas, error) {
+	bundledOnce.Do(func() {
+		bundledSchema, bundledErr = LoadSchema(awsProviderSchemaJSON)
+	})
+	return bundledSchema, bundledErr
+}
+
+// LoadSchema parses the output of `terraform providers schema -json`.
+func LoadSchema(data []byte) (*ProviderSchemas, error) {
+	var s ProviderSchemas
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse provider schema: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,151 @@
+package terraform
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+// Mapping ties a line range of a generated file to the diagram node it was generated from.
+type Mapping struct {
+	File      string `json:"file"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	NodeID    string `json:"node_id"`
+	// Address is the resource the block declares or targets (import, moved), or var.<name>
+	// for a variable. It is empty for the attributes inside a block.
+	Address string `json:"address,omitempty"`
+	// Path is a JSON pointer to the property an attribute or nested block was generated
+	// from. The builder sets it relative to the node (properties/cidr_block); the parser
+	// makes it absolute (/nodes/2/properties/cidr_block).
+	Path string `json:"path,omitempty"`
+}
+
+// SourceMap lists the blocks of the generated files that come from a diagram node, each
+// followed by its attributes and nested blocks named after one of the node's properties.
+type SourceMap []Mapping
+
+// Lookup returns the narrowest mapping of file that contains line: the attribute on that
+// line if it maps to a property, otherwise its block.
+func (m SourceMap) Lookup(file string, line int) (Mapping, bool) {
+	var best Mapping
+	found := false
+	for _, mp := range m {
+		if mp.File != file || line < mp.StartLine || line > mp.EndLine {
+			continue
+		}
+		if !found || mp.EndLine-mp.StartLine < best.EndLine-best.StartLine {
+			best, found = mp, true
+		}
+	}
+	return best, found
+}
+
+// nodeComment is the comment written above the blocks of a node with node comments on.
+func nodeComment(n *diagram.Node) string {
+	c := "# node: " + n.ID
+	if label := strings.Join(strings.Fields(n.Label), " "); label != "" {
+		c += " (" + label + ")"
+	}
+	return c + "\n"
+}
+
+// output is a generated file being written, with the number of lines written so far.
+type output struct {
+	name  string
+	buf   bytes.Buffer
+	lines int
+}
+
+func (o *output) write(p []byte) {
+	o.buf.Write(p)
+	o.lines += bytes.Count(p, []byte("\n"))
+}
+
+// render appends f to o, mapping each of its top-level blocks to the node nodeOf returns
+// for it (blocks without a node are not mapped). With comments, a node comment is written
+// above every mapped block. A fragment that does not parse is mapped as a whole to its node,
+// so its syntax errors can be reported on the node.
+func render(o *output, sm *SourceMap, f fragment, comments bool, nodeOf func(*hclsyntax.Block) *diagram.Node) {
+	if f.body == nil {
+		start := o.lines + 1
+		o.write(f.src)
+		end := o.lines
+		if !bytes.HasSuffix(f.src, []byte("\n")) {
+			end++
+		}
+		if f.node != nil {
+			*sm = append(*sm, Mapping{File: o.name, StartLine: start, EndLine: end, NodeID: f.node.ID})
+		}
+		return
+	}
+	// Line l of f.src is line shift+l of the file: shift counts the lines written before
+	// f and the comments inserted so far.
+	shift := o.lines
+	prev := 0
+	for _, b := range f.body.Blocks {
+		n := nodeOf(b)
+		if n == nil {
+			continue
+		}
+		r := b.Range()
+		if comments {
+			o.write(f.src[prev:r.Start.Byte])
+			o.write([]byte(nodeComment(n)))
+			prev = r.Start.Byte
+			shift++
+		}
+		*sm = append(*sm, Mapping{File: o.name, StartLine: shift + r.Start.Line, EndLine: shift + r.End.Line, NodeID: n.ID, Address: blockAddress(b)})
+		if b.Type == "resource" {
+			*sm = append(*sm, mapBody(b.Body, n.Properties, "properties", o.name, n.ID, shift)...)
+		}
+	}
+	o.write(f.src[prev:])
+}
+
+// mapBody maps the attributes and nested blocks of a resource body whose name is a key of
+// props (the node's properties, or a nested object of them) to that property. Adding shift
+// to a line of the body gives its line in the file.
+func mapBody(body *hclsyntax.Body, props map[string]any, path, file, nodeID string, shift int) []Mapping {
+	var out []Mapping
+	for _, a := range sortedAttributes(body) {
+		if _, ok := props[a.Name]; ok {
+			r := a.Range()
+			out = append(out, Mapping{File: file, StartLine: shift + r.Start.Line, EndLine: shift + r.End.Line, NodeID: nodeID, Path: path + "/" + a.Name})
+		}
+	}
+	for _, b := range body.Blocks {
+		nested, ok := props[b.Type].(map[string]any)
+		if !ok {
+			continue
+		}
+		r := b.Range()
+		out = append(out, Mapping{File: file, StartLine: shift + r.Start.Line, EndLine: shift + r.End.Line, NodeID: nodeID, Path: path + "/" + b.Type})
+		out = append(out, mapBody(b.Body, nested, path+"/"+b.Type, file, nodeID, shift)...)
+	}
+	return out
+}
+
+// blockAddress returns the address a block declares or targets: the labels of a resource,
+// var.<name> for a variable, or the to of an import or moved block.
+func blockAddress(b *hclsyntax.Block) string {
+	switch b.Type {
+	case "resource":
+		if len(b.Labels) == 2 {
+			return b.Labels[0] + "." + b.Labels[1]
+		}
+	case "variable":
+		if len(b.Labels) == 1 {
+			return "var." + b.Labels[0]
+		}
+	case "import", "moved":
+		if to, ok := b.Body.Attributes["to"]; ok {
+			if vars := to.Expr.Variables(); len(vars) == 1 {
+				return traversalAddress(vars[0])
+			}
+		}
+	}
+	return ""
+}
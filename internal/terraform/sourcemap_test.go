@@ -0,0 +1,121 @@
+package terraform
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/diagram"
+)
+
+func TestBuildSourceMap(t *testing.T) {
+	vpc := &diagram.Node{ID: "vpc-main", Label: "Main VPC", Properties: map[string]any{"cidr_block": "10.0.0.0/16"}}
+	ec2 := &diagram.Node{ID: "web", Properties: map[string]any{
+		"ami":               "ami-123",
+		"root_block_device": map[string]any{"volume_size": 20},
+	}}
+	b := NewBuilder(false)
+	b.SetNodeComments(true)
+	b.SetVariables([]byte("variable \"region\" {}\n"))
+	b.AddResource(vpc, []byte(`resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`))
+	b.AddResource(ec2, []byte(`resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+  root_block_device {
+    volume_size = 20
+  }
+}
+`))
+	b.AddVariables(ec2, []byte("variable \"web_password\" {}\n"))
+	b.SetImports([]byte("import {\n  to = aws_vpc.main\n  id = \"vpc-1\"\n}\n"))
+	files, sm := b.Build()
+
+	wantMain := `# node: vpc-main (Main VPC)
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+
+# node: web
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+  root_block_device {
+    volume_size = 20
+  }
+}
+`
+	if got := string(files["main.tf"]); got != wantMain {
+		t.Errorf("main.tf =\n%s\nwant\n%s", got, wantMain)
+	}
+	if got, want := string(files["variables.tf"]), "variable \"region\" {}\n\n# node: web\nvariable \"web_password\" {}\n"; got != want {
+		t.Errorf("variables.tf = %q, want %q", got, want)
+	}
+
+	want := SourceMap{
+		{File: "variables.tf", StartLine: 4, EndLine: 4, NodeID: "web", Address: "var.web_password"},
+		{File: "main.tf", StartLine: 2, EndLine: 4, NodeID: "vpc-main", Address: "aws_vpc.main"},
+		{File: "main.tf", StartLine: 3, EndLine: 3, NodeID: "vpc-main", Path: "properties/cidr_block"},
+		{File: "main.tf", StartLine: 8, EndLine: 14, NodeID: "web", Address: "aws_instance.web"},
+		{File: "main.tf", StartLine: 9, EndLine: 9, NodeID: "web", Path: "properties/ami"},
+		{File: "main.tf", StartLine: 11, EndLine: 13, NodeID: "web", Path: "properties/root_block_device"},
+		{File: "main.tf", StartLine: 12, EndLine: 12, NodeID: "web", Path: "properties/root_block_device/volume_size"},
+		{File: "imports.tf", StartLine: 2, EndLine: 5, NodeID: "vpc-main", Address: "aws_vpc.main"},
+	}
+	if !reflect.DeepEqual(sm, want) {
+		t.Errorf("source map =\n%+v\nwant\n%+v", sm, want)
+	}
+
+	lookups := map[int]string{3: "properties/cidr_block", 10: "", 12: "properties/root_block_device/volume_size"}
+	for line, path := range lookups {
+		if m, ok := sm.Lookup("main.tf", line); !ok || m.Path != path {
+			t.Errorf("Lookup(main.tf, %d) = %+v, %v; want path %q", line, m, ok, path)
+		}
+	}
+	if m, ok := sm.Lookup("main.tf", 6); ok {
+		t.Errorf("Lookup(main.tf, 6) = %+v, want no mapping between blocks", m)
+	}
+}
+
+func TestBuildWithoutComments(t *testing.T) {
+	block := []byte("resource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n")
+	b := NewBuilder(false)
+	b.AddResource(&diagram.Node{ID: "vpc-main"}, block)
+	files, sm := b.Build()
+	if string(files["main.tf"]) != string(block) {
+		t.Errorf("main.tf = %q, want the block unchanged", files["main.tf"])
+	}
+	if len(sm) != 1 || sm[0].StartLine != 1 || sm[0].EndLine != 3 {
+		t.Errorf("source map = %+v", sm)
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	nodes := make([]diagram.Node, 2000)
+	blocks := make([][]byte, len(nodes))
+	for i := range nodes {
+		nodes[i] = diagram.Node{ID: fmt.Sprintf("vpc-%d", i), Properties: map[string]any{"cidr_block": "10.0.0.0/16"}}
+		blocks[i] = []byte(fmt.Sprintf("resource \"aws_vpc\" \"vpc_%d\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n", i))
+	}
+	for i := 0; i < b.N; i++ {
+		bld := NewBuilder(false)
+		bld.SetNodeComments(true)
+		for k := range nodes {
+			bld.AddResource(&nodes[k], blocks[k])
+		}
+		bld.Build()
+	}
+}
+
+func TestBuildMapsUnparsableFragments(t *testing.T) {
+	b := NewBuilder(false)
+	b.AddResource(&diagram.Node{ID: "vpc-main"}, []byte("resource \"aws_vpc\" \"main\" {\n  cidr_block = \"10.0.0.0/16\"\n}\n"))
+	b.AddResource(&diagram.Node{ID: "broken"}, []byte("resource \"aws_vpc\" \"broken\" {\n  cidr_block =\n}\n"))
+	_, sm := b.Build()
+	if m, ok := sm.Lookup("main.tf", 7); !ok || m.NodeID != "broken" || m.StartLine != 6 || m.EndLine != 8 {
+		t.Errorf("Lookup(main.tf, 7) = %+v, %v; want lines 6-8 of node broken", m, ok)
+	}
+}
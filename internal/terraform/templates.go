@@ -1,25 +1,67 @@
 package terraform
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclwrite"
 	"github.com/json-to-terraform/parser/internal/diagram"
 	"github.com/zclconf/go-cty/cty"
 )
 
-// VersionsTF returns content for versions.tf (terraform block + aws provider).
-func VersionsTF() []byte {
+// extraProviders are the providers besides aws that generated resources may need, by local name.
+var extraProviders = map[string]cty.Value{
+	"random": cty.ObjectVal(map[string]cty.Value{
+		"source":  cty.StringVal("hashicorp/random"),
+		"version": cty.StringVal("~> 3.0"),
+	}),
+}
+
+// ProvidersFor returns the local names of the extra providers (see VersionsTF) that the
+// given resource addresses belong to, in sorted order.
+func ProvidersFor(addresses []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, addr := range addresses {
+		name, _, _ := strings.Cut(addr, "_")
+		if _, ok := extraProviders[name]; ok && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Terraform version constraints for versions.tf: the configuration language features the
+// generated files use determine the oldest Terraform that can run them.
+const (
+	RequiredVersion        = ">= 1.0"
+	RequiredVersionMoved   = ">= 1.1" // moved blocks
+	RequiredVersionImports = ">= 1.5" // import blocks
+)
+
+// VersionsTF returns content for versions.tf (terraform block + aws provider). required is
+// the Terraform version constraint (one of the RequiredVersion constants). providers adds
+// further required providers by local name (e.g. "random"); unknown names are ignored.
+func VersionsTF(required string, providers ...string) []byte {
 	f := hclwrite.NewEmptyFile()
 	body := f.Body()
 
 	tfBlock := body.AppendNewBlock("terraform", nil)
 	tfBody := tfBlock.Body()
-	tfBody.SetAttributeValue("required_version", cty.StringVal(">= 1.0"))
+	tfBody.SetAttributeValue("required_version", cty.StringVal(required))
 	reqProv := tfBody.AppendNewBlock("required_providers", nil)
 	reqProv.Body().SetAttributeValue("aws", cty.ObjectVal(map[string]cty.Value{
 		"source":  cty.StringVal("hashicorp/aws"),
 		"version": cty.StringVal("~> 5.0"),
 	}))
+	for _, name := range providers {
+		if req, ok := extraProviders[name]; ok {
+			reqProv.Body().SetAttributeValue(name, req)
+		}
+	}
 
 	body.AppendNewline()
 	provBlock := body.AppendNewBlock("provider", []string{"aws"})
@@ -28,6 +70,27 @@ func VersionsTF() []byte {
 	return f.Bytes()
 }
 
+// Import adopts an existing resource: To is the resource address, ID its provider import id.
+type Import struct {
+	To string
+	ID string
+}
+
+// ImportsTF returns content for imports.tf: one import block per entry, in order.
+func ImportsTF(imports []Import) []byte {
+	f := hclwrite.NewEmptyFile()
+	for i, imp := range imports {
+		if i > 0 {
+			f.Body().AppendNewline()
+		}
+		typ, name, _ := strings.Cut(imp.To, ".")
+		b := f.Body().AppendNewBlock("import", nil).Body()
+		b.SetAttributeTraversal("to", hcl.Traversal{hcl.TraverseRoot{Name: typ}, hcl.TraverseAttr{Name: name}})
+		b.SetAttributeValue("id", cty.StringVal(imp.ID))
+	}
+	return f.Bytes()
+}
+
 // VariablesTF returns content for variables.tf (aws_region and optional vars).
 func VariablesTF() []byte {
 	f := hclwrite.NewEmptyFile()
@@ -36,7 +99,7 @@ func VariablesTF() []byte {
 	// aws_region
 	regionBlock := body.AppendNewBlock("variable", []string{"aws_region"})
 	regionBlock.Body().SetAttributeValue("description", cty.StringVal("AWS region"))
-	regionBlock.Body().SetAttributeValue("type", cty.StringVal("string"))
+	regionBlock.Body().SetAttributeRaw("type", hclwrite.TokensForIdentifier("string"))
 	regionBlock.Body().SetAttributeValue("default", cty.StringVal("us-east-1"))
 
 	return f.Bytes()
@@ -56,7 +119,7 @@ func TfvarsFromMetadata(m *diagram.Metadata) []byte {
 	}
 	f := hclwrite.NewEmptyFile()
 	body := f.Body()
-	body.SetAttributeValue("aws_region", cty.StringVal("us-east-1"))
+	body.SetAttributeValue("aws_region", cty.StringVal(m.AWSRegion()))
 	if m.Environment != "" {
 		// Could add more metadata-driven vars here
 	}
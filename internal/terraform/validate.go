@@ -0,0 +1,351 @@
+package terraform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// Diagnostic is a problem found in generated Terraform.
+type Diagnostic struct {
+	File    string
+	Line    int
+	Address string // enclosing resource address (e.g. aws_vpc.main), empty for file-level problems
+	Message string
+	// Unlisted marks an argument, block or resource type missing from the schema checked
+	// against. The bundled schema is trimmed, so it may still be valid.
+	Unlisted bool
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	case d.File != "":
+		return d.File + ": " + d.Message
+	}
+	return d.Message
+}
+
+// metaArguments are accepted in every resource block regardless of provider schema.
+var metaArguments = map[string]bool{
+	"count": true, "for_each": true, "depends_on": true, "provider": true,
+	"lifecycle": true, "provisioner": true, "connection": true,
+}
+
+// builtinRoots are traversal roots that are always valid in a resource body.
+var builtinRoots = map[string]bool{
+	"path": true, "terraform": true, "count": true, "each": true, "self": true,
+}
+
+// ValidateFiles re-parses generated files and checks that every reference resolves to a declared
+// resource, variable, local, data source or module. When schema is non-nil, resource arguments and
+// nested blocks are also checked against it. Files are checked in name order.
+func ValidateFiles(files map[string][]byte, schema *ProviderSchemas) []Diagnostic {
+	var diags []Diagnostic
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	p := hclparse.NewParser()
+	var bodies []*hclsyntax.Body
+	for _, name := range names {
+		f, hdiags := p.ParseHCL(files[name], name)
+		for _, hd := range hdiags {
+			diags = append(diags, fromHCL(hd, ""))
+		}
+		if hdiags.HasErrors() || !strings.HasSuffix(name, ".tf") {
+			continue
+		}
+		if body, ok := f.Body.(*hclsyntax.Body); ok {
+			bodies = append(bodies, body)
+		}
+	}
+
+	decl := newDeclarations()
+	for _, body := range bodies {
+		decl.collect(body)
+	}
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type == "variable" {
+				// type constraints (type = string) are keywords, not references, but Terraform
+				// 0.15 and later reject the quoted form
+				if typ, ok := block.Body.Attributes["type"]; ok {
+					if _, quoted := typ.Expr.(*hclsyntax.TemplateExpr); quoted {
+						r := typ.Expr.Range()
+						diags = append(diags, Diagnostic{
+							File: r.Filename, Line: r.Start.Line,
+							Message: "quoted type constraints are not supported; write the type without quotes (type = string)",
+						})
+					}
+				}
+				continue
+			}
+			addr := ""
+			if block.Type == "resource" && len(block.Labels) == 2 {
+				addr = block.Labels[0] + "." + block.Labels[1]
+			}
+			traversals := blockTraversals(block.Body)
+			if block.Type == "moved" {
+				// from names a resource that is no longer declared; only to must resolve
+				traversals = nil
+				if to, ok := block.Body.Attributes["to"]; ok {
+					traversals = to.Expr.Variables()
+				}
+			}
+			for _, t := range traversals {
+				if msg := decl.check(t); msg != "" {
+					diags = append(diags, Diagnostic{
+						File: t.SourceRange().Filename, Line: t.SourceRange().Start.Line,
+						Address: addr, Message: msg,
+					})
+				}
+			}
+			if schema != nil && addr != "" {
+				diags = append(diags, checkResourceSchema(block, addr, schema)...)
+			}
+		}
+	}
+	return diags
+}
+
+// ResourceAddresses returns the addresses of resource blocks declared in an HCL fragment.
+func ResourceAddresses(src []byte) []string {
+	f, diags := hclsyntax.ParseConfig(src, "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil
+	}
+	var out []string
+	for _, b := range body.Blocks {
+		if b.Type == "resource" && len(b.Labels) == 2 {
+			out = append(out, b.Labels[0]+"."+b.Labels[1])
+		}
+	}
+	return out
+}
+
+// AddressAt returns the address of the resource whose block in src (a file named filename)
+// spans line: the labels of a resource block, or the target (to) of an import or moved
+// block. It returns "" for lines outside such blocks.
+func AddressAt(src []byte, filename string, line int) string {
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return ""
+	}
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return ""
+	}
+	for _, b := range body.Blocks {
+		if r := b.Range(); line < r.Start.Line || line > r.End.Line {
+			continue
+		}
+		if b.Type == "variable" {
+			return ""
+		}
+		return blockAddress(b)
+	}
+	return ""
+}
+
+// traversalAddress renders a resource reference such as aws_vpc.main as an address.
+func traversalAddress(t hcl.Traversal) string {
+	parts := make([]string, 0, len(t))
+	for _, step := range t {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			parts = append(parts, s.Name)
+		case hcl.TraverseAttr:
+			parts = append(parts, s.Name)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+type declarations struct {
+	resources map[string]bool
+	data      map[string]bool
+	variables map[string]bool
+	locals    map[string]bool
+	modules   map[string]bool
+}
+
+func newDeclarations() *declarations {
+	return &declarations{
+		resources: make(map[string]bool),
+		data:      make(map[string]bool),
+		variables: make(map[string]bool),
+		locals:    make(map[string]bool),
+		modules:   make(map[string]bool),
+	}
+}
+
+func (d *declarations) collect(body *hclsyntax.Body) {
+	for _, b := range body.Blocks {
+		switch {
+		case b.Type == "resource" && len(b.Labels) == 2:
+			d.resources[b.Labels[0]+"."+b.Labels[1]] = true
+		case b.Type == "data" && len(b.Labels) == 2:
+			d.data[b.Labels[0]+"."+b.Labels[1]] = true
+		case b.Type == "variable" && len(b.Labels) == 1:
+			d.variables[b.Labels[0]] = true
+		case b.Type == "module" && len(b.Labels) == 1:
+			d.modules[b.Labels[0]] = true
+		case b.Type == "locals":
+			for name := range b.Body.Attributes {
+				d.locals[name] = true
+			}
+		}
+	}
+}
+
+// check returns a message when the traversal does not resolve, or "" when it does.
+func (d *declarations) check(t hcl.Traversal) string {
+	root := t.RootName()
+	if builtinRoots[root] {
+		return ""
+	}
+	attr := func(i int) string {
+		if i < len(t) {
+			if a, ok := t[i].(hcl.TraverseAttr); ok {
+				return a.Name
+			}
+		}
+		return ""
+	}
+	switch root {
+	case "var":
+		if name := attr(1); !d.variables[name] {
+			return fmt.Sprintf("reference to undeclared input variable %q", name)
+		}
+	case "local":
+		if name := attr(1); !d.locals[name] {
+			return fmt.Sprintf("reference to undeclared local value %q", name)
+		}
+	case "module":
+		if name := attr(1); !d.modules[name] {
+			return fmt.Sprintf("reference to undeclared module %q", name)
+		}
+	case "data":
+		if addr := attr(1) + "." + attr(2); !d.data[addr] {
+			return fmt.Sprintf("reference to undeclared data source \"data.%s\"", addr)
+		}
+	default:
+		if addr := root + "." + attr(1); !d.resources[addr] {
+			return fmt.Sprintf("reference to undeclared resource %q", addr)
+		}
+	}
+	return ""
+}
+
+// blockTraversals returns every variable traversal used in a body, including nested blocks.
+// The attribute names of lifecycle's ignore_changes are not references.
+func blockTraversals(body *hclsyntax.Body) []hcl.Traversal {
+	var out []hcl.Traversal
+	for _, a := range sortedAttributes(body) {
+		if a.Name == "ignore_changes" {
+			continue
+		}
+		out = append(out, a.Expr.Variables()...)
+	}
+	for _, b := range body.Blocks {
+		out = append(out, blockTraversals(b.Body)...)
+	}
+	return out
+}
+
+func checkResourceSchema(block *hclsyntax.Block, addr string, schema *ProviderSchemas) []Diagnostic {
+	rs, ok := schema.Resource(block.Labels[0])
+	if !ok {
+		return []Diagnostic{{
+			File: block.TypeRange.Filename, Line: block.TypeRange.Start.Line, Address: addr,
+			Message:  fmt.Sprintf("resource type %q is not in the provider schema", block.Labels[0]),
+			Unlisted: true,
+		}}
+	}
+	return checkBody(block.Body, rs.Block, addr, true)
+}
+
+func checkBody(body *hclsyntax.Body, sb SchemaBlock, addr string, top bool) []Diagnostic {
+	var diags []Diagnostic
+	add := func(rng hcl.Range, unlisted bool, format string, args ...any) {
+		diags = append(diags, Diagnostic{
+			File: rng.Filename, Line: rng.Start.Line, Address: addr,
+			Message: fmt.Sprintf(format, args...), Unlisted: unlisted,
+		})
+	}
+
+	for _, a := range sortedAttributes(body) {
+		if top && metaArguments[a.Name] {
+			continue
+		}
+		sa, ok := sb.Attributes[a.Name]
+		if !ok {
+			if _, isBlock := sb.BlockTypes[a.Name]; isBlock {
+				add(a.NameRange, false, "%q must be written as a block, not an argument", a.Name)
+			} else {
+				add(a.NameRange, true, "unsupported argument %q", a.Name)
+			}
+			continue
+		}
+		if sa.ComputedOnly() {
+			add(a.NameRange, false, "argument %q is read-only and cannot be set", a.Name)
+		}
+	}
+
+	for _, b := range body.Blocks {
+		if top && metaArguments[b.Type] {
+			continue
+		}
+		if bt, ok := sb.BlockTypes[b.Type]; ok {
+			diags = append(diags, checkBody(b.Body, bt.Block, addr, false)...)
+			continue
+		}
+		// Terraform allows list/set(object) attributes to be written in block syntax.
+		if sa, ok := sb.Attributes[b.Type]; ok {
+			if names, isObj := sa.objectAttributes(); isObj {
+				for _, a := range sortedAttributes(b.Body) {
+					if !names[a.Name] {
+						add(a.NameRange, true, "unsupported argument %q in %s", a.Name, b.Type)
+					}
+				}
+				continue
+			}
+		}
+		add(b.TypeRange, true, "unsupported block type %q", b.Type)
+	}
+	return diags
+}
+
+// sortedAttributes returns body attributes in source order.
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, a := range body.Attributes {
+		attrs = append(attrs, a)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte })
+	return attrs
+}
+
+func fromHCL(hd *hcl.Diagnostic, addr string) Diagnostic {
+	d := Diagnostic{Address: addr, Message: hd.Summary}
+	if hd.Detail != "" {
+		d.Message += ": " + hd.Detail
+	}
+	if hd.Subject != nil {
+		d.File = hd.Subject.Filename
+		d.Line = hd.Subject.Start.Line
+	}
+	return d
+}
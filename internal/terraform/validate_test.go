@@ -0,0 +1,145 @@
+package terraform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateFiles(t *testing.T) {
+	schema, err := BundledSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			name: "valid references",
+			src: `variable "region" {}
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+resource "aws_subnet" "a" {
+  vpc_id     = aws_vpc.main.id
+  cidr_block = "10.0.1.0/24"
+  depends_on = [aws_vpc.main]
+}
+`,
+		},
+		{
+			name: "undeclared references",
+			src: `resource "aws_subnet" "a" {
+  vpc_id            = aws_vpc.missing.id
+  availability_zone = var.zone
+}
+`,
+			want: []string{
+				`main.tf:2: reference to undeclared resource "aws_vpc.missing"`,
+				`main.tf:3: reference to undeclared input variable "zone"`,
+			},
+		},
+		{
+			name: "quoted type constraint",
+			src: `variable "region" {
+  type = "string"
+}
+`,
+			want: []string{`main.tf:2: quoted type constraints are not supported; write the type without quotes (type = string)`},
+		},
+		{
+			name: "moved blocks",
+			src: `resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+moved {
+  from = aws_vpc.vpc_main
+  to   = aws_vpc.main
+}
+moved {
+  from = aws_vpc.old
+  to   = aws_vpc.gone
+}
+`,
+			want: []string{`main.tf:10: reference to undeclared resource "aws_vpc.gone"`},
+		},
+		{
+			name: "schema violations",
+			src: `resource "aws_s3_bucket" "b" {
+  bucket = "x"
+  arn    = "y"
+  public_access_block {
+    block_public_acls = true
+  }
+}
+resource "aws_security_group" "sg" {
+  ingress {
+    from_port = 22
+    bogus     = true
+  }
+}
+`,
+			want: []string{
+				`main.tf:3: argument "arn" is read-only and cannot be set`,
+				`main.tf:4: unsupported block type "public_access_block" (unlisted)`,
+				`main.tf:11: unsupported argument "bogus" in ingress (unlisted)`,
+			},
+		},
+		{
+			name: "syntax error",
+			src:  "resource \"aws_vpc\" \"main\" {\n",
+			want: []string{`main.tf:1: Unclosed configuration block: There is no closing brace for this block before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.`},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, d := range ValidateFiles(map[string][]byte{"main.tf": []byte(tc.src)}, schema) {
+				s := d.String()
+				if d.Unlisted {
+					s += " (unlisted)"
+				}
+				got = append(got, s)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ValidateFiles() =\n%q\nwant\n%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResourceAddresses(t *testing.T) {
+	src := []byte(`resource "aws_s3_bucket" "b" {}
+
+resource "aws_s3_bucket_public_access_block" "b" {}
+`)
+	want := []string{"aws_s3_bucket.b", "aws_s3_bucket_public_access_block.b"}
+	if got := ResourceAddresses(src); !reflect.DeepEqual(got, want) {
+		t.Errorf("ResourceAddresses() = %q, want %q", got, want)
+	}
+}
+
+func TestAddressAt(t *testing.T) {
+	src := []byte(`resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+import {
+  to = aws_vpc.main
+  id = "vpc-0abc"
+}
+
+variable "x" {}
+`)
+	tests := map[int]string{1: "aws_vpc.main", 2: "aws_vpc.main", 3: "aws_vpc.main", 4: "", 7: "aws_vpc.main", 10: "", 99: ""}
+	for line, want := range tests {
+		if got := AddressAt(src, "main.tf", line); got != want {
+			t.Errorf("AddressAt(line %d) = %q, want %q", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package tfcli runs the Terraform CLI (terraform, or OpenTofu's tofu) on generated files:
+// fmt -check, init -backend=false and validate -json, and optionally plan. Diagnostics are
+// returned with the address of the resource they concern, so they can be attributed to the
+// diagram nodes that produced it.
+package tfcli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/json-to-terraform/parser/internal/terraform"
+)
+
+// Runner runs the CLI.
+type Runner struct {
+	// Binary is the path of terraform or tofu; see Find.
+	Binary string
+	// Plan also runs plan (without refresh) once validate passes. The AWS provider needs
+	// credentials for it, and variables without a default need values (TF_VAR_<name>).
+	Plan bool
+	// Env is added to the environment of every command, e.g. TF_PLUGIN_CACHE_DIR=...
+	Env []string
+}
+
+// Diagnostic is a problem reported by the CLI. Address is the resource it concerns, ""
+// for problems outside a resource (providers, variables).
+type Diagnostic struct {
+	terraform.Diagnostic
+	Severity string // error or warning
+}
+
+// Result is what Check found.
+type Result struct {
+	Diagnostics []Diagnostic
+	// Summary is plan's change summary ("Plan: 3 to add, 0 to change, 0 to destroy."),
+	// empty when plan did not run.
+	Summary string
+}
+
+// Find returns the path of the CLI binary: name when given (a path, or looked up in
+// PATH), otherwise terraform or else tofu from PATH.
+func Find(name string) (string, error) {
+	if name != "" {
+		return exec.LookPath(name)
+	}
+	for _, candidate := range []string{"terraform", "tofu"} {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("neither terraform nor tofu found in PATH")
+}
+
+// Check writes files into a temporary directory and runs fmt -check, init -backend=false,
+// validate and, with Plan, plan there. A failing init (no network to download providers,
+// for example) is an error; findings are diagnostics.
+func (r *Runner) Check(ctx context.Context, files map[string][]byte) (*Result, error) {
+	dir, err := os.MkdirTemp("", "json2tf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{}
+	// fmt exits non-zero when files differ; the listed names are what matters
+	out, _, _ := r.run(ctx, dir, "fmt", "-check", "-list=true", "-no-color")
+	for _, name := range strings.Fields(string(out)) {
+		res.Diagnostics = append(res.Diagnostics, Diagnostic{
+			Diagnostic: terraform.Diagnostic{File: name, Message: "not formatted as terraform fmt would format it"},
+			Severity:   "warning",
+		})
+	}
+
+	if _, stderr, err := r.run(ctx, dir, "init", "-backend=false", "-input=false", "-no-color"); err != nil {
+		return nil, fmt.Errorf("init: %w%s", err, lastLines(stderr))
+	}
+
+	out, stderr, err := r.run(ctx, dir, "validate", "-json", "-no-color")
+	var v struct {
+		Diagnostics []jsonDiagnostic `json:"diagnostics"`
+	}
+	if jerr := json.Unmarshal(out, &v); jerr != nil {
+		if err == nil {
+			err = jerr
+		}
+		return nil, fmt.Errorf("validate: %w%s", err, lastLines(stderr))
+	}
+	res.Diagnostics = append(res.Diagnostics, convert(v.Diagnostics, files)...)
+	if !r.Plan || hasErrors(res.Diagnostics) {
+		return res, nil
+	}
+
+	// plan -json streams one message per line; its exit status only repeats the diagnostics
+	out, stderr, err = r.run(ctx, dir, "plan", "-json", "-input=false", "-lock=false", "-refresh=false", "-no-color")
+	var diags []jsonDiagnostic
+	messages := 0
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var m struct {
+			Type       string          `json:"type"`
+			Message    string          `json:"@message"`
+			Diagnostic *jsonDiagnostic `json:"diagnostic"`
+		}
+		if json.Unmarshal(sc.Bytes(), &m) != nil {
+			continue
+		}
+		messages++
+		switch {
+		case m.Type == "diagnostic" && m.Diagnostic != nil:
+			diags = append(diags, *m.Diagnostic)
+		case m.Type == "change_summary":
+			res.Summary = m.Message
+		}
+	}
+	if messages == 0 && err != nil {
+		return nil, fmt.Errorf("plan: %w%s", err, lastLines(stderr))
+	}
+	res.Diagnostics = append(res.Diagnostics, convert(diags, files)...)
+	return res, nil
+}
+
+// jsonDiagnostic is a diagnostic in the CLI's JSON output.
+type jsonDiagnostic struct {
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	Detail   string `json:"detail"`
+	Address  string `json:"address"`
+	Range    *struct {
+		Filename string `json:"filename"`
+		Start    struct {
+			Line int `json:"line"`
+		} `json:"start"`
+	} `json:"range"`
+}
+
+// convert attributes diagnostics to resources: by the address the CLI reports (plan does,
+// for resource instances) or else by the block at the diagnostic's line.
+func convert(in []jsonDiagnostic, files map[string][]byte) []Diagnostic {
+	out := make([]Diagnostic, 0, len(in))
+	for _, jd := range in {
+		d := Diagnostic{Severity: jd.Severity, Diagnostic: terraform.Diagnostic{Address: jd.Address, Message: jd.Summary}}
+		if jd.Detail != "" {
+			d.Message += ": " + jd.Detail
+		}
+		if jd.Range != nil {
+			d.File, d.Line = jd.Range.Filename, jd.Range.Start.Line
+			if d.Address == "" {
+				d.Address = terraform.AddressAt(files[d.File], d.File, d.Line)
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func hasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Runner) run(ctx context.Context, dir string, args ...string) (stdout, stderr []byte, err error) {
+	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1", "CHECKPOINT_DISABLE=1")
+	cmd.Env = append(cmd.Env, r.Env...)
+	var out, errOut bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &errOut
+	err = cmd.Run()
+	return out.Bytes(), errOut.Bytes(), err
+}
+
+// lastLines returns the end of a command's stderr for an error message.
+func lastLines(stderr []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	if len(lines) > 5 {
+		lines = lines[len(lines)-5:]
+	}
+	if s := strings.TrimSpace(strings.Join(lines, "\n")); s != "" {
+		return ": " + s
+	}
+	return ""
+}
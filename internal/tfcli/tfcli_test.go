@@ -0,0 +1,117 @@
+package tfcli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/json-to-terraform/parser/internal/terraform"
+)
+
+// fakeTerraform writes a shell script standing in for terraform: fmt lists unformatted.tf
+// when it exists, validate prints validate.json and plan prints plan.jsonl from the
+// script's directory. Every call is appended to calls.
+func fakeTerraform(t *testing.T, validate, plan string) (bin, dir string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake terraform is a shell script")
+	}
+	dir = t.TempDir()
+	script := `#!/bin/sh
+d=$(dirname "$0")
+echo "$@" >> "$d/calls"
+case "$1" in
+fmt) if [ -f unformatted.tf ]; then echo unformatted.tf; exit 3; fi ;;
+init) [ -f main.tf ] || { echo "no main.tf" >&2; exit 1; } ;;
+validate) cat "$d/validate.json"; grep -q '"valid": *false' "$d/validate.json" && exit 1 ;;
+plan) cat "$d/plan.jsonl" ;;
+esac
+exit 0
+`
+	bin = filepath.Join(dir, "terraform")
+	for name, content := range map[string]string{"terraform": script, "validate.json": validate, "plan.jsonl": plan} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bin, dir
+}
+
+var files = map[string][]byte{
+	"main.tf": []byte(`resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+  colour     = "blue"
+}
+`),
+	"unformatted.tf": []byte("variable   \"x\"   {}\n"),
+}
+
+func TestCheckValidate(t *testing.T) {
+	bin, dir := fakeTerraform(t, `{"valid": false, "diagnostics": [
+		{"severity": "error", "summary": "Unsupported argument", "detail": "An argument named \"colour\" is not expected here.",
+		 "range": {"filename": "main.tf", "start": {"line": 3, "column": 3}}},
+		{"severity": "warning", "summary": "Deprecated", "detail": ""}
+	]}`, "")
+	res, err := (&Runner{Binary: bin, Plan: true}).Check(context.Background(), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Diagnostic{
+		{Severity: "warning", Diagnostic: terraform.Diagnostic{File: "unformatted.tf", Message: "not formatted as terraform fmt would format it"}},
+		{Severity: "error", Diagnostic: terraform.Diagnostic{File: "main.tf", Line: 3, Address: "aws_vpc.main",
+			Message: `Unsupported argument: An argument named "colour" is not expected here.`}},
+		{Severity: "warning", Diagnostic: terraform.Diagnostic{Message: "Deprecated"}},
+	}
+	if !reflect.DeepEqual(res.Diagnostics, want) {
+		t.Errorf("diagnostics = %+v, want %+v", res.Diagnostics, want)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if strings.Contains(string(calls), "plan") || !strings.Contains(string(calls), "init -backend=false") {
+		t.Errorf("calls = %q; plan must not run after validate errors", calls)
+	}
+}
+
+func TestCheckPlan(t *testing.T) {
+	bin, _ := fakeTerraform(t, `{"valid": true, "diagnostics": []}`, `{"@level":"info","@message":"Terraform 1.9.0","type":"version"}
+{"@level":"error","@message":"Error: invalid CIDR","type":"diagnostic","diagnostic":{"severity":"error","summary":"invalid CIDR","detail":"","address":"aws_vpc.main"}}
+{"@level":"info","@message":"Plan: 1 to add, 0 to change, 0 to destroy.","type":"change_summary","changes":{"add":1}}
+`)
+	res, err := (&Runner{Binary: bin, Plan: true}).Check(context.Background(), map[string][]byte{"main.tf": files["main.tf"]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Summary != "Plan: 1 to add, 0 to change, 0 to destroy." {
+		t.Errorf("summary = %q", res.Summary)
+	}
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Address != "aws_vpc.main" || res.Diagnostics[0].Message != "invalid CIDR" {
+		t.Errorf("diagnostics = %+v", res.Diagnostics)
+	}
+}
+
+func TestCheckInitFails(t *testing.T) {
+	bin, _ := fakeTerraform(t, `{}`, "")
+	_, err := (&Runner{Binary: bin}).Check(context.Background(), map[string][]byte{"other.tf": nil})
+	if err == nil || !strings.Contains(err.Error(), "init") || !strings.Contains(err.Error(), "no main.tf") {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	bin, dir := fakeTerraform(t, "", "")
+	if got, err := Find(bin); err != nil || got != bin {
+		t.Errorf("Find(%q) = %q, %v", bin, got, err)
+	}
+	t.Setenv("PATH", dir)
+	if got, err := Find(""); err != nil || got != bin {
+		t.Errorf("Find() = %q, %v", got, err)
+	}
+	t.Setenv("PATH", t.TempDir())
+	if _, err := Find(""); err == nil {
+		t.Error("Find() without terraform or tofu succeeded")
+	}
+}
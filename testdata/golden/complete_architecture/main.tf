@@ -0,0 +1,174 @@
+resource "aws_vpc" "vpc_main" {
+  cidr_block           = "10.0.0.0/16"
+  enable_dns_hostnames = true
+  enable_dns_support   = true
+  tags = {
+    Environment = "production"
+    Name        = "main-vpc"
+    Tier        = "network"
+  }
+}
+
+
+resource "aws_s3_bucket" "s3_app_data" {
+  bucket = "my-app-data-bucket-unique-12345"
+  tags = {
+    Environment = "production"
+    Name        = "app-data"
+    Purpose     = "uploads-and-assets"
+  }
+}
+
+resource "aws_s3_bucket_versioning" "s3_app_data" {
+  bucket = aws_s3_bucket.s3_app_data.id
+  versioning_configuration {
+    status = "Enabled"
+  }
+}
+
+resource "aws_s3_bucket_public_access_block" "s3_app_data" {
+  bucket              = aws_s3_bucket.s3_app_data.id
+  block_public_acls   = true
+  block_public_policy = true
+}
+
+
+resource "aws_subnet" "subnet_public_1a" {
+  cidr_block              = "10.0.1.0/24"
+  availability_zone       = "us-east-1a"
+  map_public_ip_on_launch = true
+  vpc_id                  = aws_vpc.vpc_main.id
+  tags = {
+    Name = "public-1a"
+    Type = "public"
+  }
+}
+
+
+resource "aws_subnet" "subnet_public_1b" {
+  cidr_block              = "10.0.2.0/24"
+  availability_zone       = "us-east-1b"
+  map_public_ip_on_launch = true
+  vpc_id                  = aws_vpc.vpc_main.id
+  tags = {
+    Name = "public-1b"
+    Type = "public"
+  }
+}
+
+
+resource "aws_subnet" "subnet_private_1a" {
+  cidr_block              = "10.0.10.0/24"
+  availability_zone       = "us-east-1a"
+  map_public_ip_on_launch = false
+  vpc_id                  = aws_vpc.vpc_main.id
+  tags = {
+    Name = "private-1a"
+    Type = "private"
+  }
+}
+
+
+resource "aws_security_group" "sg_web" {
+  name        = "web-sg"
+  description = "Allow HTTP/HTTPS and SSH for web instances"
+  vpc_id      = aws_vpc.vpc_main.id
+  ingress {
+    from_port   = 80
+    to_port     = 80
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/16"]
+  }
+  egress {
+    from_port   = 0
+    to_port     = 0
+    protocol    = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  tags = {
+    Name = "web-sg"
+  }
+}
+
+
+resource "aws_security_group" "sg_db" {
+  name        = "db-sg"
+  description = "Allow PostgreSQL from VPC only"
+  vpc_id      = aws_vpc.vpc_main.id
+  ingress {
+    from_port   = 5432
+    to_port     = 5432
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/16"]
+  }
+  egress {
+    from_port   = 0
+    to_port     = 0
+    protocol    = "-1"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+  tags = {
+    Name = "db-sg"
+  }
+}
+
+
+resource "aws_instance" "ec2_web_1" {
+  ami                    = "ami-0c55b159cbfafe1f0"
+  instance_type          = "t3.small"
+  key_name               = "my-key"
+  subnet_id              = aws_subnet.subnet_public_1a.id
+  vpc_security_group_ids = [aws_security_group.sg_web.id]
+  tags = {
+    Environment = "production"
+    Name        = "web-server-1"
+    Role        = "web"
+  }
+}
+
+
+resource "aws_instance" "ec2_web_2" {
+  ami                    = "ami-0c55b159cbfafe1f0"
+  instance_type          = "t3.small"
+  key_name               = "my-key"
+  subnet_id              = aws_subnet.subnet_public_1b.id
+  vpc_security_group_ids = [aws_security_group.sg_web.id]
+  tags = {
+    Environment = "production"
+    Name        = "web-server-2"
+    Role        = "web"
+  }
+}
+
+
+resource "aws_db_instance" "rds_main" {
+  engine                  = "postgres"
+  engine_version          = "15.4"
+  instance_class          = "db.t3.micro"
+  allocated_storage       = 20
+  storage_type            = "gp3"
+  db_name                 = "appdb"
+  username                = "dbadmin"
+  password                = var.rds_main_password
+  skip_final_snapshot     = true
+  backup_retention_period = 7
+  multi_az                = false
+  vpc_security_group_ids  = [aws_security_group.sg_db.id]
+  tags = {
+    Environment = "production"
+    Name        = "main-postgres"
+    Role        = "database"
+  }
+}
@@ -0,0 +1,11 @@
+variable "aws_region" {
+  description = "AWS region"
+  type        = string
+  default     = "us-east-1"
+}
+
+variable "rds_main_password" {
+  description = "Master password of rds-main"
+  type        = string
+  sensitive   = true
+}
@@ -0,0 +1,15 @@
+resource "aws_lambda_function" "node_lambda" {
+  runtime       = "python3.9"
+  handler       = "index.handler"
+  memory_size   = 256
+  timeout       = 60
+  function_name = "Data Processor"
+  environment {
+    variables = {
+      STAGE = "prod"
+    }
+  }
+  tags = {
+    Name = "Data Processor"
+  }
+}
@@ -0,0 +1,34 @@
+resource "random_password" "rds_main" {
+  length           = 32
+  special          = true
+  override_special = "!#$%&*()-_=+[]{}<>:?"
+}
+
+resource "aws_secretsmanager_secret" "rds_main_password" {
+  name_prefix = "rds-main-master-password-"
+  description = "Master password of rds-main"
+  lifecycle {
+    ignore_changes = [name_prefix]
+  }
+}
+
+resource "aws_secretsmanager_secret_version" "rds_main_password" {
+  secret_id     = aws_secretsmanager_secret.rds_main_password.id
+  secret_string = random_password.rds_main.result
+}
+
+resource "aws_db_instance" "rds_main" {
+  engine                  = "postgres"
+  engine_version          = "15.4"
+  instance_class          = "db.t3.micro"
+  allocated_storage       = 20
+  db_name                 = "appdb"
+  username                = "dbadmin"
+  password                = random_password.rds_main.result
+  backup_retention_period = 7
+  multi_az                = true
+  storage_encrypted       = true
+  tags = {
+    Name = "PostgreSQL Database"
+  }
+}
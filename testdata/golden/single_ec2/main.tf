@@ -0,0 +1,9 @@
+resource "aws_instance" "node_1" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = "t3.micro"
+  key_name      = "my-key"
+  tags = {
+    Environment = "production"
+    Name        = "WebServer"
+  }
+}
@@ -0,0 +1,30 @@
+resource "aws_vpc" "node_vpc" {
+  cidr_block           = "10.0.0.0/16"
+  enable_dns_hostnames = true
+  enable_dns_support   = true
+  tags = {
+    Name = "Main VPC"
+  }
+}
+
+
+resource "aws_subnet" "node_subnet" {
+  cidr_block              = "10.0.1.0/24"
+  availability_zone       = "us-east-1a"
+  map_public_ip_on_launch = false
+  vpc_id                  = aws_vpc.node_vpc.id
+  tags = {
+    Name = "Public Subnet"
+  }
+}
+
+
+resource "aws_instance" "node_ec2" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = "t3.micro"
+  key_name      = "my-key"
+  subnet_id     = aws_subnet.node_subnet.id
+  tags = {
+    Name = "WebServer"
+  }
+}